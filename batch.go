@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/marianogappa/signal-checker/common"
+	"github.com/marianogappa/signal-checker/signalchecker"
+)
+
+// maxBatchLineBytes is the longest input line a batch accepts.
+const maxBatchLineBytes = 10 * 1024 * 1024
+
+// batchSummary is printed as the last line of a batch, after all outputs.
+type batchSummary struct {
+	Total       int `json:"total"`
+	Errors      int `json:"errors"`
+	Entered     int `json:"entered"`
+	TookProfit  int `json:"tookProfit"`
+	StoppedLoss int `json:"stoppedLoss"`
+	Liquidated  int `json:"liquidated"`
+}
+
+func (s *batchSummary) add(output common.SignalCheckOutput) {
+	s.Total++
+	if output.IsError {
+		s.Errors++
+		return
+	}
+	if output.Entered {
+		s.Entered++
+	}
+	if output.HighestTakeProfit > 0 {
+		s.TookProfit++
+	}
+	if output.ReachedStopLoss {
+		s.StoppedLoss++
+	}
+	if output.Liquidated {
+		s.Liquidated++
+	}
+}
+
+// checkBatch checks one JSON input per line of r on the given number of concurrent workers, and writes one JSON output
+// per line to w, in the same order as the inputs. Empty lines are skipped. Errors are prefixed with the input's line
+// number, so that they can be traced back to the input.
+func checkBatch(r io.Reader, w io.Writer, workers int, opts []signalchecker.Option) (batchSummary, error) {
+	if workers < 1 {
+		workers = 1
+	}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxBatchLineBytes)
+
+	// Each input gets a channel for its output, queued in input order. The queue's buffer limits how far ahead of the
+	// slowest pending check the workers can go.
+	queue := make(chan chan common.SignalCheckOutput, workers)
+	slots := make(chan struct{}, workers)
+	var scanErr error
+	go func() {
+		defer close(queue)
+		lineNumber := 0
+		for scanner.Scan() {
+			lineNumber++
+			line := bytes.TrimSpace(scanner.Bytes())
+			if len(line) == 0 {
+				continue
+			}
+			result := make(chan common.SignalCheckOutput, 1)
+			queue <- result
+
+			input := common.SignalCheckInput{}
+			if err := json.Unmarshal(line, &input); err != nil {
+				result <- common.SignalCheckOutput{IsError: true, HttpStatus: 400, ErrorMessage: fmt.Sprintf("line %v: invalid input: %v", lineNumber, err)}
+				continue
+			}
+			slots <- struct{}{}
+			go func(input common.SignalCheckInput, lineNumber int) {
+				defer func() { <-slots }()
+				output, _ := signalchecker.NewSignalChecker(input, opts...).Check()
+				if output.IsError {
+					output.ErrorMessage = fmt.Sprintf("line %v: %v", lineNumber, output.ErrorMessage)
+				}
+				result <- output
+			}(input, lineNumber)
+		}
+		scanErr = scanner.Err()
+	}()
+
+	summary := batchSummary{}
+	encoder := json.NewEncoder(w)
+	var writeErr error
+	for result := range queue {
+		output := <-result
+		summary.add(output)
+		if writeErr == nil {
+			writeErr = encoder.Encode(output)
+		}
+	}
+	if scanErr != nil {
+		return summary, scanErr
+	}
+	if writeErr != nil {
+		return summary, writeErr
+	}
+	return summary, encoder.Encode(struct {
+		Summary batchSummary `json:"summary"`
+	}{summary})
+}
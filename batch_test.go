@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/marianogappa/signal-checker/common"
+)
+
+func TestCheckBatch(t *testing.T) {
+	dataDir, err := ioutil.TempDir("", "signal-checker-batch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dataDir)
+	if err := os.MkdirAll(filepath.Join(dataDir, "BTC-USDT", "candlesticks"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	candlesticks := "t,o,c,l,h\n1625408058,1.5,1.5,1.5,1.5\n1625408118,5.5,5.5,5.5,5.5\n1625408178,0.05,0.05,0.05,0.05\n"
+	if err := ioutil.WriteFile(filepath.Join(dataDir, "BTC-USDT", "candlesticks", "2021-07.csv"), []byte(candlesticks), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Even lines take profit, and odd lines stop loss.
+	lines := []string{}
+	for i := 0; i < 10; i++ {
+		takeProfit := 5
+		if i%2 == 1 {
+			takeProfit = 10
+		}
+		lines = append(lines, fmt.Sprintf(`{"exchange":"offline","dataDir":%q,"baseAsset":"BTC","quoteAsset":"USDT","entries":[2,1],"stopLoss":0.1,"takeProfits":[%v],"initialISO8601":"2021-07-04T14:14:18Z","dontCalculateMaxEnterUSD":true,"dontApplyFees":true}`, dataDir, takeProfit))
+	}
+	lines = append(lines, "", `{"exchange":`, `{"exchange":"binance"}`)
+
+	var out bytes.Buffer
+	summary, err := checkBatch(strings.NewReader(strings.Join(lines, "\n")), &out, 3, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	outputLines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(outputLines) != 13 {
+		t.Fatalf("expected 12 outputs and a summary, but got %v lines: %v", len(outputLines), out.String())
+	}
+	for i := 0; i < 10; i++ {
+		output := common.SignalCheckOutput{}
+		if err := json.Unmarshal([]byte(outputLines[i]), &output); err != nil {
+			t.Fatal(err)
+		}
+		if output.IsError {
+			t.Fatalf("unexpected error on output %v: %v", i, output.ErrorMessage)
+		}
+		expectedTakeProfit := 1
+		if i%2 == 1 {
+			expectedTakeProfit = 0
+		}
+		if output.HighestTakeProfit != expectedTakeProfit || output.ReachedStopLoss == (i%2 == 0) {
+			t.Fatalf("output %v is out of order: %v", i, outputLines[i])
+		}
+	}
+	for i, expected := range []string{"line 12: invalid input", "line 13: " + common.ErrBaseAssetRequired.Error()} {
+		output := common.SignalCheckOutput{}
+		if err := json.Unmarshal([]byte(outputLines[10+i]), &output); err != nil {
+			t.Fatal(err)
+		}
+		if !output.IsError || !strings.HasPrefix(output.ErrorMessage, expected) {
+			t.Fatalf("expected an error starting with %q but got %v", expected, outputLines[10+i])
+		}
+	}
+
+	expectedSummary := batchSummary{Total: 12, Errors: 2, Entered: 10, TookProfit: 5, StoppedLoss: 5}
+	if summary != expectedSummary {
+		t.Fatalf("expected summary %+v but got %+v", expectedSummary, summary)
+	}
+	if !strings.HasPrefix(outputLines[12], `{"summary":{"total":12,`) {
+		t.Fatalf("expected a summary as the last line, but got %v", outputLines[12])
+	}
+}
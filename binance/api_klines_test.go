@@ -1,13 +1,17 @@
 package binance
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
 	"testing"
 
 	"github.com/marianogappa/signal-checker/common"
+	"github.com/marianogappa/signal-checker/httpclient"
 )
 
 func TestHappyToCandlesticks(t *testing.T) {
@@ -432,13 +436,79 @@ func TestKlinesInvalidUrl(t *testing.T) {
 
 	b := NewBinance()
 	b.overrideAPIURL("invalid url")
-	ci := b.BuildCandlestickIterator("BTC", "USDT", "2021-07-04T14:14:18+00:00")
+	ci := b.BuildCandlestickIterator(context.Background(), "BTC", "USDT", "2021-07-04T14:14:18+00:00")
 	_, err := ci.Next()
 	if err == nil {
 		t.Fatalf("should have failed due to invalid url")
 	}
 }
 
+func TestKlinesContextCancelled(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatalf("should not have made a request with a cancelled context")
+	}))
+	defer ts.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	b := NewBinance()
+	b.overrideAPIURL(ts.URL + "/")
+	ci := b.BuildCandlestickIterator(ctx, "BTC", "USDT", "2021-07-04T14:14:18+00:00")
+	_, err := ci.Next()
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("should have failed due to cancelled context, but failed with %v", err)
+	}
+}
+
+func TestKlinesWithClientOptions(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v3/klines" || r.UserAgent() != "test-agent" {
+			t.Fatalf("unexpected request to %v with user agent %v", r.URL.Path, r.UserAgent())
+		}
+		fmt.Fprintln(w, `[[1499040000000,"0.01634790","0.80000000","0.01575800","0.01577100","148976.11427815",1499644799999,"2434.19055334",308,"1756.87402397","28.46694368","17928899.62484339"]]`)
+	}))
+	defer ts.Close()
+
+	b := NewBinance(httpclient.WithBaseURL(ts.URL+"/api/v3"), httpclient.WithUserAgent("test-agent"))
+	ci := b.BuildCandlestickIterator(context.Background(), "BTC", "USDT", "2017-07-03T00:00:00+00:00")
+	cs, err := ci.Next()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cs.OpenPrice != 0.01634790 {
+		t.Fatalf("expected open price 0.01634790 but got %v", cs.OpenPrice)
+	}
+}
+
+func TestKlinesAtResolution(t *testing.T) {
+	requestedStartTimes := []string{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("interval") != "1h" {
+			t.Fatalf("expected interval 1h but got %v", r.URL.Query().Get("interval"))
+		}
+		requestedStartTimes = append(requestedStartTimes, r.URL.Query().Get("startTime"))
+		if len(requestedStartTimes) > 1 {
+			fmt.Fprintln(w, `[]`)
+			return
+		}
+		fmt.Fprintln(w, `[[1499040000000,"0.01634790","0.80000000","0.01575800","0.01577100","148976.11427815",1499043599999,"2434.19055334",308,"1756.87402397","28.46694368","17928899.62484339"]]`)
+	}))
+	defer ts.Close()
+
+	b := NewBinance(httpclient.WithBaseURL(ts.URL + "/api/v3"))
+	ci := b.BuildCandlestickIteratorAtResolution(context.Background(), "BTC", "USDT", "2017-07-03T00:00:00+00:00", 3600)
+	if _, err := ci.Next(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := ci.Next(); err != common.ErrOutOfCandlesticks {
+		t.Fatalf("expected to be out of candlesticks, but got %v", err)
+	}
+	expected := []string{"1499040000000", "1499043600000"}
+	if !reflect.DeepEqual(requestedStartTimes, expected) {
+		t.Fatalf("expected requests starting at %v but got %v", expected, requestedStartTimes)
+	}
+}
+
 func TestKlinesErrReadingResponseBody(t *testing.T) {
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Length", "1")
@@ -447,7 +517,7 @@ func TestKlinesErrReadingResponseBody(t *testing.T) {
 
 	b := NewBinance()
 	b.overrideAPIURL(ts.URL + "/")
-	ci := b.BuildCandlestickIterator("BTC", "USDT", "2021-07-04T14:14:18+00:00")
+	ci := b.BuildCandlestickIterator(context.Background(), "BTC", "USDT", "2021-07-04T14:14:18+00:00")
 	_, err := ci.Next()
 	if err == nil {
 		t.Fatalf("should have failed due to invalid response body")
@@ -462,7 +532,7 @@ func TestKlinesErrorResponse(t *testing.T) {
 
 	b := NewBinance()
 	b.overrideAPIURL(ts.URL + "/")
-	ci := b.BuildCandlestickIterator("BTC", "USDT", "2021-07-04T14:14:18+00:00")
+	ci := b.BuildCandlestickIterator(context.Background(), "BTC", "USDT", "2021-07-04T14:14:18+00:00")
 	_, err := ci.Next()
 	if err == nil {
 		t.Fatalf("should have failed due to error response")
@@ -476,7 +546,7 @@ func TestKlinesInvalidJSONResponse(t *testing.T) {
 
 	b := NewBinance()
 	b.overrideAPIURL(ts.URL + "/")
-	ci := b.BuildCandlestickIterator("BTC", "USDT", "2021-07-04T14:14:18+00:00")
+	ci := b.BuildCandlestickIterator(context.Background(), "BTC", "USDT", "2021-07-04T14:14:18+00:00")
 	_, err := ci.Next()
 	if err == nil {
 		t.Fatalf("should have failed due to invalid json")
@@ -506,7 +576,7 @@ func TestKlinesInvalidFloatsInJSONResponse(t *testing.T) {
 
 	b := NewBinance()
 	b.overrideAPIURL(ts.URL + "/")
-	ci := b.BuildCandlestickIterator("BTC", "USDT", "2021-07-04T14:14:18+00:00")
+	ci := b.BuildCandlestickIterator(context.Background(), "BTC", "USDT", "2021-07-04T14:14:18+00:00")
 	_, err := ci.Next()
 	if err == nil {
 		t.Fatalf("should have failed due to invalid floats in json")
@@ -1,13 +1,13 @@
 package binance
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"strconv"
 	"strings"
-	"time"
 
 	"github.com/marianogappa/signal-checker/common"
 )
@@ -73,8 +73,8 @@ type aggTradesResult struct {
 	httpStatus          int
 }
 
-func (b Binance) getTrades(baseAsset string, quoteAsset string, startTimeMillis int) (aggTradesResult, error) {
-	req, _ := http.NewRequest("GET", fmt.Sprintf("%vaggTrades", b.apiURL), nil)
+func (b Binance) getTrades(ctx context.Context, baseAsset string, quoteAsset string, startTimeMillis int) (aggTradesResult, error) {
+	req, _ := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%vaggTrades", b.apiURL), nil)
 	symbol := fmt.Sprintf("%v%v", strings.ToUpper(baseAsset), strings.ToUpper(quoteAsset))
 
 	q := req.URL.Query()
@@ -85,9 +85,7 @@ func (b Binance) getTrades(baseAsset string, quoteAsset string, startTimeMillis
 
 	req.URL.RawQuery = q.Encode()
 
-	client := &http.Client{Timeout: 10 * time.Second}
-
-	resp, err := client.Do(req)
+	resp, err := b.client.Do(req, 1)
 	if err != nil {
 		return aggTradesResult{err: err}, err
 	}
@@ -1,6 +1,7 @@
 package binance
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -35,7 +36,7 @@ func TestTrades(t *testing.T) {
 
 	b := NewBinance()
 	b.overrideAPIURL(ts.URL + "/")
-	ci := b.BuildTradeIterator("BTC", "USDT", "2021-07-04T14:14:18+00:00")
+	ci := b.BuildTradeIterator(context.Background(), "BTC", "USDT", "2021-07-04T14:14:18+00:00")
 
 	expectedResults := []expectedTrade{
 		{
@@ -157,7 +158,7 @@ func TestInvalidUrl(t *testing.T) {
 
 	b := NewBinance()
 	b.overrideAPIURL("invalid url")
-	ci := b.BuildTradeIterator("BTC", "USDT", "2021-07-04T14:14:18+00:00")
+	ci := b.BuildTradeIterator(context.Background(), "BTC", "USDT", "2021-07-04T14:14:18+00:00")
 	_, err := ci.Next()
 	if err == nil {
 		t.Fatalf("should have failed due to invalid url")
@@ -172,7 +173,7 @@ func TestErrReadingResponseBody(t *testing.T) {
 
 	b := NewBinance()
 	b.overrideAPIURL(ts.URL + "/")
-	ci := b.BuildTradeIterator("BTC", "USDT", "2021-07-04T14:14:18+00:00")
+	ci := b.BuildTradeIterator(context.Background(), "BTC", "USDT", "2021-07-04T14:14:18+00:00")
 	_, err := ci.Next()
 	if err == nil {
 		t.Fatalf("should have failed due to invalid response body")
@@ -187,7 +188,7 @@ func TestErrorResponse(t *testing.T) {
 
 	b := NewBinance()
 	b.overrideAPIURL(ts.URL + "/")
-	ci := b.BuildTradeIterator("BTC", "USDT", "2021-07-04T14:14:18+00:00")
+	ci := b.BuildTradeIterator(context.Background(), "BTC", "USDT", "2021-07-04T14:14:18+00:00")
 	_, err := ci.Next()
 	if err == nil {
 		t.Fatalf("should have failed due to error response")
@@ -201,7 +202,7 @@ func TestInvalidJSONResponse(t *testing.T) {
 
 	b := NewBinance()
 	b.overrideAPIURL(ts.URL + "/")
-	ci := b.BuildTradeIterator("BTC", "USDT", "2021-07-04T14:14:18+00:00")
+	ci := b.BuildTradeIterator(context.Background(), "BTC", "USDT", "2021-07-04T14:14:18+00:00")
 	_, err := ci.Next()
 	if err == nil {
 		t.Fatalf("should have failed due to invalid json")
@@ -218,7 +219,7 @@ func TestInvalidFloatsInJSONResponse(t *testing.T) {
 
 	b := NewBinance()
 	b.overrideAPIURL(ts.URL + "/")
-	ci := b.BuildTradeIterator("BTC", "USDT", "2021-07-04T14:14:18+00:00")
+	ci := b.BuildTradeIterator(context.Background(), "BTC", "USDT", "2021-07-04T14:14:18+00:00")
 	_, err := ci.Next()
 	if err == nil {
 		t.Fatalf("should have failed due to invalid floats in json")
@@ -1,16 +1,21 @@
 package binance
 
 import (
+	"context"
+
 	"github.com/marianogappa/signal-checker/common"
+	"github.com/marianogappa/signal-checker/httpclient"
 )
 
 type Binance struct {
 	apiURL string
-	debug  bool
+	client *httpclient.Client
 }
 
-func NewBinance() *Binance {
-	return &Binance{apiURL: "https://api.binance.com/api/v3/"}
+// NewBinance is the constructor for Binance.
+func NewBinance(opts ...httpclient.Option) *Binance {
+	client := httpclient.NewClient("binance", httpclient.BinanceLimit, opts...)
+	return &Binance{apiURL: client.BaseURL("https://api.binance.com/api/v3/"), client: client}
 }
 
 func (b *Binance) overrideAPIURL(url string) {
@@ -18,15 +23,27 @@ func (b *Binance) overrideAPIURL(url string) {
 }
 
 func (b *Binance) SetDebug(debug bool) {
-	b.debug = debug
+	b.client.SetDebug(debug)
+}
+
+func (b Binance) Capabilities() common.ExchangeCapabilities {
+	return common.ExchangeCapabilities{Trades: true, Resolutions: []int{3600}, Fees: common.DefaultFees[common.BINANCE]}
 }
 
-func (b Binance) BuildCandlestickIterator(baseAsset, quoteAsset string, initialISO8601 common.ISO8601) *common.CandlestickIterator {
-	return common.NewCandlestickIterator(b.newCandlestickIterator(baseAsset, quoteAsset, initialISO8601).next)
+func (b Binance) BuildCandlestickIterator(ctx context.Context, baseAsset, quoteAsset string, initialISO8601 common.ISO8601) *common.CandlestickIterator {
+	return common.NewCandlestickIterator(b.newCandlestickIterator(ctx, baseAsset, quoteAsset, initialISO8601, 60).next)
 }
 
-func (b Binance) BuildTradeIterator(baseAsset, quoteAsset string, initialISO8601 common.ISO8601) *common.TradeIterator {
-	return common.NewTradeIterator(b.newTradeIterator(baseAsset, quoteAsset, initialISO8601).next)
+// BuildCandlestickIteratorAtResolution only supports hourly candlesticks, the only ones mapped to a kline interval.
+func (b Binance) BuildCandlestickIteratorAtResolution(ctx context.Context, baseAsset, quoteAsset string, initialISO8601 common.ISO8601, resolutionSeconds int) *common.CandlestickIterator {
+	return common.NewCandlestickIterator(b.newCandlestickIterator(ctx, baseAsset, quoteAsset, initialISO8601, resolutionSeconds).next)
 }
 
-const ERR_INVALID_SYMBOL = -1121
+func (b Binance) BuildTradeIterator(ctx context.Context, baseAsset, quoteAsset string, initialISO8601 common.ISO8601) *common.TradeIterator {
+	return common.NewTradeIterator(b.newTradeIterator(ctx, baseAsset, quoteAsset, initialISO8601).next)
+}
+
+const (
+	ERR_TOO_MANY_REQUESTS = -1003
+	ERR_INVALID_SYMBOL    = -1121
+)
@@ -1,27 +1,33 @@
 package binance
 
 import (
+	"context"
+
 	"github.com/marianogappa/signal-checker/common"
 )
 
 type binanceCandlestickIterator struct {
+	ctx                   context.Context
 	binance               Binance
 	baseAsset, quoteAsset string
 	candlesticks          []common.Candlestick
 	requestFromMillis     int
 	initialSeconds        int
+	resolutionSeconds     int
 }
 
-func (b Binance) newCandlestickIterator(baseAsset, quoteAsset string, initialISO8601 common.ISO8601) *binanceCandlestickIterator {
+func (b Binance) newCandlestickIterator(ctx context.Context, baseAsset, quoteAsset string, initialISO8601 common.ISO8601, resolutionSeconds int) *binanceCandlestickIterator {
 	// N.B. already validated
 	initial, _ := initialISO8601.Time()
 	initialSeconds := int(initial.Unix())
 	return &binanceCandlestickIterator{
+		ctx:               ctx,
 		binance:           b,
 		baseAsset:         baseAsset,
 		quoteAsset:        quoteAsset,
 		requestFromMillis: initialSeconds * 1000,
 		initialSeconds:    initialSeconds,
+		resolutionSeconds: resolutionSeconds,
 	}
 }
 
@@ -31,7 +37,7 @@ func (it *binanceCandlestickIterator) next() (common.Candlestick, error) {
 		it.candlesticks = it.candlesticks[1:]
 		return c, nil
 	}
-	klinesResult, err := it.binance.getKlines(it.baseAsset, it.quoteAsset, it.requestFromMillis)
+	klinesResult, err := it.binance.getKlines(it.ctx, it.baseAsset, it.quoteAsset, it.requestFromMillis, it.resolutionSeconds)
 	if err != nil {
 		return common.Candlestick{}, err
 	}
@@ -46,7 +52,7 @@ func (it *binanceCandlestickIterator) next() (common.Candlestick, error) {
 		it.candlesticks = it.candlesticks[1:]
 	}
 	if len(it.candlesticks) > 0 {
-		it.requestFromMillis = (it.candlesticks[len(it.candlesticks)-1].Timestamp + 60) * 1000
+		it.requestFromMillis = (it.candlesticks[len(it.candlesticks)-1].Timestamp + it.resolutionSeconds) * 1000
 	}
 	return it.next()
 }
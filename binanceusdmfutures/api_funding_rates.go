@@ -0,0 +1,123 @@
+package binanceusdmfutures
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/marianogappa/signal-checker/common"
+)
+
+// [
+//   {
+//     "symbol": "BTCUSDT",
+//     "fundingRate": "-0.03750000",
+//     "fundingTime": 1570608000000,
+//     "markPrice": "34287.54619963"
+//   }
+// ]
+type binanceFundingRate struct {
+	Symbol      string `json:"symbol"`
+	FundingRate string `json:"fundingRate"`
+	FundingTime int    `json:"fundingTime"`
+}
+
+func (r binanceFundingRate) toFundingRate() (common.FundingRate, error) {
+	rate, err := strconv.ParseFloat(r.FundingRate, 64)
+	if err != nil {
+		return common.FundingRate{}, err
+	}
+	return common.FundingRate{
+		Rate:      common.JsonFloat64(rate),
+		Timestamp: r.FundingTime / 1000,
+	}, nil
+}
+
+type fundingRatesResponse = []binanceFundingRate
+
+func binanceFundingRatesToFundingRates(r fundingRatesResponse) ([]common.FundingRate, error) {
+	fundingRates := []common.FundingRate{}
+	for _, binanceFundingRate := range r {
+		fundingRate, err := binanceFundingRate.toFundingRate()
+		if err != nil {
+			return fundingRates, err
+		}
+		fundingRates = append(fundingRates, fundingRate)
+	}
+	return fundingRates, nil
+}
+
+type fundingRatesResult struct {
+	fundingRates        []common.FundingRate
+	err                 error
+	binanceErrorCode    int
+	binanceErrorMessage string
+	httpStatus          int
+}
+
+func (b BinanceUSDMFutures) getFundingRates(ctx context.Context, baseAsset string, quoteAsset string, startTimeMillis int) (fundingRatesResult, error) {
+	req, _ := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%vfundingRate", b.apiURL), nil)
+	symbol := fmt.Sprintf("%v%v", strings.ToUpper(baseAsset), strings.ToUpper(quoteAsset))
+
+	q := req.URL.Query()
+	q.Add("symbol", symbol)
+	q.Add("limit", "1000")
+	q.Add("startTime", fmt.Sprintf("%v", startTimeMillis))
+
+	req.URL.RawQuery = q.Encode()
+
+	resp, err := b.client.Do(req, 1)
+	if err != nil {
+		return fundingRatesResult{err: err}, err
+	}
+	defer resp.Body.Close()
+
+	byts, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		err := fmt.Errorf("binance returned broken body response! Was: %v", string(byts))
+		return fundingRatesResult{err: err, httpStatus: 500}, err
+	}
+
+	maybeErrorResponse := errorResponse{}
+	err = json.Unmarshal(byts, &maybeErrorResponse)
+	errResp := maybeErrorResponse.toError()
+	if err == nil && errResp != nil {
+		return fundingRatesResult{
+			binanceErrorCode:    maybeErrorResponse.Code,
+			binanceErrorMessage: maybeErrorResponse.Msg,
+			httpStatus:          500,
+			err:                 errResp,
+		}, errResp
+	}
+
+	maybeResponse := fundingRatesResponse([]binanceFundingRate{})
+	err = json.Unmarshal(byts, &maybeResponse)
+	if err != nil {
+		err := fmt.Errorf("binance returned invalid JSON response! Was: %v", string(byts))
+		return fundingRatesResult{err: err, httpStatus: 500}, err
+	}
+
+	fundingRates, err := binanceFundingRatesToFundingRates(maybeResponse)
+	if err != nil {
+		return fundingRatesResult{
+			httpStatus: 500,
+			err:        err,
+		}, err
+	}
+
+	if len(fundingRates) == 0 {
+		return fundingRatesResult{
+			httpStatus: 200,
+			err:        common.ErrOutOfFundingRates,
+		}, common.ErrOutOfFundingRates
+	}
+
+	return fundingRatesResult{
+		fundingRates: fundingRates,
+		httpStatus:   200,
+	}, nil
+}
@@ -0,0 +1,84 @@
+package binanceusdmfutures
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/marianogappa/signal-checker/common"
+)
+
+type expectedFundingRate struct {
+	fundingRate common.FundingRate
+	err         error
+}
+
+func TestFundingRates(t *testing.T) {
+	i := 0
+	replies := []string{
+		`[
+			{"symbol":"BTCUSDT","fundingRate":"0.00010000","fundingTime":1625414400000,"markPrice":"35278.12000000"},
+			{"symbol":"BTCUSDT","fundingRate":"-0.00005000","fundingTime":1625443200000,"markPrice":"35542.96000000"}
+		]`,
+		`[
+			{"symbol":"BTCUSDT","fundingRate":"0.00012500","fundingTime":1625472000001,"markPrice":"35007.01000000"}
+		]`,
+		`[]`,
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, replies[i%len(replies)])
+		i++
+	}))
+	defer ts.Close()
+
+	b := NewBinanceUSDMFutures()
+	b.overrideAPIURL(ts.URL + "/")
+	fi := b.BuildFundingRateIterator(context.Background(), "BTC", "USDT", "2021-07-04T14:14:18+00:00")
+
+	expectedResults := []expectedFundingRate{
+		{fundingRate: common.FundingRate{Rate: 0.0001, Timestamp: 1625414400}, err: nil},
+		{fundingRate: common.FundingRate{Rate: -0.00005, Timestamp: 1625443200}, err: nil},
+		{fundingRate: common.FundingRate{Rate: 0.000125, Timestamp: 1625472000}, err: nil},
+		{fundingRate: common.FundingRate{}, err: common.ErrOutOfFundingRates},
+	}
+	for i, expectedResult := range expectedResults {
+		actualFundingRate, actualErr := fi.Next()
+		if actualFundingRate != expectedResult.fundingRate {
+			t.Errorf("on funding rate %v expected %v but got %v", i, expectedResult.fundingRate, actualFundingRate)
+			t.FailNow()
+		}
+		if actualErr != expectedResult.err {
+			t.Errorf("on funding rate %v expected no errors but this error happened %v", i, actualErr)
+			t.FailNow()
+		}
+	}
+}
+
+func TestFundingRatesError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"code":-1121,"msg":"Invalid symbol."}`)
+	}))
+	defer ts.Close()
+
+	b := NewBinanceUSDMFutures()
+	b.overrideAPIURL(ts.URL + "/")
+	fi := b.BuildFundingRateIterator(context.Background(), "DOGE", "SHIB", "2021-07-04T14:14:18+00:00")
+
+	_, err := fi.Next()
+	if err != common.ErrInvalidMarketPair {
+		t.Fatalf("expected ErrInvalidMarketPair but got %v", err)
+	}
+}
+
+func TestBinanceFundingRateToFundingRateFailsRate(t *testing.T) {
+	_, err := binanceFundingRate{
+		Symbol:      "BTCUSDT",
+		FundingRate: "invalid",
+		FundingTime: 1625414400000,
+	}.toFundingRate()
+	if err == nil {
+		t.Fatalf("should have failed with invalid funding rate")
+	}
+}
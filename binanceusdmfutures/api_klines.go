@@ -1,10 +1,10 @@
 package binanceusdmfutures
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
-	"log"
 	"net/http"
 	"strconv"
 	"strings"
@@ -25,6 +25,9 @@ func (r errorResponse) toError() error {
 	if r.Code == ERR_INVALID_SYMBOL {
 		return common.ErrInvalidMarketPair
 	}
+	if r.Code == ERR_TOO_MANY_REQUESTS {
+		return fmt.Errorf("%w: binance returned error code! Code: %v, Message: %v", common.ErrRateLimit, r.Code, r.Msg)
+	}
 	return fmt.Errorf("binance returned error code! Code: %v, Message: %v", r.Code, r.Msg)
 }
 
@@ -194,6 +197,9 @@ func (c binanceCandlestick) toCandlestick() common.Candlestick {
 	}
 }
 
+// klinesIntervals are the kline intervals for each supported resolution in seconds.
+var klinesIntervals = map[int]string{60: "1m", 3600: "1h"}
+
 type klinesResult struct {
 	candlesticks        []common.Candlestick
 	err                 error
@@ -202,21 +208,19 @@ type klinesResult struct {
 	httpStatus          int
 }
 
-func (b BinanceUSDMFutures) getKlines(baseAsset string, quoteAsset string, startTimeMillis int) (klinesResult, error) {
-	req, _ := http.NewRequest("GET", fmt.Sprintf("%vklines", b.apiURL), nil)
+func (b BinanceUSDMFutures) getKlines(ctx context.Context, baseAsset string, quoteAsset string, startTimeMillis int, resolutionSeconds int) (klinesResult, error) {
+	req, _ := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%vklines", b.apiURL), nil)
 	symbol := fmt.Sprintf("%v%v", strings.ToUpper(baseAsset), strings.ToUpper(quoteAsset))
 
 	q := req.URL.Query()
 	q.Add("symbol", symbol)
-	q.Add("interval", "1m")
+	q.Add("interval", klinesIntervals[resolutionSeconds])
 	q.Add("limit", "1000")
 	q.Add("startTime", fmt.Sprintf("%v", startTimeMillis))
 
 	req.URL.RawQuery = q.Encode()
 
-	client := &http.Client{Timeout: 10 * time.Second}
-
-	resp, err := client.Do(req)
+	resp, err := b.client.Do(req, 5)
 	if err != nil {
 		return klinesResult{err: err, httpStatus: 500}, err
 	}
@@ -268,9 +272,7 @@ func (b BinanceUSDMFutures) getKlines(baseAsset string, quoteAsset string, start
 		}, common.ErrOutOfCandlesticks
 	}
 
-	if b.debug {
-		log.Printf("BinanceUSDMFutures candlestick request successful! Candlestick count: %v\n", len(candlesticks))
-	}
+	common.LoggerFrom(ctx, b.client.Debug()).Printf("BinanceUSDMFutures candlestick request successful! Candlestick count: %v\n", len(candlesticks))
 
 	return klinesResult{
 		candlesticks: candlesticks,
@@ -1,6 +1,7 @@
 package binanceusdmfutures
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -432,7 +433,7 @@ func TestKlinesInvalidUrl(t *testing.T) {
 
 	b := NewBinanceUSDMFutures()
 	b.overrideAPIURL("invalid url")
-	ci := b.BuildCandlestickIterator("BTC", "USDT", "2021-07-04T14:14:18+00:00")
+	ci := b.BuildCandlestickIterator(context.Background(), "BTC", "USDT", "2021-07-04T14:14:18+00:00")
 	_, err := ci.Next()
 	if err == nil {
 		t.Fatalf("should have failed due to invalid url")
@@ -447,7 +448,7 @@ func TestKlinesErrReadingResponseBody(t *testing.T) {
 
 	b := NewBinanceUSDMFutures()
 	b.overrideAPIURL(ts.URL + "/")
-	ci := b.BuildCandlestickIterator("BTC", "USDT", "2021-07-04T14:14:18+00:00")
+	ci := b.BuildCandlestickIterator(context.Background(), "BTC", "USDT", "2021-07-04T14:14:18+00:00")
 	_, err := ci.Next()
 	if err == nil {
 		t.Fatalf("should have failed due to invalid response body")
@@ -462,7 +463,7 @@ func TestKlinesErrorResponse(t *testing.T) {
 
 	b := NewBinanceUSDMFutures()
 	b.overrideAPIURL(ts.URL + "/")
-	ci := b.BuildCandlestickIterator("BTC", "USDT", "2021-07-04T14:14:18+00:00")
+	ci := b.BuildCandlestickIterator(context.Background(), "BTC", "USDT", "2021-07-04T14:14:18+00:00")
 	_, err := ci.Next()
 	if err == nil {
 		t.Fatalf("should have failed due to error response")
@@ -476,7 +477,7 @@ func TestKlinesInvalidJSONResponse(t *testing.T) {
 
 	b := NewBinanceUSDMFutures()
 	b.overrideAPIURL(ts.URL + "/")
-	ci := b.BuildCandlestickIterator("BTC", "USDT", "2021-07-04T14:14:18+00:00")
+	ci := b.BuildCandlestickIterator(context.Background(), "BTC", "USDT", "2021-07-04T14:14:18+00:00")
 	_, err := ci.Next()
 	if err == nil {
 		t.Fatalf("should have failed due to invalid json")
@@ -506,7 +507,7 @@ func TestKlinesInvalidFloatsInJSONResponse(t *testing.T) {
 
 	b := NewBinanceUSDMFutures()
 	b.overrideAPIURL(ts.URL + "/")
-	ci := b.BuildCandlestickIterator("BTC", "USDT", "2021-07-04T14:14:18+00:00")
+	ci := b.BuildCandlestickIterator(context.Background(), "BTC", "USDT", "2021-07-04T14:14:18+00:00")
 	_, err := ci.Next()
 	if err == nil {
 		t.Fatalf("should have failed due to invalid floats in json")
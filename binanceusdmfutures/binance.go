@@ -1,16 +1,21 @@
 package binanceusdmfutures
 
 import (
+	"context"
+
 	"github.com/marianogappa/signal-checker/common"
+	"github.com/marianogappa/signal-checker/httpclient"
 )
 
 type BinanceUSDMFutures struct {
 	apiURL string
-	debug  bool
+	client *httpclient.Client
 }
 
-func NewBinanceUSDMFutures() *BinanceUSDMFutures {
-	return &BinanceUSDMFutures{apiURL: "https://fapi.binance.com/fapi/v1/"}
+// NewBinanceUSDMFutures is the constructor for BinanceUSDMFutures.
+func NewBinanceUSDMFutures(opts ...httpclient.Option) *BinanceUSDMFutures {
+	client := httpclient.NewClient("binanceusdmfutures", httpclient.BinanceUSDMFuturesLimit, opts...)
+	return &BinanceUSDMFutures{apiURL: client.BaseURL("https://fapi.binance.com/fapi/v1/"), client: client}
 }
 
 func (b *BinanceUSDMFutures) overrideAPIURL(url string) {
@@ -18,15 +23,31 @@ func (b *BinanceUSDMFutures) overrideAPIURL(url string) {
 }
 
 func (b *BinanceUSDMFutures) SetDebug(debug bool) {
-	b.debug = debug
+	b.client.SetDebug(debug)
+}
+
+func (b BinanceUSDMFutures) Capabilities() common.ExchangeCapabilities {
+	return common.ExchangeCapabilities{Trades: true, Futures: true, Resolutions: []int{3600}, Fees: common.DefaultFees[common.BINANCE_USDM_FUTURES]}
+}
+
+func (b BinanceUSDMFutures) BuildCandlestickIterator(ctx context.Context, baseAsset, quoteAsset string, initialISO8601 common.ISO8601) *common.CandlestickIterator {
+	return common.NewCandlestickIterator(b.newCandlestickIterator(ctx, baseAsset, quoteAsset, initialISO8601, 60).next)
+}
+
+// BuildCandlestickIteratorAtResolution only supports hourly candlesticks, the only ones mapped to a kline interval.
+func (b BinanceUSDMFutures) BuildCandlestickIteratorAtResolution(ctx context.Context, baseAsset, quoteAsset string, initialISO8601 common.ISO8601, resolutionSeconds int) *common.CandlestickIterator {
+	return common.NewCandlestickIterator(b.newCandlestickIterator(ctx, baseAsset, quoteAsset, initialISO8601, resolutionSeconds).next)
 }
 
-func (b BinanceUSDMFutures) BuildCandlestickIterator(baseAsset, quoteAsset string, initialISO8601 common.ISO8601) *common.CandlestickIterator {
-	return common.NewCandlestickIterator(b.newCandlestickIterator(baseAsset, quoteAsset, initialISO8601).next)
+func (b BinanceUSDMFutures) BuildTradeIterator(ctx context.Context, baseAsset, quoteAsset string, initialISO8601 common.ISO8601) *common.TradeIterator {
+	return common.NewTradeIterator(b.newTradeIterator(ctx, baseAsset, quoteAsset, initialISO8601).next)
 }
 
-func (b BinanceUSDMFutures) BuildTradeIterator(baseAsset, quoteAsset string, initialISO8601 common.ISO8601) *common.TradeIterator {
-	return common.NewTradeIterator(b.newTradeIterator(baseAsset, quoteAsset, initialISO8601).next)
+func (b BinanceUSDMFutures) BuildFundingRateIterator(ctx context.Context, baseAsset, quoteAsset string, initialISO8601 common.ISO8601) *common.FundingRateIterator {
+	return common.NewFundingRateIterator(b.newFundingRateIterator(ctx, baseAsset, quoteAsset, initialISO8601).next)
 }
 
-const ERR_INVALID_SYMBOL = -1121
+const (
+	ERR_TOO_MANY_REQUESTS = -1003
+	ERR_INVALID_SYMBOL    = -1121
+)
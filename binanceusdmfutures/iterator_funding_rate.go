@@ -0,0 +1,43 @@
+package binanceusdmfutures
+
+import (
+	"context"
+
+	"github.com/marianogappa/signal-checker/common"
+)
+
+type binanceFundingRateIterator struct {
+	ctx                   context.Context
+	binance               BinanceUSDMFutures
+	baseAsset, quoteAsset string
+	fundingRates          []common.FundingRate
+	requestFromMillis     int
+}
+
+func (b BinanceUSDMFutures) newFundingRateIterator(ctx context.Context, baseAsset, quoteAsset string, initialISO8601 common.ISO8601) *binanceFundingRateIterator {
+	// N.B. already validated
+	initial, _ := initialISO8601.Time()
+	return &binanceFundingRateIterator{
+		ctx:               ctx,
+		binance:           b,
+		baseAsset:         baseAsset,
+		quoteAsset:        quoteAsset,
+		requestFromMillis: int(initial.Unix()) * 1000,
+	}
+}
+
+func (it *binanceFundingRateIterator) next() (common.FundingRate, error) {
+	if len(it.fundingRates) > 0 {
+		r := it.fundingRates[0]
+		it.fundingRates = it.fundingRates[1:]
+		return r, nil
+	}
+	fundingRatesResult, err := it.binance.getFundingRates(it.ctx, it.baseAsset, it.quoteAsset, it.requestFromMillis)
+	if err != nil {
+		return common.FundingRate{}, err
+	}
+	it.fundingRates = fundingRatesResult.fundingRates
+	// Funding rates settle hours apart, so resuming from the next second never skips one.
+	it.requestFromMillis = (it.fundingRates[len(it.fundingRates)-1].Timestamp + 1) * 1000
+	return it.next()
+}
@@ -1,19 +1,25 @@
 package binanceusdmfutures
 
-import "github.com/marianogappa/signal-checker/common"
+import (
+	"context"
+
+	"github.com/marianogappa/signal-checker/common"
+)
 
 type binanceTradeIterator struct {
+	ctx                               context.Context
 	binance                           BinanceUSDMFutures
 	baseAsset, quoteAsset             string
 	trades                            []common.Trade
 	requestFromMillis, initialSeconds int
 }
 
-func (b BinanceUSDMFutures) newTradeIterator(baseAsset, quoteAsset string, initialISO8601 common.ISO8601) *binanceTradeIterator {
+func (b BinanceUSDMFutures) newTradeIterator(ctx context.Context, baseAsset, quoteAsset string, initialISO8601 common.ISO8601) *binanceTradeIterator {
 	// N.B. already validated
 	initial, _ := initialISO8601.Time()
 	initialSeconds := int(initial.Unix())
 	return &binanceTradeIterator{
+		ctx:               ctx,
 		binance:           b,
 		baseAsset:         baseAsset,
 		quoteAsset:        quoteAsset,
@@ -28,7 +34,7 @@ func (it *binanceTradeIterator) next() (common.Trade, error) {
 		it.trades = it.trades[1:]
 		return c, nil
 	}
-	aggTradesResult, err := it.binance.getTrades(it.baseAsset, it.quoteAsset, it.requestFromMillis)
+	aggTradesResult, err := it.binance.getTrades(it.ctx, it.baseAsset, it.quoteAsset, it.requestFromMillis)
 	if err != nil {
 		return common.Trade{}, err
 	}
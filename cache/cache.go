@@ -0,0 +1,163 @@
+// The cache package contains a decorator for common.Exchange that stores fetched 1m candlesticks (and coarser ones, for
+// exchanges that support them) on local disk, so that subsequent checks on the same exchange, pair and time range are
+// served from disk, only fetching missing gaps.
+//
+// Candlesticks are stored in chunks, in files named "{from}-{to}.json" (UNIX timestamps in seconds) under a
+// "{exchange}/{BASE}-{QUOTE}" directory, or "{exchange}/{BASE}-{QUOTE}@{resolution}s" for coarser candlesticks. A chunk means that all candlesticks in that range were fetched, so missing
+// minutes within a chunk are minutes without candlesticks on the exchange.
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/marianogappa/signal-checker/common"
+)
+
+// candlestickDurationSeconds is the duration of the candlesticks that BuildCandlestickIterator caches, i.e. one minute.
+const candlestickDurationSeconds = 60
+
+// flushEvery is the number of fetched candlesticks after which a chunk is stored on disk.
+const flushEvery = 1000
+
+type Cache struct {
+	exchange     common.Exchange
+	exchangeName string
+	dir          string
+	debug        bool
+}
+
+// NewCache decorates the given exchange, storing its candlesticks under dir. exchangeName is used to separate
+// candlesticks from different exchanges, e.g. "binance".
+func NewCache(exchange common.Exchange, exchangeName string, dir string) *Cache {
+	return &Cache{exchange: exchange, exchangeName: exchangeName, dir: dir}
+}
+
+func (c *Cache) SetDebug(debug bool) {
+	c.debug = debug
+	c.exchange.SetDebug(debug)
+}
+
+// Capabilities are the decorated exchange's.
+func (c Cache) Capabilities() common.ExchangeCapabilities {
+	capabilities := common.CapabilitiesOf(c.exchange)
+	if _, ok := c.exchange.(common.MultiResolutionExchange); !ok {
+		capabilities.Resolutions = nil
+	}
+	return capabilities
+}
+
+func (c Cache) BuildCandlestickIterator(ctx context.Context, baseAsset, quoteAsset string, initialISO8601 common.ISO8601) *common.CandlestickIterator {
+	it := c.newCandlestickIterator(ctx, baseAsset, quoteAsset, initialISO8601, candlestickDurationSeconds)
+	return common.NewClosableCandlestickIterator(it.next, it.close)
+}
+
+// BuildCandlestickIteratorAtResolution caches the candlesticks separately from the one minute ones. It must only be
+// called if the decorated exchange declares the resolution on its Capabilities.
+func (c Cache) BuildCandlestickIteratorAtResolution(ctx context.Context, baseAsset, quoteAsset string, initialISO8601 common.ISO8601, resolutionSeconds int) *common.CandlestickIterator {
+	it := c.newCandlestickIterator(ctx, baseAsset, quoteAsset, initialISO8601, resolutionSeconds)
+	return common.NewClosableCandlestickIterator(it.next, it.close)
+}
+
+// buildExchangeIterator builds the decorated exchange's iterator at the resolution.
+func (c Cache) buildExchangeIterator(ctx context.Context, baseAsset, quoteAsset string, initialISO8601 common.ISO8601, resolutionSeconds int) *common.CandlestickIterator {
+	if resolutionSeconds == candlestickDurationSeconds {
+		return c.exchange.BuildCandlestickIterator(ctx, baseAsset, quoteAsset, initialISO8601)
+	}
+	return c.exchange.(common.MultiResolutionExchange).BuildCandlestickIteratorAtResolution(ctx, baseAsset, quoteAsset, initialISO8601, resolutionSeconds)
+}
+
+// BuildTradeIterator is not cached, as trades are only requested for short periods.
+func (c Cache) BuildTradeIterator(ctx context.Context, baseAsset, quoteAsset string, initialISO8601 common.ISO8601) *common.TradeIterator {
+	return c.exchange.BuildTradeIterator(ctx, baseAsset, quoteAsset, initialISO8601)
+}
+
+// BuildFundingRateIterator is not cached. If the decorated exchange has no funding rates, it runs out of them
+// immediately.
+func (c Cache) BuildFundingRateIterator(ctx context.Context, baseAsset, quoteAsset string, initialISO8601 common.ISO8601) *common.FundingRateIterator {
+	if fundingRateExchange, ok := c.exchange.(common.FundingRateExchange); ok {
+		return fundingRateExchange.BuildFundingRateIterator(ctx, baseAsset, quoteAsset, initialISO8601)
+	}
+	return common.NewFundingRateIterator(func() (common.FundingRate, error) {
+		return common.FundingRate{}, common.ErrOutOfFundingRates
+	})
+}
+
+type chunk struct {
+	from, to int
+}
+
+func (c Cache) pairDir(baseAsset, quoteAsset string, resolutionSeconds int) string {
+	pair := fmt.Sprintf("%v-%v", strings.ToUpper(baseAsset), strings.ToUpper(quoteAsset))
+	if resolutionSeconds != candlestickDurationSeconds {
+		pair = fmt.Sprintf("%v@%vs", pair, resolutionSeconds)
+	}
+	return filepath.Join(c.dir, c.exchangeName, pair)
+}
+
+// listChunks returns the chunks stored for the pair at the resolution, sorted by from.
+func (c Cache) listChunks(baseAsset, quoteAsset string, resolutionSeconds int) ([]chunk, error) {
+	files, err := ioutil.ReadDir(c.pairDir(baseAsset, quoteAsset, resolutionSeconds))
+	if os.IsNotExist(err) {
+		return []chunk{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	chunks := []chunk{}
+	for _, file := range files {
+		ch := chunk{}
+		if _, err := fmt.Sscanf(file.Name(), "%d-%d.json", &ch.from, &ch.to); err != nil || ch.from > ch.to {
+			continue
+		}
+		chunks = append(chunks, ch)
+	}
+	sort.Slice(chunks, func(i, j int) bool {
+		return chunks[i].from < chunks[j].from
+	})
+	return chunks, nil
+}
+
+func (c Cache) readChunk(baseAsset, quoteAsset string, resolutionSeconds int, ch chunk) ([]common.Candlestick, error) {
+	byts, err := ioutil.ReadFile(filepath.Join(c.pairDir(baseAsset, quoteAsset, resolutionSeconds), fmt.Sprintf("%v-%v.json", ch.from, ch.to)))
+	if err != nil {
+		return nil, err
+	}
+	candlesticks := []common.Candlestick{}
+	if err := json.Unmarshal(byts, &candlesticks); err != nil {
+		return nil, fmt.Errorf("cache chunk %v-%v is corrupt: %v", ch.from, ch.to, err)
+	}
+	return candlesticks, nil
+}
+
+// writeChunk stores the chunk atomically, so that concurrent checks never read half-written chunks.
+func (c Cache) writeChunk(baseAsset, quoteAsset string, resolutionSeconds int, ch chunk, candlesticks []common.Candlestick) error {
+	dir := c.pairDir(baseAsset, quoteAsset, resolutionSeconds)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	byts, err := json.Marshal(candlesticks)
+	if err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(dir, ".chunk-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(byts); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Join(dir, fmt.Sprintf("%v-%v.json", ch.from, ch.to)))
+}
@@ -0,0 +1,186 @@
+package cache
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/marianogappa/signal-checker/common"
+)
+
+// testExchange serves candlesticks from the requested initial time, and records the requested initial times.
+type testExchange struct {
+	candlesticks   []common.Candlestick
+	requestedFroms []int
+}
+
+func (e *testExchange) SetDebug(debug bool) {}
+
+func (e *testExchange) BuildCandlestickIterator(ctx context.Context, baseAsset, quoteAsset string, initialISO8601 common.ISO8601) *common.CandlestickIterator {
+	from, _ := initialISO8601.Seconds()
+	e.requestedFroms = append(e.requestedFroms, from)
+	i := 0
+	return common.NewCandlestickIterator(func() (common.Candlestick, error) {
+		for i < len(e.candlesticks) && e.candlesticks[i].Timestamp < from {
+			i++
+		}
+		if i >= len(e.candlesticks) {
+			return common.Candlestick{}, common.ErrOutOfCandlesticks
+		}
+		i++
+		return e.candlesticks[i-1], nil
+	})
+}
+
+func (e *testExchange) BuildTradeIterator(ctx context.Context, baseAsset, quoteAsset string, initialISO8601 common.ISO8601) *common.TradeIterator {
+	return common.NewTradeIterator(func() (common.Trade, error) { return common.Trade{}, common.ErrOutOfTrades })
+}
+
+var base, _ = common.ISO8601("2021-07-04T14:14:00Z").Seconds()
+
+func buildCandlesticks(count int) []common.Candlestick {
+	candlesticks := []common.Candlestick{}
+	for i := 0; i < count; i++ {
+		candlesticks = append(candlesticks, common.Candlestick{Timestamp: base + i*60, OpenPrice: common.JsonFloat64(i + 1)})
+	}
+	return candlesticks
+}
+
+func iso(seconds int) common.ISO8601 {
+	return common.ISO8601(time.Unix(int64(seconds), 0).UTC().Format(time.RFC3339))
+}
+
+func iterateAll(t *testing.T, c *Cache, initialSeconds int, now time.Time) []common.Candlestick {
+	it := c.newCandlestickIterator(context.Background(), "BTC", "USDT", iso(initialSeconds), candlestickDurationSeconds)
+	it.now = func() time.Time { return now }
+	candlesticks := []common.Candlestick{}
+	for {
+		candlestick, err := it.next()
+		if err == common.ErrOutOfCandlesticks {
+			return candlesticks
+		}
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		candlesticks = append(candlesticks, candlestick)
+	}
+}
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "signal-checker-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	return dir
+}
+
+func TestServesFromCacheOnSecondRun(t *testing.T) {
+	dir := tempDir(t)
+	future := time.Unix(int64(base+3600), 0)
+
+	exchange := &testExchange{candlesticks: buildCandlesticks(5)}
+	first := iterateAll(t, NewCache(exchange, "binance", dir), base, future)
+	if !reflect.DeepEqual(first, exchange.candlesticks) {
+		t.Fatalf("expected %v but got %v", exchange.candlesticks, first)
+	}
+
+	exchange.requestedFroms = nil
+	second := iterateAll(t, NewCache(exchange, "binance", dir), base, future)
+	if !reflect.DeepEqual(second, exchange.candlesticks) {
+		t.Fatalf("expected %v but got %v", exchange.candlesticks, second)
+	}
+	// Only what's after the cached chunk should be requested.
+	if !reflect.DeepEqual(exchange.requestedFroms, []int{base + 300}) {
+		t.Fatalf("expected only a request after the cached chunk, but got requests from %v", exchange.requestedFroms)
+	}
+}
+
+func TestOnlyFetchesMissingGaps(t *testing.T) {
+	dir := tempDir(t)
+	future := time.Unix(int64(base+3600), 0)
+	exchange := &testExchange{candlesticks: buildCandlesticks(5)}
+
+	// Caches from the third candlestick.
+	iterateAll(t, NewCache(exchange, "binance", dir), base+120, future)
+
+	exchange.requestedFroms = nil
+	actual := iterateAll(t, NewCache(exchange, "binance", dir), base, future)
+	if !reflect.DeepEqual(actual, exchange.candlesticks) {
+		t.Fatalf("expected %v but got %v", exchange.candlesticks, actual)
+	}
+	if !reflect.DeepEqual(exchange.requestedFroms, []int{base, base + 300}) {
+		t.Fatalf("expected requests for the gaps only, but got requests from %v", exchange.requestedFroms)
+	}
+
+	chunks, _ := NewCache(exchange, "binance", dir).listChunks("BTC", "USDT", candlestickDurationSeconds)
+	expectedChunks := []chunk{{from: base, to: base + 60}, {from: base + 120, to: base + 240}}
+	if !reflect.DeepEqual(chunks, expectedChunks) {
+		t.Fatalf("expected chunks %v but got %v", expectedChunks, chunks)
+	}
+}
+
+func TestDoesNotCacheIncompleteCandlesticks(t *testing.T) {
+	dir := tempDir(t)
+	exchange := &testExchange{candlesticks: buildCandlesticks(5)}
+
+	// The last candlestick started 10 seconds ago, so it's incomplete.
+	actual := iterateAll(t, NewCache(exchange, "binance", dir), base, time.Unix(int64(base+250), 0))
+	if !reflect.DeepEqual(actual, exchange.candlesticks) {
+		t.Fatalf("expected %v but got %v", exchange.candlesticks, actual)
+	}
+
+	chunks, _ := NewCache(exchange, "binance", dir).listChunks("BTC", "USDT", candlestickDurationSeconds)
+	expectedChunks := []chunk{{from: base, to: base + 180}}
+	if !reflect.DeepEqual(chunks, expectedChunks) {
+		t.Fatalf("expected chunks %v but got %v", expectedChunks, chunks)
+	}
+}
+
+func TestServesFromInitialSecond(t *testing.T) {
+	dir := tempDir(t)
+	future := time.Unix(int64(base+3600), 0)
+	exchange := &testExchange{candlesticks: buildCandlesticks(5)}
+
+	iterateAll(t, NewCache(exchange, "binance", dir), base, future)
+	actual := iterateAll(t, NewCache(exchange, "binance", dir), base+18, future)
+	if !reflect.DeepEqual(actual, exchange.candlesticks[1:]) {
+		t.Fatalf("expected %v but got %v", exchange.candlesticks[1:], actual)
+	}
+}
+
+func TestFlushesEveryThousandCandlesticks(t *testing.T) {
+	dir := tempDir(t)
+	future := time.Unix(int64(base+3600*48), 0)
+	exchange := &testExchange{candlesticks: buildCandlesticks(1500)}
+
+	iterateAll(t, NewCache(exchange, "binance", dir), base, future)
+
+	chunks, _ := NewCache(exchange, "binance", dir).listChunks("BTC", "USDT", candlestickDurationSeconds)
+	expectedChunks := []chunk{{from: base, to: base + 999*60}, {from: base + 1000*60, to: base + 1499*60}}
+	if !reflect.DeepEqual(chunks, expectedChunks) {
+		t.Fatalf("expected chunks %v but got %v", expectedChunks, chunks)
+	}
+}
+
+func TestFlushesOnClose(t *testing.T) {
+	dir := tempDir(t)
+	exchange := &testExchange{candlesticks: buildCandlesticks(100)}
+
+	it := NewCache(exchange, "binance", dir).BuildCandlestickIterator(context.Background(), "BTC", "USDT", iso(base))
+	for i := 0; i < 10; i++ {
+		if _, err := it.Next(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	it.Close()
+
+	chunks, _ := NewCache(exchange, "binance", dir).listChunks("BTC", "USDT", candlestickDurationSeconds)
+	expectedChunks := []chunk{{from: base, to: base + 9*60}}
+	if !reflect.DeepEqual(chunks, expectedChunks) {
+		t.Fatalf("expected chunks %v but got %v", expectedChunks, chunks)
+	}
+}
@@ -0,0 +1,165 @@
+package cache
+
+import (
+	"context"
+	"time"
+
+	"github.com/marianogappa/signal-checker/common"
+)
+
+type cacheCandlestickIterator struct {
+	ctx                   context.Context
+	cache                 Cache
+	baseAsset, quoteAsset string
+	resolutionSeconds     int
+	candlesticks          []common.Candlestick
+	initialSeconds        int
+	cursor                int
+	now                   func() time.Time
+
+	// State while fetching a gap from the exchange.
+	exchangeIterator *common.CandlestickIterator
+	fetchUntil       int
+	pending          []common.Candlestick
+	pendingFrom      int
+	persisting       bool
+}
+
+func (c Cache) newCandlestickIterator(ctx context.Context, baseAsset, quoteAsset string, initialISO8601 common.ISO8601, resolutionSeconds int) *cacheCandlestickIterator {
+	// N.B. already validated
+	initial, _ := initialISO8601.Time()
+	initialSeconds := int(initial.Unix())
+	return &cacheCandlestickIterator{
+		ctx:               ctx,
+		cache:             c,
+		baseAsset:         baseAsset,
+		quoteAsset:        quoteAsset,
+		resolutionSeconds: resolutionSeconds,
+		initialSeconds:    initialSeconds,
+		// Chunks start at the beginning of a candlestick, so that they can be reused by signals starting at any second.
+		cursor: initialSeconds - initialSeconds%resolutionSeconds,
+		now:    time.Now,
+	}
+}
+
+func (it *cacheCandlestickIterator) next() (common.Candlestick, error) {
+	for {
+		if len(it.candlesticks) > 0 {
+			c := it.candlesticks[0]
+			it.candlesticks = it.candlesticks[1:]
+			return c, nil
+		}
+		if it.exchangeIterator == nil {
+			if it.serveFromCache() {
+				continue
+			}
+			it.startFetching()
+		}
+		candlestick, err := it.exchangeIterator.Next()
+		if err != nil {
+			it.flush(it.lastPendingTimestamp())
+			return common.Candlestick{}, err
+		}
+		// The gap is filled, so the rest is served from the cache.
+		if it.fetchUntil > 0 && candlestick.Timestamp >= it.fetchUntil {
+			it.flush(it.fetchUntil - it.resolutionSeconds)
+			it.cursor = it.fetchUntil
+			it.exchangeIterator = nil
+			continue
+		}
+		it.cursor = candlestick.Timestamp + it.resolutionSeconds
+		if candlestick.Timestamp >= it.initialSeconds {
+			it.candlesticks = append(it.candlesticks, candlestick)
+		}
+		// Don't cache the current candlestick, as it's not final yet.
+		if it.persisting && int64(candlestick.Timestamp+it.resolutionSeconds) > it.now().Unix() {
+			it.flush(it.lastPendingTimestamp())
+			it.persisting = false
+		}
+		if it.persisting {
+			it.pending = append(it.pending, candlestick)
+			if len(it.pending) >= flushEvery {
+				it.flush(candlestick.Timestamp)
+			}
+		}
+	}
+}
+
+// serveFromCache buffers the candlesticks of the chunk that contains the cursor, if any, and moves the cursor to the
+// end of it. Otherwise, it notes where the next chunk starts, so that only the gap is fetched.
+func (it *cacheCandlestickIterator) serveFromCache() bool {
+	chunks, err := it.cache.listChunks(it.baseAsset, it.quoteAsset, it.resolutionSeconds)
+	if err != nil {
+		it.debugf("Cache: couldn't list chunks: %v\n", err)
+		chunks = []chunk{}
+	}
+	found := false
+	best := chunk{}
+	it.fetchUntil = 0
+	for _, ch := range chunks {
+		if ch.from <= it.cursor && ch.to >= it.cursor && (!found || ch.to > best.to) {
+			found, best = true, ch
+		}
+		if ch.from > it.cursor && it.fetchUntil == 0 {
+			it.fetchUntil = ch.from
+		}
+	}
+	if !found {
+		return false
+	}
+	candlesticks, err := it.cache.readChunk(it.baseAsset, it.quoteAsset, it.resolutionSeconds, best)
+	if err != nil {
+		it.debugf("Cache: couldn't read chunk %v-%v: %v\n", best.from, best.to, err)
+		return false
+	}
+	it.debugf("Cache: serving %v-%v for %v-%v from the cache\n", best.from, best.to, it.baseAsset, it.quoteAsset)
+	for _, candlestick := range candlesticks {
+		if candlestick.Timestamp >= it.cursor && candlestick.Timestamp >= it.initialSeconds {
+			it.candlesticks = append(it.candlesticks, candlestick)
+		}
+	}
+	it.cursor = best.to + it.resolutionSeconds
+	return true
+}
+
+func (it *cacheCandlestickIterator) startFetching() {
+	it.debugf("Cache: fetching %v-%v from the exchange from %v until %v\n", it.baseAsset, it.quoteAsset, it.cursor, it.fetchUntil)
+	initialISO8601 := common.ISO8601(time.Unix(int64(it.cursor), 0).UTC().Format(time.RFC3339))
+	it.exchangeIterator = it.cache.buildExchangeIterator(it.ctx, it.baseAsset, it.quoteAsset, initialISO8601, it.resolutionSeconds)
+	it.pending = nil
+	it.pendingFrom = it.cursor
+	it.persisting = true
+}
+
+func (it *cacheCandlestickIterator) lastPendingTimestamp() int {
+	if len(it.pending) == 0 {
+		return it.pendingFrom - it.resolutionSeconds
+	}
+	return it.pending[len(it.pending)-1].Timestamp
+}
+
+// flush stores the pending candlesticks as the chunk from pendingFrom to the given timestamp. Failing to do so only
+// means that they will be fetched again.
+func (it *cacheCandlestickIterator) flush(to int) {
+	if !it.persisting || to < it.pendingFrom {
+		return
+	}
+	ch := chunk{from: it.pendingFrom, to: to}
+	if err := it.cache.writeChunk(it.baseAsset, it.quoteAsset, it.resolutionSeconds, ch, it.pending); err != nil {
+		it.debugf("Cache: couldn't write chunk %v-%v: %v\n", ch.from, ch.to, err)
+	}
+	it.pending = nil
+	it.pendingFrom = to + it.resolutionSeconds
+}
+
+// close stores the candlesticks fetched so far, as checks often stop iterating (e.g. when the signal ends) long before
+// the next flush.
+func (it *cacheCandlestickIterator) close() {
+	it.flush(it.lastPendingTimestamp())
+	it.persisting = false
+	it.exchangeIterator = nil
+}
+
+func (it *cacheCandlestickIterator) debugf(format string, v ...interface{}) {
+	common.LoggerFrom(it.ctx, it.cache.debug).Printf(format, v...)
+}
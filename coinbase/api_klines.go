@@ -1,13 +1,12 @@
 package coinbase
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
-	"log"
 	"net/http"
 	"strings"
-	"time"
 
 	"github.com/marianogappa/signal-checker/common"
 )
@@ -69,19 +68,17 @@ type klinesResult struct {
 	httpStatus           int
 }
 
-func (c Coinbase) getKlines(baseAsset string, quoteAsset string, startTimeISO8601, endTimeISO8601 string) (klinesResult, error) {
-	req, _ := http.NewRequest("GET", fmt.Sprintf("%vproducts/%v-%v/candles", c.apiURL, strings.ToUpper(baseAsset), strings.ToUpper(quoteAsset)), nil)
+func (c Coinbase) getKlines(ctx context.Context, baseAsset string, quoteAsset string, startTimeISO8601, endTimeISO8601 string, resolutionSeconds int) (klinesResult, error) {
+	req, _ := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%vproducts/%v-%v/candles", c.apiURL, strings.ToUpper(baseAsset), strings.ToUpper(quoteAsset)), nil)
 
 	q := req.URL.Query()
-	q.Add("granularity", "60")
+	q.Add("granularity", fmt.Sprintf("%v", resolutionSeconds))
 	q.Add("start", fmt.Sprintf("%v", startTimeISO8601))
 	q.Add("end", fmt.Sprintf("%v", endTimeISO8601))
 
 	req.URL.RawQuery = q.Encode()
 
-	client := &http.Client{Timeout: 10 * time.Second}
-
-	resp, err := client.Do(req)
+	resp, err := c.client.Do(req, 1)
 	if err != nil {
 		return klinesResult{err: err}, err
 	}
@@ -124,9 +121,7 @@ func (c Coinbase) getKlines(baseAsset string, quoteAsset string, startTimeISO860
 		}, err
 	}
 
-	if c.debug {
-		log.Printf("Coinbase candlestick request successful! Candlestick count: %v\n", len(candlesticks))
-	}
+	common.LoggerFrom(ctx, c.client.Debug()).Printf("Coinbase candlestick request successful! Candlestick count: %v\n", len(candlesticks))
 
 	return klinesResult{
 		candlesticks: candlesticks,
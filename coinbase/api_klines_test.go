@@ -1,6 +1,7 @@
 package coinbase
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -79,7 +80,7 @@ func TestKlinesInvalidUrl(t *testing.T) {
 
 	b := NewCoinbase()
 	b.overrideAPIURL("invalid url")
-	ci := b.BuildCandlestickIterator("BTC", "USDT", "2021-07-04T14:14:18+00:00")
+	ci := b.BuildCandlestickIterator(context.Background(), "BTC", "USDT", "2021-07-04T14:14:18+00:00")
 	_, err := ci.Next()
 	if err == nil {
 		t.Fatalf("should have failed due to invalid url")
@@ -94,7 +95,7 @@ func TestKlinesErrReadingResponseBody(t *testing.T) {
 
 	b := NewCoinbase()
 	b.overrideAPIURL(ts.URL + "/")
-	ci := b.BuildCandlestickIterator("BTC", "USDT", "2021-07-04T14:14:18+00:00")
+	ci := b.BuildCandlestickIterator(context.Background(), "BTC", "USDT", "2021-07-04T14:14:18+00:00")
 	_, err := ci.Next()
 	if err == nil {
 		t.Fatalf("should have failed due to invalid response body")
@@ -109,7 +110,7 @@ func TestKlinesErrorResponse(t *testing.T) {
 
 	b := NewCoinbase()
 	b.overrideAPIURL(ts.URL + "/")
-	ci := b.BuildCandlestickIterator("BTC", "USDT", "2021-07-04T14:14:18+00:00")
+	ci := b.BuildCandlestickIterator(context.Background(), "BTC", "USDT", "2021-07-04T14:14:18+00:00")
 	_, err := ci.Next()
 	if err == nil {
 		t.Fatalf("should have failed due to error response")
@@ -124,7 +125,8 @@ func TestKlinesNon200Response(t *testing.T) {
 
 	b := NewCoinbase()
 	b.overrideAPIURL(ts.URL + "/")
-	ci := b.BuildCandlestickIterator("BTC", "USDT", "2021-07-04T14:14:18+00:00")
+	b.client.BaseBackoff = 0
+	ci := b.BuildCandlestickIterator(context.Background(), "BTC", "USDT", "2021-07-04T14:14:18+00:00")
 	_, err := ci.Next()
 	if err == nil {
 		t.Fatalf("should have failed due to 500 response")
@@ -139,7 +141,7 @@ func TestKlinesInvalidJSONResponse(t *testing.T) {
 
 	b := NewCoinbase()
 	b.overrideAPIURL(ts.URL + "/")
-	ci := b.BuildCandlestickIterator("BTC", "USDT", "2021-07-04T14:14:18+00:00")
+	ci := b.BuildCandlestickIterator(context.Background(), "BTC", "USDT", "2021-07-04T14:14:18+00:00")
 	_, err := ci.Next()
 	if err == nil {
 		t.Fatalf("should have failed due to invalid json")
@@ -154,7 +156,7 @@ func TestKlinesInvalidFloatsInJSONResponse(t *testing.T) {
 
 	b := NewCoinbase()
 	b.overrideAPIURL(ts.URL + "/")
-	ci := b.BuildCandlestickIterator("BTC", "USDT", "2021-07-04T14:14:18+00:00")
+	ci := b.BuildCandlestickIterator(context.Background(), "BTC", "USDT", "2021-07-04T14:14:18+00:00")
 	_, err := ci.Next()
 	if err == nil {
 		t.Fatalf("should have failed due to invalid floats in json")
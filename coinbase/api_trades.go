@@ -0,0 +1,131 @@
+package coinbase
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/marianogappa/signal-checker/common"
+)
+
+// [
+//   {
+//     "time": "2021-07-04T14:14:18.123456Z",
+//     "trade_id": 74,
+//     "price": "10.00000000",
+//     "size": "0.01000000",
+//     "side": "buy"
+//   }
+// ]
+type coinbaseTrade struct {
+	Time    string `json:"time"`
+	TradeID int    `json:"trade_id"`
+	Price   string `json:"price"`
+	Size    string `json:"size"`
+	Side    string `json:"side"`
+}
+
+func (t coinbaseTrade) toTrade() (common.Trade, error) {
+	price, err := strconv.ParseFloat(t.Price, 64)
+	if err != nil {
+		return common.Trade{}, err
+	}
+	size, err := strconv.ParseFloat(t.Size, 64)
+	if err != nil {
+		return common.Trade{}, err
+	}
+	tm, err := time.Parse(time.RFC3339Nano, t.Time)
+	if err != nil {
+		return common.Trade{}, err
+	}
+	return common.Trade{
+		BaseAssetPrice:    common.JsonFloat64(price),
+		BaseAssetQuantity: common.JsonFloat64(size),
+		Timestamp:         int(tm.Unix()),
+	}, nil
+}
+
+type tradesResponse = []coinbaseTrade
+
+type tradesResult struct {
+	trades               []common.Trade
+	tradeIDs             []int
+	err                  error
+	coinbaseErrorMessage string
+	httpStatus           int
+}
+
+// getTrades requests up to {limit} trades with trade_id < {after}, or the latest ones if {after} is 0. Note that
+// Coinbase returns them in descending order, but the result is in ascending order.
+func (c Coinbase) getTrades(ctx context.Context, baseAsset string, quoteAsset string, after int, limit int) (tradesResult, error) {
+	req, _ := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%vproducts/%v-%v/trades", c.apiURL, strings.ToUpper(baseAsset), strings.ToUpper(quoteAsset)), nil)
+
+	q := req.URL.Query()
+	q.Add("limit", fmt.Sprintf("%v", limit))
+	if after > 0 {
+		q.Add("after", fmt.Sprintf("%v", after))
+	}
+
+	req.URL.RawQuery = q.Encode()
+
+	resp, err := c.client.Do(req, 1)
+	if err != nil {
+		return tradesResult{err: err}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		byts, _ := ioutil.ReadAll(resp.Body)
+		err := fmt.Errorf("coinbase returned %v status code with payload [%v]", resp.StatusCode, string(byts))
+		return tradesResult{httpStatus: 500, err: err}, err
+	}
+
+	byts, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		err := fmt.Errorf("coinbase returned broken body response! Was: %v", string(byts))
+		return tradesResult{err: err, httpStatus: 500}, err
+	}
+
+	maybeErrorResponse := errorResponse{}
+	err = json.Unmarshal(byts, &maybeErrorResponse)
+	if err == nil && (maybeErrorResponse.Message != "") {
+		err := fmt.Errorf("coinbase returned error code! Message: %v", maybeErrorResponse.Message)
+		return tradesResult{
+			coinbaseErrorMessage: maybeErrorResponse.Message,
+			httpStatus:           500,
+			err:                  err,
+		}, err
+	}
+
+	maybeResponse := tradesResponse{}
+	err = json.Unmarshal(byts, &maybeResponse)
+	if err != nil {
+		err := fmt.Errorf("coinbase returned invalid JSON response! Was: %v", string(byts))
+		return tradesResult{err: err, httpStatus: 500}, err
+	}
+
+	result := tradesResult{httpStatus: 200}
+	for i := len(maybeResponse) - 1; i >= 0; i-- {
+		trade, err := maybeResponse[i].toTrade()
+		if err != nil {
+			err := fmt.Errorf("error unmarshalling successful JSON response from Coinbase: %v", err)
+			return tradesResult{httpStatus: 500, err: err}, err
+		}
+		result.trades = append(result.trades, trade)
+		result.tradeIDs = append(result.tradeIDs, maybeResponse[i].TradeID)
+	}
+
+	if len(result.trades) == 0 {
+		return tradesResult{
+			httpStatus: 200,
+			err:        common.ErrOutOfTrades,
+		}, common.ErrOutOfTrades
+	}
+
+	return result, nil
+}
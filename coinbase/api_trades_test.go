@@ -0,0 +1,103 @@
+package coinbase
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/marianogappa/signal-checker/common"
+)
+
+type expectedTrade struct {
+	trade common.Trade
+	err   error
+}
+
+// newTradesServer simulates Coinbase's trades endpoint, which returns trades in descending order, paginated backwards
+// with the "after" cursor.
+func newTradesServer(trades []coinbaseTrade) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		after, err := strconv.Atoi(r.URL.Query().Get("after"))
+		if err != nil {
+			after = trades[len(trades)-1].TradeID + 1
+		}
+		page := []coinbaseTrade{}
+		for i := len(trades) - 1; i >= 0 && len(page) < limit; i-- {
+			if trades[i].TradeID < after {
+				page = append(page, trades[i])
+			}
+		}
+		byts, _ := json.Marshal(page)
+		fmt.Fprintln(w, string(byts))
+	}))
+}
+
+func TestTrades(t *testing.T) {
+	ts := newTradesServer([]coinbaseTrade{
+		{Time: "2021-07-04T14:14:16.000001Z", TradeID: 1, Price: "34000.00", Size: "0.1", Side: "buy"},
+		{Time: "2021-07-04T14:14:17.000001Z", TradeID: 2, Price: "34001.00", Size: "0.2", Side: "buy"},
+		{Time: "2021-07-04T14:14:18.000001Z", TradeID: 3, Price: "34002.00", Size: "0.3", Side: "sell"},
+		{Time: "2021-07-04T14:14:19.000001Z", TradeID: 4, Price: "34003.00", Size: "0.4", Side: "buy"},
+		{Time: "2021-07-04T14:14:20.000001Z", TradeID: 5, Price: "34004.00", Size: "0.5", Side: "sell"},
+	})
+	defer ts.Close()
+
+	c := NewCoinbase()
+	c.overrideAPIURL(ts.URL + "/")
+	ti := c.BuildTradeIterator(context.Background(), "BTC", "USD", "2021-07-04T14:14:18+00:00")
+
+	expectedResults := []expectedTrade{
+		{trade: common.Trade{BaseAssetPrice: 34002, BaseAssetQuantity: 0.3, Timestamp: 1625408058}, err: nil},
+		{trade: common.Trade{BaseAssetPrice: 34003, BaseAssetQuantity: 0.4, Timestamp: 1625408059}, err: nil},
+		{trade: common.Trade{BaseAssetPrice: 34004, BaseAssetQuantity: 0.5, Timestamp: 1625408060}, err: nil},
+		{trade: common.Trade{}, err: common.ErrOutOfTrades},
+	}
+	for i, expectedResult := range expectedResults {
+		actualTrade, actualErr := ti.Next()
+		if actualTrade != expectedResult.trade {
+			t.Errorf("on trade %v expected %v but got %v", i, expectedResult.trade, actualTrade)
+			t.FailNow()
+		}
+		if actualErr != expectedResult.err {
+			t.Errorf("on trade %v expected no errors but this error happened %v", i, actualErr)
+			t.FailNow()
+		}
+	}
+}
+
+func TestTradesAllBeforeInitial(t *testing.T) {
+	ts := newTradesServer([]coinbaseTrade{
+		{Time: "2021-07-04T14:14:16.000001Z", TradeID: 1, Price: "34000.00", Size: "0.1", Side: "buy"},
+	})
+	defer ts.Close()
+
+	c := NewCoinbase()
+	c.overrideAPIURL(ts.URL + "/")
+	ti := c.BuildTradeIterator(context.Background(), "BTC", "USD", "2021-07-04T14:14:18+00:00")
+
+	_, err := ti.Next()
+	if err != common.ErrOutOfTrades {
+		t.Fatalf("expected ErrOutOfTrades but got %v", err)
+	}
+}
+
+func TestTradesError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"message":"NotFound"}`)
+	}))
+	defer ts.Close()
+
+	c := NewCoinbase()
+	c.overrideAPIURL(ts.URL + "/")
+	ti := c.BuildTradeIterator(context.Background(), "DOGE", "SHIB", "2021-07-04T14:14:18+00:00")
+
+	_, err := ti.Next()
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+}
@@ -1,16 +1,21 @@
 package coinbase
 
 import (
+	"context"
+
 	"github.com/marianogappa/signal-checker/common"
+	"github.com/marianogappa/signal-checker/httpclient"
 )
 
 type Coinbase struct {
 	apiURL string
-	debug  bool
+	client *httpclient.Client
 }
 
-func NewCoinbase() *Coinbase {
-	return &Coinbase{apiURL: "https://api.pro.coinbase.com/"}
+// NewCoinbase is the constructor for Coinbase.
+func NewCoinbase(opts ...httpclient.Option) *Coinbase {
+	client := httpclient.NewClient("coinbase", httpclient.CoinbaseLimit, opts...)
+	return &Coinbase{apiURL: client.BaseURL("https://api.pro.coinbase.com/"), client: client}
 }
 
 func (c *Coinbase) overrideAPIURL(apiURL string) {
@@ -18,13 +23,23 @@ func (c *Coinbase) overrideAPIURL(apiURL string) {
 }
 
 func (b *Coinbase) SetDebug(debug bool) {
-	b.debug = debug
+	b.client.SetDebug(debug)
+}
+
+func (c Coinbase) Capabilities() common.ExchangeCapabilities {
+	return common.ExchangeCapabilities{Trades: true, Resolutions: []int{3600}, Fees: common.DefaultFees[common.COINBASE]}
+}
+
+func (c Coinbase) BuildCandlestickIterator(ctx context.Context, baseAsset, quoteAsset string, initialISO8601 common.ISO8601) *common.CandlestickIterator {
+	return common.NewCandlestickIterator(c.newCandlestickIterator(ctx, baseAsset, quoteAsset, initialISO8601, 60).next)
 }
 
-func (c Coinbase) BuildCandlestickIterator(baseAsset, quoteAsset string, initialISO8601 common.ISO8601) *common.CandlestickIterator {
-	return common.NewCandlestickIterator(c.newCandlestickIterator(baseAsset, quoteAsset, initialISO8601).next)
+// BuildCandlestickIteratorAtResolution passes resolutionSeconds as Coinbase's granularity, which only takes a few
+// values; of those, only hourly candlesticks are declared.
+func (c Coinbase) BuildCandlestickIteratorAtResolution(ctx context.Context, baseAsset, quoteAsset string, initialISO8601 common.ISO8601, resolutionSeconds int) *common.CandlestickIterator {
+	return common.NewCandlestickIterator(c.newCandlestickIterator(ctx, baseAsset, quoteAsset, initialISO8601, resolutionSeconds).next)
 }
 
-func (c Coinbase) BuildTradeIterator(baseAsset, quoteAsset string, initialISO8601 common.ISO8601) *common.TradeIterator {
-	return common.NewTradeIterator(c.newTradeIterator(baseAsset, quoteAsset, initialISO8601).next)
+func (c Coinbase) BuildTradeIterator(ctx context.Context, baseAsset, quoteAsset string, initialISO8601 common.ISO8601) *common.TradeIterator {
+	return common.NewTradeIterator(c.newTradeIterator(ctx, baseAsset, quoteAsset, initialISO8601).next)
 }
@@ -1,29 +1,34 @@
 package coinbase
 
 import (
+	"context"
 	"time"
 
 	"github.com/marianogappa/signal-checker/common"
 )
 
 type coinbaseCandlestickIterator struct {
+	ctx                   context.Context
 	coinbase              Coinbase
 	baseAsset, quoteAsset string
 	candlesticks          []common.Candlestick
 	requestFromTime       time.Time
 	initialSeconds        int
+	resolutionSeconds     int
 }
 
-func (c Coinbase) newCandlestickIterator(baseAsset, quoteAsset string, initialISO8601 common.ISO8601) *coinbaseCandlestickIterator {
+func (c Coinbase) newCandlestickIterator(ctx context.Context, baseAsset, quoteAsset string, initialISO8601 common.ISO8601, resolutionSeconds int) *coinbaseCandlestickIterator {
 	// N.B. already validated
 	initial, _ := initialISO8601.Time()
 	initialSeconds := int(initial.Unix())
 	return &coinbaseCandlestickIterator{
-		coinbase:        c,
-		baseAsset:       baseAsset,
-		quoteAsset:      quoteAsset,
-		requestFromTime: initial,
-		initialSeconds:  initialSeconds,
+		ctx:               ctx,
+		coinbase:          c,
+		baseAsset:         baseAsset,
+		quoteAsset:        quoteAsset,
+		requestFromTime:   initial,
+		initialSeconds:    initialSeconds,
+		resolutionSeconds: resolutionSeconds,
 	}
 }
 
@@ -34,13 +39,13 @@ func (it *coinbaseCandlestickIterator) next() (common.Candlestick, error) {
 		it.candlesticks = it.candlesticks[:len(it.candlesticks)-1]
 		return c, nil
 	}
-	if it.requestFromTime.After(time.Now().Add(-1 * time.Minute)) {
+	if it.requestFromTime.After(time.Now().Add(-time.Duration(it.resolutionSeconds) * time.Second)) {
 		return common.Candlestick{}, common.ErrOutOfCandlesticks
 	}
 	startTimeISO8601 := it.requestFromTime.Format(time.RFC3339)
-	endTimeISO8601 := it.requestFromTime.Add(299 * 60 * time.Second).Format(time.RFC3339)
+	endTimeISO8601 := it.requestFromTime.Add(time.Duration(299*it.resolutionSeconds) * time.Second).Format(time.RFC3339)
 
-	klinesResult, err := it.coinbase.getKlines(it.baseAsset, it.quoteAsset, startTimeISO8601, endTimeISO8601)
+	klinesResult, err := it.coinbase.getKlines(it.ctx, it.baseAsset, it.quoteAsset, startTimeISO8601, endTimeISO8601, it.resolutionSeconds)
 	if err != nil {
 		return common.Candlestick{}, err
 	}
@@ -55,7 +60,7 @@ func (it *coinbaseCandlestickIterator) next() (common.Candlestick, error) {
 		it.candlesticks = it.candlesticks[:len(it.candlesticks)-1]
 	}
 	if len(it.candlesticks) > 0 {
-		it.requestFromTime = it.requestFromTime.Add(299 * 60 * time.Second)
+		it.requestFromTime = it.requestFromTime.Add(time.Duration(299*it.resolutionSeconds) * time.Second)
 	}
 	return it.next()
 }
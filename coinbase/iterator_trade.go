@@ -1,29 +1,94 @@
 package coinbase
 
 import (
-	"errors"
+	"context"
 
 	"github.com/marianogappa/signal-checker/common"
 )
 
+const tradesPageSize = 1000
+
 type coinbaseTradeIterator struct {
+	ctx                   context.Context
 	coinbase              Coinbase
 	baseAsset, quoteAsset string
-	// trades                []common.Trade
-	requestFromMillis int
+	trades                []common.Trade
+	initialSeconds        int
+	lastTradeID           int
+	foundFirstTrade       bool
 }
 
-func (c Coinbase) newTradeIterator(baseAsset, quoteAsset string, initialISO8601 common.ISO8601) *coinbaseTradeIterator {
+func (c Coinbase) newTradeIterator(ctx context.Context, baseAsset, quoteAsset string, initialISO8601 common.ISO8601) *coinbaseTradeIterator {
 	// N.B. already validated
 	initial, _ := initialISO8601.Time()
 	return &coinbaseTradeIterator{
-		coinbase:          c,
-		baseAsset:         baseAsset,
-		quoteAsset:        quoteAsset,
-		requestFromMillis: int(initial.Unix()) * 1000,
+		ctx:            ctx,
+		coinbase:       c,
+		baseAsset:      baseAsset,
+		quoteAsset:     quoteAsset,
+		initialSeconds: int(initial.Unix()),
 	}
 }
 
 func (it *coinbaseTradeIterator) next() (common.Trade, error) {
-	return common.Trade{}, errors.New("coinbase's trade iterator is not implemented yet")
+	if len(it.trades) > 0 {
+		c := it.trades[0]
+		it.trades = it.trades[1:]
+		return c, nil
+	}
+	if !it.foundFirstTrade {
+		lastTradeID, err := it.findLastTradeIDBeforeInitial()
+		if err != nil {
+			return common.Trade{}, err
+		}
+		it.lastTradeID = lastTradeID
+		it.foundFirstTrade = true
+	}
+	// Coinbase only paginates backwards, so request the page that ends right after the next one to iterate.
+	tradesResult, err := it.coinbase.getTrades(it.ctx, it.baseAsset, it.quoteAsset, it.lastTradeID+tradesPageSize+1, tradesPageSize)
+	if err != nil {
+		return common.Trade{}, err
+	}
+	for i, tradeID := range tradesResult.tradeIDs {
+		if tradeID > it.lastTradeID {
+			it.trades = append(it.trades, tradesResult.trades[i])
+		}
+	}
+	if len(it.trades) == 0 {
+		return common.Trade{}, common.ErrOutOfTrades
+	}
+	it.lastTradeID = tradesResult.tradeIDs[len(tradesResult.tradeIDs)-1]
+	return it.next()
+}
+
+// findLastTradeIDBeforeInitial binary searches the trade_id of the last trade before the initial time, since
+// Coinbase's trades endpoint can't be queried by time.
+func (it *coinbaseTradeIterator) findLastTradeIDBeforeInitial() (int, error) {
+	latest, err := it.coinbase.getTrades(it.ctx, it.baseAsset, it.quoteAsset, 0, 1)
+	if err != nil {
+		return 0, err
+	}
+	if latest.trades[0].Timestamp < it.initialSeconds {
+		return 0, common.ErrOutOfTrades
+	}
+
+	// Invariant: the trade with trade_id = hi happened at or after the initial time.
+	lo, hi := 1, latest.tradeIDs[0]
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+		midResult, err := it.coinbase.getTrades(it.ctx, it.baseAsset, it.quoteAsset, mid+1, 1)
+		if err == common.ErrOutOfTrades {
+			lo = mid + 1
+			continue
+		}
+		if err != nil {
+			return 0, err
+		}
+		if midResult.trades[0].Timestamp < it.initialSeconds {
+			lo = mid + 1
+		} else {
+			hi = mid
+		}
+	}
+	return lo - 1, nil
 }
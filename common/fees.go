@@ -0,0 +1,20 @@
+package common
+
+// Fees are the trading fee ratios charged by an exchange on its base tier (e.g. 0.001 means 0.1%).
+type Fees struct {
+	// Maker is the fee ratio charged on orders that add liquidity to the order book.
+	Maker JsonFloat64
+
+	// Taker is the fee ratio charged on orders that take liquidity from the order book.
+	Taker JsonFloat64
+}
+
+// DefaultFees are the base tier fees of each supported exchange, used when the input doesn't override them.
+var DefaultFees = map[string]Fees{
+	BINANCE:              {Maker: 0.001, Taker: 0.001},
+	BINANCE_USDM_FUTURES: {Maker: 0.0002, Taker: 0.0004},
+	COINBASE:             {Maker: 0.004, Taker: 0.006},
+	FTX:                  {Maker: 0.0002, Taker: 0.0007},
+	KRAKEN:               {Maker: 0.0016, Taker: 0.0026},
+	KUCOIN:               {Maker: 0.001, Taker: 0.001},
+}
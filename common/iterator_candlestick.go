@@ -1,6 +1,7 @@
 package common
 
 import (
+	"errors"
 	"time"
 )
 
@@ -8,12 +9,30 @@ type CandlestickIterator struct {
 	SavedCandlesticks []Candlestick
 	next              func() (Candlestick, error)
 	calmDuration      time.Duration
+	close             func()
 }
 
 func NewCandlestickIterator(next func() (Candlestick, error)) *CandlestickIterator {
 	return &CandlestickIterator{next: next, SavedCandlesticks: nil, calmDuration: 1 * time.Second}
 }
 
+// NewClosableCandlestickIterator is like NewCandlestickIterator, but calls close when the iterator is closed, e.g. so
+// that decorators can persist what they fetched.
+func NewClosableCandlestickIterator(next func() (Candlestick, error), close func()) *CandlestickIterator {
+	ci := NewCandlestickIterator(next)
+	ci.close = close
+	return ci
+}
+
+// Close is called when the iterator is no longer needed, even if it didn't run out of candlesticks. It must not be
+// used afterwards.
+func (ci *CandlestickIterator) Close() {
+	if ci.close != nil {
+		ci.close()
+		ci.close = nil
+	}
+}
+
 func (ci *CandlestickIterator) SaveCandlesticks() {
 	ci.SavedCandlesticks = []Candlestick{}
 }
@@ -34,7 +53,7 @@ func (ci *CandlestickIterator) GetPriceAt(at ISO8601) (JsonFloat64, error) {
 	}
 	for {
 		candlestick, err := ci.next()
-		if err == ErrRateLimit && rateLimitAttempts > 0 {
+		if errors.Is(err, ErrRateLimit) && rateLimitAttempts > 0 {
 			time.Sleep(ci.calmDuration)
 			rateLimitAttempts--
 			continue
@@ -0,0 +1,13 @@
+package common
+
+type FundingRateIterator struct {
+	next func() (FundingRate, error)
+}
+
+func NewFundingRateIterator(next func() (FundingRate, error)) *FundingRateIterator {
+	return &FundingRateIterator{next}
+}
+
+func (fi *FundingRateIterator) Next() (FundingRate, error) {
+	return fi.next()
+}
@@ -0,0 +1,79 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+)
+
+// Logger is a check's logger. It logs to the standard logger if the check's input has Debug set, and keeps the logs to
+// return them on the output if it has ReturnLogs set. Logging on a nil *Logger does nothing.
+//
+// Checks put their Logger on their context (see WithLogger), so that everything they call (e.g. exchange clients,
+// which are shared by concurrent checks) logs to the check's Logger.
+type Logger struct {
+	debug bool
+	keep  bool
+	mutex sync.Mutex
+	logs  []string
+}
+
+// NewLogger is the constructor for Logger. debug logs to the standard logger, and keep keeps the logs for Logs.
+func NewLogger(debug, keep bool) *Logger {
+	return &Logger{debug: debug, keep: keep}
+}
+
+// NewInputLogger is a Logger for the input's Debug and ReturnLogs settings.
+func NewInputLogger(input SignalCheckInput) *Logger {
+	return NewLogger(input.Debug, input.ReturnLogs)
+}
+
+// Printf logs a line, formatted like fmt.Sprintf. Lines start with the component logging them, e.g. "Checker: ".
+func (l *Logger) Printf(format string, v ...interface{}) {
+	if l == nil || (!l.debug && !l.keep) {
+		return
+	}
+	line := strings.TrimSuffix(fmt.Sprintf(format, v...), "\n")
+	if l.debug {
+		log.Output(2, line)
+	}
+	if l.keep {
+		l.mutex.Lock()
+		defer l.mutex.Unlock()
+		l.logs = append(l.logs, line)
+	}
+}
+
+// Logs returns the logs kept so far, or nil if they're not kept.
+func (l *Logger) Logs() []string {
+	if l == nil {
+		return nil
+	}
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if !l.keep {
+		return nil
+	}
+	return append([]string{}, l.logs...)
+}
+
+type loggerKey struct{}
+
+// WithLogger returns a copy of ctx with the check's logger.
+func WithLogger(ctx context.Context, logger *Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// LoggerFrom returns the check's logger on ctx. If there's none (e.g. exchanges used without a check), it returns a
+// Logger that only logs to the standard logger, if debug is set.
+func LoggerFrom(ctx context.Context, debug bool) *Logger {
+	if logger, ok := ctx.Value(loggerKey{}).(*Logger); ok {
+		return logger
+	}
+	if !debug {
+		return nil
+	}
+	return NewLogger(true, false)
+}
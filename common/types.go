@@ -2,6 +2,7 @@
 package common
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"math"
@@ -16,9 +17,15 @@ import (
 // - Durations are in seconds.
 // - All prices are floating point numbers for the given asset pair on the given exchange.
 type SignalCheckInput struct {
-	// Exchange must be one of ['binance', 'ftx', 'coinbase', 'huobi', 'kraken', 'kucoin', 'binanceusdmfutures']; default is 'binance'
+	// Exchange must be the name of a registered exchange (see signalchecker.RegisterExchange); default is 'binance'.
+	// The built-in ones are ['binance', 'ftx', 'coinbase', 'kraken', 'kucoin', 'binanceusdmfutures', 'offline'].
 	Exchange string `json:"exchange"`
 
+	// DataDir is the directory the 'offline' exchange reads candlesticks and trades from, instead of requesting them
+	// to an exchange. It's required (and only used) when Exchange is 'offline'. Please review the offline package for
+	// the expected files.
+	DataDir string `json:"dataDir"`
+
 	// BaseAsset is LTC in LTCUSDT
 	BaseAsset string `json:"baseAsset"`
 
@@ -71,9 +78,57 @@ type SignalCheckInput struct {
 	// TPs were reached.
 	TakeProfitRatios []JsonFloat64 `json:"takeProfitRatios"`
 
-	// StopLoss is the price at which to stop loss (-1 for no stop loss)
+	// StopLoss is the price at which to stop loss (-1 or 0 for no stop loss)
 	StopLoss JsonFloat64 `json:"stopLoss"`
 
+	// EntryPercents, TakeProfitPercents and StopLossPercent are alternatives to Entries, TakeProfits and StopLoss, as
+	// percentage price changes from a reference price decided by PercentsRelativeTo. Each one replaces its absolute
+	// counterpart, which must then be left empty (or 0 for StopLoss); a StopLossPercent of 0 means no stop loss.
+	//
+	// e.g. "buy at market, TP 3% 6% 9%, SL 5%" on a LONG:   takeProfitPercents: [3, 6, 9], stopLossPercent: -5
+	// e.g. the same on a SHORT:                              takeProfitPercents: [-3, -6, -9], stopLossPercent: 5
+	// e.g. to enter between the first price and 2% below:  entryPercents: [0, -2]
+	//
+	// The checker resolves them into prices as soon as the reference price is known, and returns the resolved prices
+	// on the output's Input.
+	EntryPercents      []JsonFloat64 `json:"entryPercents"`
+	TakeProfitPercents []JsonFloat64 `json:"takeProfitPercents"`
+	StopLossPercent    JsonFloat64   `json:"stopLossPercent"`
+
+	// PercentsRelativeTo is the reference price of TakeProfitPercents and StopLossPercent. One of:
+	//
+	// - first_candle: (default) the first candlestick's open price, i.e. the output's FirstCandleOpenPrice.
+	// - entry: the average entry price, which changes on every entry. InvalidateIfTPBeforeEntering doesn't apply, as
+	//   the take profits are unknown before entering.
+	//
+	// EntryPercents are always relative to the first candlestick's open price.
+	PercentsRelativeTo string `json:"percentsRelativeTo"`
+
+	// TrailingStopLossDistance, if set, makes the stop loss trail the best price seen since entering at this fixed
+	// price distance, e.g. 500 on a BTC/USDT LONG keeps the stop loss $500 below the highest price since entering.
+	// The stop loss only ever moves in the signal's favour, so StopLoss still applies until the trailing stop loss
+	// goes past it. When the trailing stop loss is hit, a 'trailing_stopped_loss' event is emitted.
+	TrailingStopLossDistance JsonFloat64 `json:"trailingStopLossDistance"`
+
+	// TrailingStopLossRatio is like TrailingStopLossDistance, but expressed as a ratio of the best price seen since
+	// entering, e.g. 0.03 to "trail SL 3% after entry". Only one of them may be set.
+	TrailingStopLossRatio JsonFloat64 `json:"trailingStopLossRatio"`
+
+	// Leverage is the leverage used to follow this signal on a futures exchange, e.g. 20 for a "20x LONG". Defaults
+	// to 1, i.e. no leverage. Profit ratios are relative to the margin, so they are multiplied by the leverage.
+	Leverage JsonFloat64 `json:"leverage"`
+
+	// MarginMode is one of 'isolated' or 'cross'; defaults to 'isolated'. Only relevant when Leverage is above 1.
+	//
+	// - isolated: only the margin of the entered position backs it, so it's liquidated when that margin is lost.
+	// - cross: all the capital invested in this signal (including what's awaiting entry and profits taken) backs the
+	//   position, so it's liquidated when all of it is lost.
+	MarginMode string `json:"marginMode"`
+
+	// MaintenanceMarginRatio is the ratio of the position's notional that must be kept as margin to avoid being
+	// liquidated. Defaults to 0.004 (i.e. Binance USD-M Futures' lowest tier) when Leverage is above 1.
+	MaintenanceMarginRatio JsonFloat64 `json:"maintenanceMarginRatio"`
+
 	// InitialISO8601 is the ISO3601 datetime at which the signal becomes valid (e.g. 2021-07-04T14:14:18+00:00)
 	InitialISO8601 ISO8601 `json:"initialISO8601"`
 
@@ -86,55 +141,198 @@ type SignalCheckInput struct {
 	// Considering a signal invalid, if entered, means "selling", either at a profit or at a loss.
 	InvalidateAfterSeconds int `json:"invalidateAfterSeconds"`
 
-	// ReturnLogs decides whether to return logs on the output.
+	// InvalidateIfTPBeforeEntering considers the signal invalid if the price reaches TP1 before any entry fills, as
+	// most signal providers consider such a signal void. Only applies if there are Entries.
+	InvalidateIfTPBeforeEntering bool `json:"invalidateIfTPBeforeEntering"`
+
+	// IntraCandleResolution decides what happened first when a single candlestick touches more than one level, e.g.
+	// both the stop loss and a take profit. One of:
+	//
+	// - low_first: (default) the lowest price happened first, i.e. pessimistic for LONG and optimistic for SHORT.
+	// - pessimistic: the price moved against the signal first, e.g. the stop loss was reached first.
+	// - optimistic: the price moved in favour of the signal first, e.g. the take profit was reached first.
+	// - exact: the exchange's trades during that candlestick are used to find out. Falls back to pessimistic if the
+	//   trades can't be fetched. This is slower, as it requests trades for every such candlestick.
+	IntraCandleResolution string `json:"intraCandleResolution"`
+
+	// ReturnLogs decides whether to return logs on the output, e.g. why each event happened, the profit calculations and
+	// the exchange API pages that were fetched. Unlike the Debug logs, they only contain this check's logs.
 	ReturnLogs bool `json:"returnLogs"`
 
 	// Debug decides whether to turn debug mode on, which means verbose stderr output.
 	Debug bool `json:"debug"`
 
+	// StopLossMovements are rules that move the stop loss when take profits are reached, e.g. "when TP2 is reached,
+	// move the stop loss to TP1". Rules are applied in the order of their take profits, so if the price jumps over
+	// many take profits at once, the rule for the highest one reached wins.
+	//
+	// e.g. to move the stop loss to entry on TP1:  stopLossMovements: [{"whenTakeProfit": 1, "moveTo": "entry"}]
+	StopLossMovements []StopLossMovement `json:"stopLossMovements"`
+
 	// IfTP1StopAtEntry is a boolean that, if set, changes the stop loss to entry if TP1 is reached.
+	// Deprecated: use StopLossMovements; this is converted into the equivalent rule upon validation.
 	IfTP1StopAtEntry bool `json:"ifTP1StopAtEntry"`
 
 	// IfTP2StopAtTP1 is a boolean that, if set, changes the stop loss to TP1 if TP2 is reached.
+	// Deprecated: use StopLossMovements; this is converted into the equivalent rule upon validation.
 	IfTP2StopAtTP1 bool `json:"ifTP2StopAtTP1"`
 
 	// IfTP3StopAtTP2 is a boolean that, if set, changes the stop loss to TP2 if TP3 is reached.
+	// Deprecated: use StopLossMovements; this is converted into the equivalent rule upon validation.
 	IfTP3StopAtTP2 bool `json:"ifTP3StopAtTP2"`
 
 	// IfTP4StopAtTP3 is a boolean that, if set, changes the stop loss to TP3 if TP4 is reached.
+	// Deprecated: use StopLossMovements; this is converted into the equivalent rule upon validation.
 	IfTP4StopAtTP3 bool `json:"ifTP4StopAtTP3"`
 
 	// DontCalculateMaxEnterUSD prevents calculation of MaxEnterUSD, which can be expensive and lengthy.
 	DontCalculateMaxEnterUSD bool `json:"dontCalculateMaxEnterUSD"`
 
+	// DontApplyFees prevents trading fees and slippage from being deducted from profit ratios, i.e. profit ratios are
+	// gross rather than net.
+	DontApplyFees bool `json:"dontApplyFees"`
+
+	// DontApplyFundingRates prevents perpetual futures funding rates from being applied to profit ratios. Funding
+	// rates are only applied on exchanges that have them, e.g. binanceusdmfutures.
+	DontApplyFundingRates bool `json:"dontApplyFundingRates"`
+
+	// DontZoom prevents scanning with hourly candlesticks, and only requesting the one minute candlesticks within those
+	// that might trigger something. Zooming doesn't change the output, but makes long checks request far fewer
+	// candlesticks. It only applies on exchanges that support hourly candlesticks, and never when ReturnCandlesticks
+	// is set.
+	DontZoom bool `json:"dontZoom"`
+
+	// MakerFeeRatio is the fee charged on fills at the signal's target prices, i.e. entries and take profits (e.g.
+	// 0.001 for 0.1%). Defaults (i.e. if not set) to the exchange's base tier maker fee, but it can be set to 0, e.g. for
+	// VIP or rebate tiers. To model no fees at all, set dontApplyFees.
+	MakerFeeRatio *JsonFloat64 `json:"makerFeeRatio"`
+
+	// TakerFeeRatio is the fee charged on market fills, i.e. stop losses, invalidations, finishing the dataset and
+	// entering immediately on signals without entries. Defaults (i.e. if not set) to the exchange's base tier taker fee.
+	TakerFeeRatio *JsonFloat64 `json:"takerFeeRatio"`
+
+	// SlippageModel is one of '' (no slippage), 'fixed' or 'volume'. Slippage only applies to market fills (see
+	// TakerFeeRatio), as the other fills happen at the signal's target prices.
+	//
+	// - fixed: every market fill costs SlippageBps basis points.
+	// - volume: every market fill costs SlippageBps basis points times the ratio of the filled base asset quantity
+	//   to the volume of the candlestick it filled on (capped at 1, which is also used when volume is unknown).
+	//   The filled quantity is calculated from Capital, which is required.
+	SlippageModel string `json:"slippageModel"`
+
+	// SlippageBps is the slippage in basis points (i.e. 1 is 0.01%) used by the SlippageModel.
+	SlippageBps JsonFloat64 `json:"slippageBps"`
+
+	// Capital is the amount of quote asset invested in this signal. It's only used by the 'volume' slippage model.
+	Capital JsonFloat64 `json:"capital"`
+
 	// ReturnCandlesticks decides if all input candlesticks should be returned with the output. This could span MBs,
 	// so should only be set when needed, e.g. to plot a candlestick chart.
 	ReturnCandlesticks bool `json:"returnCandlesticks"`
-	// TODO add invalidateIfTPBeforeEntering
+
+	// ReturnEquityCurve decides if the signal's profit ratio over time should be returned with the output, e.g. to plot
+	// how close it came to the stop loss.
+	ReturnEquityCurve bool `json:"returnEquityCurve"`
+
+	// EquityCurveSampleSeconds is how often the equity curve is sampled while entered, besides on every event.
+	// Default is 3600 (i.e. hourly).
+	EquityCurveSampleSeconds int `json:"equityCurveSampleSeconds"`
+
+	// Provider optionally tags the signal with who published it (e.g. a channel's name), so that reports can be
+	// grouped by it. It doesn't affect the check.
+	Provider string `json:"provider,omitempty"`
+}
+
+// EntryCount is the number of entries, whether they are prices or percents.
+func (input SignalCheckInput) EntryCount() int {
+	if len(input.EntryPercents) > 0 {
+		return len(input.EntryPercents)
+	}
+	return len(input.Entries)
+}
+
+// TakeProfitCount is the number of take profits, whether they are prices or percents.
+func (input SignalCheckInput) TakeProfitCount() int {
+	if len(input.TakeProfitPercents) > 0 {
+		return len(input.TakeProfitPercents)
+	}
+	return len(input.TakeProfits)
+}
+
+// StopLossMovement is a rule that moves the stop loss when a take profit is reached.
+type StopLossMovement struct {
+	// WhenTakeProfit is the take profit target (starting from 1, i.e. 1 for TP1) that triggers this rule.
+	WhenTakeProfit int `json:"whenTakeProfit"`
+
+	// MoveTo is one of 'entry', 'take_profit', 'price' or 'ratio'.
+	//
+	// - entry: moves the stop loss to the average entry price (i.e. break even).
+	// - take_profit: moves the stop loss to the price of the TakeProfit target.
+	// - price: moves the stop loss to Price.
+	// - ratio: moves the stop loss Ratio away from the average entry price, in the signal's favour (e.g. 0.01 is 1%
+	//   in profit, whereas -0.02 is 2% in loss).
+	MoveTo string `json:"moveTo"`
+
+	// TakeProfit is the take profit target (starting from 1) to move the stop loss to, when MoveTo is 'take_profit'.
+	TakeProfit int `json:"takeProfit,omitempty"`
+
+	// Price is the price to move the stop loss to, when MoveTo is 'price'.
+	Price JsonFloat64 `json:"price,omitempty"`
+
+	// Ratio is the ratio from the average entry price to move the stop loss to, when MoveTo is 'ratio'.
+	Ratio JsonFloat64 `json:"ratio,omitempty"`
 }
 
 const (
-	ENTERED          = "entered"
-	STOPPED_LOSS     = "stopped_loss"
-	INVALIDATED      = "invalidated"
-	FINISHED_DATASET = "finished_dataset"
-	TOOK_PROFIT      = "took_profit"
+	ENTERED               = "entered"
+	STOPPED_LOSS          = "stopped_loss"
+	TRAILING_STOPPED_LOSS = "trailing_stopped_loss"
+	LIQUIDATED            = "liquidated"
+	INVALIDATED           = "invalidated"
+	FINISHED_DATASET      = "finished_dataset"
+	TOOK_PROFIT           = "took_profit"
+
+	INVALIDATION_REASON_EXPIRED                     = "expired"
+	INVALIDATION_REASON_TOOK_PROFIT_BEFORE_ENTERING = "took_profit_before_entering"
 
 	BINANCE              = "binance"
 	FTX                  = "ftx"
 	COINBASE             = "coinbase"
-	HUOBI                = "huobi"
 	KRAKEN               = "kraken"
 	KUCOIN               = "kucoin"
 	BINANCE_USDM_FUTURES = "binanceusdmfutures"
+	OFFLINE              = "offline"
+
+	// Deprecated: Huobi is not supported, so checks with this exchange fail validation.
+	HUOBI = "huobi"
 
 	// Used for testing
 	FAKE = "fake"
+
+	MOVE_STOP_LOSS_TO_ENTRY       = "entry"
+	MOVE_STOP_LOSS_TO_TAKE_PROFIT = "take_profit"
+	MOVE_STOP_LOSS_TO_PRICE       = "price"
+	MOVE_STOP_LOSS_TO_RATIO       = "ratio"
+
+	SLIPPAGE_MODEL_FIXED  = "fixed"
+	SLIPPAGE_MODEL_VOLUME = "volume"
+
+	MARGIN_MODE_ISOLATED = "isolated"
+	MARGIN_MODE_CROSS    = "cross"
+
+	INTRA_CANDLE_RESOLUTION_LOW_FIRST   = "low_first"
+	INTRA_CANDLE_RESOLUTION_PESSIMISTIC = "pessimistic"
+	INTRA_CANDLE_RESOLUTION_OPTIMISTIC  = "optimistic"
+	INTRA_CANDLE_RESOLUTION_EXACT       = "exact"
+
+	PERCENTS_RELATIVE_TO_FIRST_CANDLE = "first_candle"
+	PERCENTS_RELATIVE_TO_ENTRY        = "entry"
 )
 
 // SignalCheckOutputEvent is an event that happened upon checking a signal.
 type SignalCheckOutputEvent struct {
-	// EventType is one of entered, took_profit, stopped_loss, invalidated, finished_dataset.
+	// EventType is one of entered, took_profit, stopped_loss, trailing_stopped_loss, liquidated, invalidated,
+	// finished_dataset.
 	EventType string `json:"eventType"`
 
 	// Target is, in the case of 'entered' and 'took_profit', which entry or take profit target, e.g. TP1, TP2.
@@ -148,6 +346,15 @@ type SignalCheckOutputEvent struct {
 
 	// ProfitRatio answers how much the profit/loss of this signal is up to this point.
 	ProfitRatio JsonFloat64 `json:"takeProfitRatio"`
+
+	// Ambiguous is true if this event happened on a candlestick that touched more than one level (e.g. both the stop
+	// loss and a take profit), so its ordering was decided by the IntraCandleResolution, or by the exchange's trades
+	// for 'exact'.
+	Ambiguous bool `json:"ambiguous,omitempty"`
+
+	// Reason is, in the case of 'invalidated', why the signal was invalidated: one of expired (i.e. reached
+	// InvalidateISO8601 or InvalidateAfterSeconds) or took_profit_before_entering.
+	Reason string `json:"reason,omitempty"`
 }
 
 type ISO8601 string
@@ -197,14 +404,28 @@ type SignalCheckOutput struct {
 	// HighestTakeProfit is the highest take profit reached from the signal input (0 means none were reached).
 	HighestTakeProfit int `json:"highestTakeProfit"`
 
-	// ReachedStopLoss is a boolean that answers if, upon checking this signal, a stop loss was reached.
+	// ReachedStopLoss is a boolean that answers if, upon checking this signal, a stop loss (trailing or not) was
+	// reached.
 	ReachedStopLoss bool `json:"reachedStopLoss"`
 
+	// Liquidated is a boolean that answers if, upon checking this signal, the leveraged position was liquidated
+	// before reaching the stop loss.
+	Liquidated bool `json:"liquidated"`
+
 	// ProfitRatio answers how much the profit/loss of following this signal would have been.
 	// A profit ratio of 0.0 means break even. A profit ratio of 1.0 means doubling your investment.
 	// To calculate how much you would profit, multiply your investment times the profit ratio.
+	// Unless input.dontApplyFees is set, the profit ratio is net of trading fees and slippage.
 	ProfitRatio JsonFloat64 `json:"profitRatio"`
 
+	// TradingCostRatio answers how much of the investment was spent on trading fees and slippage. It's already
+	// deducted from ProfitRatio.
+	TradingCostRatio JsonFloat64 `json:"tradingCostRatio,omitempty"`
+
+	// FundingRatio answers how much of the investment was received (if positive) or paid (if negative) on perpetual
+	// futures funding while the position was open. It's already included in ProfitRatio.
+	FundingRatio JsonFloat64 `json:"fundingRatio,omitempty"`
+
 	// IsError is a boolean that answers if there was any error checking this signal. This boolean should always be
 	// checked first, because if it is true, all other output values are meaningless, except for the ones that describe
 	// the error.
@@ -230,6 +451,28 @@ type SignalCheckOutput struct {
 	MaxEnterUSD JsonFloat64 `json:"maxEnterUSD,omitempty"`
 
 	Candlesticks []Candlestick `json:"candlesticks,omitempty"`
+
+	// MaxFavourableExcursionRatio is the highest profit ratio the signal reached while entered, i.e. the best moment
+	// to close it.
+	MaxFavourableExcursionRatio JsonFloat64 `json:"maxFavourableExcursionRatio,omitempty"`
+
+	// MaxAdverseExcursionRatio is the lowest profit ratio the signal reached while entered, i.e. how close it came to
+	// the stop loss.
+	MaxAdverseExcursionRatio JsonFloat64 `json:"maxAdverseExcursionRatio,omitempty"`
+
+	// MaxDrawdownRatio is the largest drop of the invested capital (i.e. 1 + profit ratio) from a previous peak while
+	// entered, as a ratio of that peak.
+	MaxDrawdownRatio JsonFloat64 `json:"maxDrawdownRatio,omitempty"`
+
+	// TimeToFirstEntrySeconds is how long it took the signal to enter since its initial time.
+	TimeToFirstEntrySeconds int `json:"timeToFirstEntrySeconds,omitempty"`
+
+	// TimeInPositionSeconds is how long the signal was entered, until its last event.
+	TimeInPositionSeconds int `json:"timeInPositionSeconds,omitempty"`
+
+	// EquityCurve is the signal's profit ratio over time while entered. It's only returned when
+	// input.returnEquityCurve is set.
+	EquityCurve []EquityCurvePoint `json:"equityCurve,omitempty"`
 }
 
 // Candlestick is the generic struct for candlestick data for all supported exchanges.
@@ -257,7 +500,8 @@ type Candlestick struct {
 }
 
 // ToTicks converts a Candlestick to two Ticks. Lowest value is put first, because since there's no way to tell
-// which one happened first, this library chooses to be pessimistic.
+// which one happened first, this library chooses to be pessimistic (for LONG signals). Note that the signal checker
+// may reorder them, depending on the IntraCandleResolution.
 func (c Candlestick) ToTicks() []Tick {
 	return []Tick{
 		{Timestamp: c.Timestamp, Volume: c.Volume, NumberOfTrades: c.NumberOfTrades, Price: c.LowestPrice},
@@ -278,6 +522,10 @@ type Tick struct {
 
 	// NumberOfTrades (may not exist) is the total number of filled order book orders in the candlestick of this tick.
 	NumberOfTrades int `json:"n,omitempty"`
+
+	// Ambiguous is true if this tick comes from a candlestick that touched more than one level, and it's unknown which
+	// one happened first.
+	Ambiguous bool `json:"a,omitempty"`
 }
 
 // Trade is an order filled on an exchange for some price & quantity of a base asset.
@@ -292,9 +540,25 @@ type Trade struct {
 	Timestamp int `json:"t"`
 }
 
+// EquityCurvePoint is the profit ratio of a signal at a point in time.
+type EquityCurvePoint struct {
+	At          ISO8601     `json:"at"`
+	ProfitRatio JsonFloat64 `json:"profitRatio"`
+}
+
+// FundingRate is a perpetual futures funding rate settlement.
+type FundingRate struct {
+	// Rate is the ratio of the position's notional that longs pay to shorts (or shorts to longs, when negative).
+	Rate JsonFloat64 `json:"r"`
+
+	// Timestamp is the UNIX timestamp (i.e. seconds since UTC Epoch) at which this funding rate was settled.
+	Timestamp int `json:"t"`
+}
+
 var (
 	ErrOutOfCandlesticks                           = errors.New("exchange ran out of candlesticks")
 	ErrOutOfTrades                                 = errors.New("exchange ran out of trades")
+	ErrOutOfFundingRates                           = errors.New("exchange ran out of funding rates")
 	ErrInvalidMarketPair                           = errors.New("market pair does not exist on exchange")
 	ErrRateLimit                                   = errors.New("exchange asked us to enhance our calm")
 	ErrInvalidEntriesLength                        = errors.New("entries must either be empty or have two values or more (because a range is made of at least 2 numbers)")
@@ -303,17 +567,50 @@ var (
 	ErrStopLossIsLessThanOrEqualToEnterRangeHigh   = errors.New("stopLoss is <= enterRangeHigh; if you want no stopLoss, set the value to -1")
 	ErrFirstTPIsLessThanOrEqualToEnterRangeHigh    = errors.New("first take profit is <= enterRangeHigh")
 	ErrFirstTPIsGreaterThanOrEqualToEnterRangeLow  = errors.New("first take profit is >= enterRangeLow")
-	ErrInvalidExchange                             = errors.New("the only valid exchanges are 'binance', 'ftx', 'coinbase', 'huobi', 'kraken', 'kucoin' and 'binanceusdmfutures'")
+	ErrInvalidExchange                             = errors.New("invalid exchange")
 	ErrInitialISO8601Required                      = errors.New("InitialISO8601 is required")
 	ErrInitialISO8601FormattedIncorrectly          = errors.New("InitialISO8601 is formatted incorrectly, should be ISO3601 e.g. 2021-07-04T14:14:18+00:00")
 	ErrInvalidateISO8601FormattedIncorrectly       = errors.New("InvalidateISO8601 is formatted incorrectly, should be ISO3601 e.g. 2021-07-04T14:14:18+00:00")
 	ErrTakeProfitRatiosMustAddUpToOne              = errors.New("takeProfitRatios must add up to 1 (but it does not need to match the takeProfits length)")
 	ErrBaseAssetRequired                           = errors.New("base asset is required (e.g. BTC)")
 	ErrQuoteAssetRequired                          = errors.New("quote asset is required (e.g. USDT)")
+	ErrTrailingStopLossMustNotBeNegative           = errors.New("trailingStopLossDistance and trailingStopLossRatio must not be negative")
+	ErrTrailingStopLossRatioMustBeLessThanOne      = errors.New("trailingStopLossRatio must be less than 1")
+	ErrOnlyOneTrailingStopLossAllowed              = errors.New("only one of trailingStopLossDistance and trailingStopLossRatio may be set")
+	ErrStopLossMovementWhenTakeProfitInvalid       = errors.New("stopLossMovements whenTakeProfit must be a take profit target, starting from 1")
+	ErrStopLossMovementWhenTakeProfitDoesNotExist  = errors.New("stopLossMovements whenTakeProfit must not be greater than the number of take profits, as it could never be reached")
+	ErrStopLossMovementMoveToInvalid               = errors.New("stopLossMovements moveTo must be one of 'entry', 'take_profit', 'price' or 'ratio'")
+	ErrStopLossMovementTakeProfitInvalid           = errors.New("stopLossMovements takeProfit must be one of the take profit targets, starting from 1")
+	ErrStopLossMovementPriceInvalid                = errors.New("stopLossMovements price must be greater than 0")
+	ErrFeeRatiosMustBeBetweenZeroAndOne            = errors.New("makerFeeRatio and takerFeeRatio must be >= 0 and < 1")
+	ErrInvalidSlippageModel                        = errors.New("slippageModel must be one of '', 'fixed' or 'volume'")
+	ErrSlippageBpsMustNotBeNegative                = errors.New("slippageBps must not be negative")
+	ErrCapitalRequiredForVolumeSlippage            = errors.New("capital is required (and must be greater than 0) for the 'volume' slippage model")
+	ErrLeverageMustBeAtLeastOne                    = errors.New("leverage must be at least 1")
+	ErrInvalidMarginMode                           = errors.New("marginMode must be one of 'isolated' or 'cross'")
+	ErrMaintenanceMarginRatioInvalid               = errors.New("maintenanceMarginRatio must be >= 0 and < 1/leverage")
+	ErrInvalidIntraCandleResolution                = errors.New("intraCandleResolution must be one of 'low_first', 'pessimistic', 'optimistic' or 'exact'")
+	ErrDataDirRequired                             = errors.New("dataDir is required when exchange is 'offline'")
+	ErrEquityCurveSampleSecondsMustNotBeNegative   = errors.New("equityCurveSampleSeconds must not be negative")
+	ErrPricesAndPercentsBothSet                    = errors.New("only one of entries and entryPercents, takeProfits and takeProfitPercents, and stopLoss and stopLossPercent may be set")
+	ErrPercentsMustBeGreaterThanMinusOneHundred    = errors.New("entryPercents, takeProfitPercents and stopLossPercent must be greater than -100")
+	ErrInvalidPercentsRelativeTo                   = errors.New("percentsRelativeTo must be one of 'first_candle' or 'entry'")
+	ErrTakeProfitPercentsMustBeInFavour            = errors.New("takeProfitPercents relative to the entry must be positive for LONG signals and negative for SHORT signals")
+	ErrStopLossPercentMustBeAgainst                = errors.New("stopLossPercent relative to the entry must be negative for LONG signals and positive for SHORT signals")
 )
 
 type JsonFloat64 float64
 
+// NewJsonFloat64 returns a pointer to f, e.g. to set optional fields like MakerFeeRatio.
+func NewJsonFloat64(f JsonFloat64) *JsonFloat64 {
+	return &f
+}
+
+// String formats it like a float64, so that optional (i.e. pointer) fields print their value rather than their address.
+func (jf JsonFloat64) String() string {
+	return fmt.Sprint(float64(jf))
+}
+
 func (jf JsonFloat64) MarshalJSON() ([]byte, error) {
 	f := float64(jf)
 	if math.IsInf(f, 0) || math.IsNaN(f) {
@@ -334,7 +631,66 @@ func (jf JsonFloat64) MarshalJSON() ([]byte, error) {
 }
 
 type Exchange interface {
-	BuildCandlestickIterator(baseAsset, quoteAsset string, initialISO8601 ISO8601) *CandlestickIterator
-	BuildTradeIterator(baseAsset, quoteAsset string, initialISO8601 ISO8601) *TradeIterator
+	BuildCandlestickIterator(ctx context.Context, baseAsset, quoteAsset string, initialISO8601 ISO8601) *CandlestickIterator
+	BuildTradeIterator(ctx context.Context, baseAsset, quoteAsset string, initialISO8601 ISO8601) *TradeIterator
 	SetDebug(debug bool)
 }
+
+// FundingRateExchange is implemented by exchanges of perpetual futures, whose positions pay or receive funding.
+type FundingRateExchange interface {
+	BuildFundingRateIterator(ctx context.Context, baseAsset, quoteAsset string, initialISO8601 ISO8601) *FundingRateIterator
+}
+
+// MultiResolutionExchange is implemented by exchanges that can build candlestick iterators at resolutions other than
+// one minute. Checks use it to scan with coarse candlesticks, and only request the one minute ones where needed.
+type MultiResolutionExchange interface {
+	// BuildCandlestickIteratorAtResolution is like BuildCandlestickIterator, but its candlesticks last
+	// resolutionSeconds, which must be one of the exchange's declared Resolutions. Callers align initialISO8601 to the
+	// resolution (e.g. to the hour), and candlesticks are returned in ascending order from it.
+	BuildCandlestickIteratorAtResolution(ctx context.Context, baseAsset, quoteAsset string, initialISO8601 ISO8601, resolutionSeconds int) *CandlestickIterator
+}
+
+// CapableExchange is implemented by exchanges that declare their capabilities. Exchanges that don't are assumed to
+// support trades, not to be futures exchanges and to have no default fees.
+type CapableExchange interface {
+	Capabilities() ExchangeCapabilities
+}
+
+// ExchangeCapabilities are what an exchange supports, so that checks don't ask it for data it can't provide.
+type ExchangeCapabilities struct {
+	// Trades is whether the exchange's trades can be iterated. Without them, maxEnterUSD isn't calculated, and the
+	// 'exact' intra candle resolution falls back to 'pessimistic'.
+	Trades bool
+
+	// Futures is whether the exchange's markets are perpetual futures, so that funding rates are applied (if the
+	// exchange is also a FundingRateExchange).
+	Futures bool
+
+	// Fees are the exchange's default fees, used when the input doesn't override them.
+	Fees Fees
+
+	// Resolutions are the candlestick durations in seconds, besides one minute, that the exchange can iterate (if it's
+	// also a MultiResolutionExchange).
+	Resolutions []int
+}
+
+// SupportsResolution answers if the exchange declares that it can iterate candlesticks of resolutionSeconds.
+func (c ExchangeCapabilities) SupportsResolution(resolutionSeconds int) bool {
+	for _, resolution := range c.Resolutions {
+		if resolution == resolutionSeconds {
+			return true
+		}
+	}
+	return false
+}
+
+// DefaultExchangeCapabilities are the capabilities of exchanges that don't declare them.
+var DefaultExchangeCapabilities = ExchangeCapabilities{Trades: true}
+
+// CapabilitiesOf returns the capabilities the exchange declares, or DefaultExchangeCapabilities if it doesn't.
+func CapabilitiesOf(exchange Exchange) ExchangeCapabilities {
+	if capableExchange, ok := exchange.(CapableExchange); ok {
+		return capableExchange.Capabilities()
+	}
+	return DefaultExchangeCapabilities
+}
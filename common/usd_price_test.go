@@ -1,6 +1,9 @@
 package common
 
-import "testing"
+import (
+	"context"
+	"testing"
+)
 
 //"USDT", "USDC", "BUSD", "DAI", "USD"
 
@@ -161,7 +164,7 @@ func TestUSDPrice(t *testing.T) {
 			exchange := NewTestExchange(ts.candlestickGroups)
 			input := SignalCheckInput{BaseAsset: ts.baseAsset, QuoteAsset: ts.quoteAsset, Debug: true}
 			event := SignalCheckOutputEvent{EventType: ENTERED, At: ts.eventAt, Price: ts.eventPrice}
-			actualPrice, actualErr := GetUSDPricePerBaseAssetUnitAtEvent(exchange, input, event)
+			actualPrice, actualErr := GetUSDPricePerBaseAssetUnitAtEvent(context.Background(), exchange, input, event)
 			if actualErr != nil && !ts.expectedErr {
 				t.Fatalf("Expected no error, but failed with %v", actualErr)
 			}
@@ -184,7 +187,7 @@ func NewTestExchange(mockCandlestickGroups [][]Candlestick) *testExchange {
 	return &testExchange{mockCandlestickGroups: mockCandlestickGroups, candlestickI: -1}
 }
 
-func (t *testExchange) BuildCandlestickIterator(baseAsset, quoteAsset string, initialISO8601 ISO8601) *CandlestickIterator {
+func (t *testExchange) BuildCandlestickIterator(ctx context.Context, baseAsset, quoteAsset string, initialISO8601 ISO8601) *CandlestickIterator {
 	t.candlestickI++
 	testCandlestickIterator := func(cs []Candlestick) func() (Candlestick, error) {
 		i := 0
@@ -198,7 +201,7 @@ func (t *testExchange) BuildCandlestickIterator(baseAsset, quoteAsset string, in
 	}
 	return NewCandlestickIterator(testCandlestickIterator(t.mockCandlestickGroups[t.candlestickI]))
 }
-func (t testExchange) BuildTradeIterator(baseAsset, quoteAsset string, initialISO8601 ISO8601) *TradeIterator {
+func (t testExchange) BuildTradeIterator(ctx context.Context, baseAsset, quoteAsset string, initialISO8601 ISO8601) *TradeIterator {
 	return nil
 }
 func (t testExchange) SetDebug(debug bool) {}
@@ -1,29 +1,45 @@
 package fake
 
 import (
+	"context"
+
 	"github.com/marianogappa/signal-checker/common"
 )
 
 type Fake struct {
 	candlesticks []common.Candlestick
 	trades       []common.Trade
+	fundingRates []common.FundingRate
 	returnErr    error
 }
 
-func NewFake(candlesticks []common.Candlestick, trades []common.Trade, returnErr error) *Fake {
-	return &Fake{candlesticks: candlesticks, trades: trades, returnErr: returnErr}
+func NewFake(candlesticks []common.Candlestick, trades []common.Trade, fundingRates []common.FundingRate, returnErr error) *Fake {
+	return &Fake{candlesticks: candlesticks, trades: trades, fundingRates: fundingRates, returnErr: returnErr}
 }
 
 func (b *Fake) SetDebug(debug bool) {}
 
-func (b Fake) BuildCandlestickIterator(baseAsset, quoteAsset string, initialISO8601 common.ISO8601) *common.CandlestickIterator {
+func (b Fake) Capabilities() common.ExchangeCapabilities {
+	return common.ExchangeCapabilities{Trades: true, Futures: true, Resolutions: []int{3600}}
+}
+
+func (b Fake) BuildCandlestickIterator(ctx context.Context, baseAsset, quoteAsset string, initialISO8601 common.ISO8601) *common.CandlestickIterator {
 	return common.NewCandlestickIterator(b.testCandlestickIterator(b.candlesticks))
 }
 
-func (b Fake) BuildTradeIterator(baseAsset, quoteAsset string, initialISO8601 common.ISO8601) *common.TradeIterator {
+// BuildCandlestickIteratorAtResolution aggregates the fake's candlesticks into candlesticks of resolutionSeconds.
+func (b Fake) BuildCandlestickIteratorAtResolution(ctx context.Context, baseAsset, quoteAsset string, initialISO8601 common.ISO8601, resolutionSeconds int) *common.CandlestickIterator {
+	return common.NewCandlestickIterator(b.testCandlestickIterator(aggregate(b.candlesticks, resolutionSeconds)))
+}
+
+func (b Fake) BuildTradeIterator(ctx context.Context, baseAsset, quoteAsset string, initialISO8601 common.ISO8601) *common.TradeIterator {
 	return common.NewTradeIterator(b.testTradeIterator(b.trades))
 }
 
+func (b Fake) BuildFundingRateIterator(ctx context.Context, baseAsset, quoteAsset string, initialISO8601 common.ISO8601) *common.FundingRateIterator {
+	return common.NewFundingRateIterator(b.testFundingRateIterator(b.fundingRates))
+}
+
 func (b Fake) testCandlestickIterator(cs []common.Candlestick) func() (common.Candlestick, error) {
 	i := 0
 	last := common.Candlestick{}
@@ -48,3 +64,36 @@ func (b Fake) testTradeIterator(ts []common.Trade) func() (common.Trade, error)
 		return ts[i-1], b.returnErr
 	}
 }
+func (b Fake) testFundingRateIterator(rs []common.FundingRate) func() (common.FundingRate, error) {
+	i := 0
+	return func() (common.FundingRate, error) {
+		if i >= len(rs) {
+			return common.FundingRate{}, common.ErrOutOfFundingRates
+		}
+		i++
+		return rs[i-1], nil
+	}
+}
+
+func aggregate(cs []common.Candlestick, resolutionSeconds int) []common.Candlestick {
+	aggregated := []common.Candlestick{}
+	for _, c := range cs {
+		timestamp := c.Timestamp - c.Timestamp%resolutionSeconds
+		if len(aggregated) == 0 || aggregated[len(aggregated)-1].Timestamp != timestamp {
+			c.Timestamp = timestamp
+			aggregated = append(aggregated, c)
+			continue
+		}
+		last := &aggregated[len(aggregated)-1]
+		last.ClosePrice = c.ClosePrice
+		if c.LowestPrice < last.LowestPrice {
+			last.LowestPrice = c.LowestPrice
+		}
+		if c.HighestPrice > last.HighestPrice {
+			last.HighestPrice = c.HighestPrice
+		}
+		last.Volume += c.Volume
+		last.NumberOfTrades += c.NumberOfTrades
+	}
+	return aggregated
+}
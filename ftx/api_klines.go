@@ -1,13 +1,12 @@
 package ftx
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
-	"log"
 	"net/http"
 	"strings"
-	"time"
 
 	"github.com/marianogappa/signal-checker/common"
 )
@@ -64,17 +63,15 @@ type klinesResult struct {
 	httpStatus      int
 }
 
-func (f FTX) getKlines(baseAsset string, quoteAsset string, startTimeSecs int) (klinesResult, error) {
-	req, _ := http.NewRequest("GET", fmt.Sprintf("%vmarkets/%v/%v/candles", f.apiURL, strings.ToUpper(baseAsset), strings.ToUpper(quoteAsset)), nil)
+func (f FTX) getKlines(ctx context.Context, baseAsset string, quoteAsset string, startTimeSecs int, resolutionSeconds int) (klinesResult, error) {
+	req, _ := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%vmarkets/%v/%v/candles", f.apiURL, strings.ToUpper(baseAsset), strings.ToUpper(quoteAsset)), nil)
 	q := req.URL.Query()
-	q.Add("resolution", "60")
+	q.Add("resolution", fmt.Sprintf("%v", resolutionSeconds))
 	q.Add("start_time", fmt.Sprintf("%v", startTimeSecs))
 
 	req.URL.RawQuery = q.Encode()
 
-	client := &http.Client{Timeout: 10 * time.Second}
-
-	resp, err := client.Do(req)
+	resp, err := f.client.Do(req, 1)
 	if err != nil {
 		return klinesResult{err: err}, err
 	}
@@ -107,7 +104,7 @@ func (f FTX) getKlines(baseAsset string, quoteAsset string, startTimeSecs int) (
 		}, err
 	}
 
-	log.Printf("FTX candlestick request successful! Candlestick count: %v\n", len(maybeResponse.Result))
+	common.LoggerFrom(ctx, f.client.Debug()).Printf("FTX candlestick request successful! Candlestick count: %v\n", len(maybeResponse.Result))
 
 	return klinesResult{
 		candlesticks: maybeResponse.toCandlesticks(),
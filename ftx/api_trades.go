@@ -0,0 +1,122 @@
+package ftx
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/marianogappa/signal-checker/common"
+)
+
+//[
+//	{
+//		"id":3855995,
+//		"liquidation":false,
+//		"price":3857.75,
+//		"side":"buy",
+//		"size":0.111,
+//		"time":"2019-03-20T18:16:23.397991+00:00"
+//	}
+//]
+type responseTrade struct {
+	ID          int     `json:"id"`
+	Liquidation bool    `json:"liquidation"`
+	Price       float64 `json:"price"`
+	Side        string  `json:"side"`
+	Size        float64 `json:"size"`
+	Time        string  `json:"time"`
+}
+
+type tradesResponse struct {
+	Success bool            `json:"success"`
+	Error   string          `json:"error"`
+	Result  []responseTrade `json:"result"`
+}
+
+// toTrades converts the response's trades, which FTX returns in descending order, into trades in ascending order.
+func (r tradesResponse) toTrades() ([]common.Trade, error) {
+	sort.SliceStable(r.Result, func(i, j int) bool {
+		return r.Result[i].Time < r.Result[j].Time
+	})
+	trades := make([]common.Trade, len(r.Result))
+	for i, raw := range r.Result {
+		tm, err := time.Parse(time.RFC3339Nano, raw.Time)
+		if err != nil {
+			return trades, fmt.Errorf("trade %v had time = %v! Invalid syntax from FTX", i, raw.Time)
+		}
+		trades[i] = common.Trade{
+			BaseAssetPrice:    common.JsonFloat64(raw.Price),
+			BaseAssetQuantity: common.JsonFloat64(raw.Size),
+			Timestamp:         int(tm.Unix()),
+		}
+	}
+	return trades, nil
+}
+
+type tradesResult struct {
+	trades          []common.Trade
+	err             error
+	ftxErrorMessage string
+	httpStatus      int
+}
+
+// getTrades requests the trades between the given times (in seconds), in ascending order. N.B. if there are more
+// than {limit} trades, FTX returns the latest ones.
+func (f FTX) getTrades(ctx context.Context, baseAsset string, quoteAsset string, startTimeSecs, endTimeSecs, limit int) (tradesResult, error) {
+	req, _ := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%vmarkets/%v/%v/trades", f.apiURL, strings.ToUpper(baseAsset), strings.ToUpper(quoteAsset)), nil)
+	q := req.URL.Query()
+	q.Add("start_time", fmt.Sprintf("%v", startTimeSecs))
+	q.Add("end_time", fmt.Sprintf("%v", endTimeSecs))
+	q.Add("limit", fmt.Sprintf("%v", limit))
+
+	req.URL.RawQuery = q.Encode()
+
+	resp, err := f.client.Do(req, 1)
+	if err != nil {
+		return tradesResult{err: err}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		byts, _ := ioutil.ReadAll(resp.Body)
+		err := fmt.Errorf("ftx returned %v status code with payload [%v]", resp.StatusCode, string(byts))
+		return tradesResult{httpStatus: 500, err: err}, err
+	}
+
+	byts, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		err := fmt.Errorf("ftx returned broken body response! Was: %v", string(byts))
+		return tradesResult{err: err, httpStatus: 500}, err
+	}
+
+	maybeResponse := tradesResponse{}
+	err = json.Unmarshal(byts, &maybeResponse)
+	if err != nil {
+		err := fmt.Errorf("ftx returned invalid JSON response! Was: %v", string(byts))
+		return tradesResult{err: err, httpStatus: 500}, err
+	}
+
+	if !maybeResponse.Success {
+		err := fmt.Errorf("FTX returned error: %v", maybeResponse.Error)
+		return tradesResult{
+			httpStatus:      500,
+			ftxErrorMessage: maybeResponse.Error,
+			err:             err,
+		}, err
+	}
+
+	trades, err := maybeResponse.toTrades()
+	if err != nil {
+		return tradesResult{httpStatus: 500, err: err}, err
+	}
+
+	return tradesResult{
+		trades:     trades,
+		httpStatus: 200,
+	}, nil
+}
@@ -0,0 +1,86 @@
+package ftx
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/marianogappa/signal-checker/common"
+)
+
+type expectedTrade struct {
+	trade common.Trade
+	err   error
+}
+
+// newTradesServer simulates FTX's trades endpoint, which returns the latest trades within the requested time window,
+// in descending order.
+func newTradesServer(trades []responseTrade) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		startTime, _ := strconv.Atoi(r.URL.Query().Get("start_time"))
+		endTime, _ := strconv.Atoi(r.URL.Query().Get("end_time"))
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		page := []responseTrade{}
+		for i := len(trades) - 1; i >= 0 && len(page) < limit; i-- {
+			tm, _ := time.Parse(time.RFC3339Nano, trades[i].Time)
+			if tm.Unix() >= int64(startTime) && tm.Unix() <= int64(endTime) {
+				page = append(page, trades[i])
+			}
+		}
+		byts, _ := json.Marshal(tradesResponse{Success: true, Result: page})
+		fmt.Fprintln(w, string(byts))
+	}))
+}
+
+func TestTrades(t *testing.T) {
+	ts := newTradesServer([]responseTrade{
+		{ID: 1, Price: 34000, Side: "buy", Size: 0.1, Time: "2021-07-04T14:14:17.397991+00:00"},
+		{ID: 2, Price: 34001, Side: "buy", Size: 0.2, Time: "2021-07-04T14:14:18.397991+00:00"},
+		{ID: 3, Price: 34002, Side: "sell", Size: 0.3, Time: "2021-07-04T14:15:18.000000+00:00"},
+		{ID: 4, Price: 34003, Side: "sell", Size: 0.4, Time: "2021-07-04T14:20:00.000000+00:00"},
+	})
+	defer ts.Close()
+
+	f := NewFTX()
+	f.overrideAPIURL(ts.URL + "/")
+	ti := f.BuildTradeIterator(context.Background(), "BTC", "USD", "2021-07-04T14:14:18+00:00")
+
+	expectedResults := []expectedTrade{
+		{trade: common.Trade{BaseAssetPrice: 34001, BaseAssetQuantity: 0.2, Timestamp: 1625408058}, err: nil},
+		{trade: common.Trade{BaseAssetPrice: 34002, BaseAssetQuantity: 0.3, Timestamp: 1625408118}, err: nil},
+		{trade: common.Trade{BaseAssetPrice: 34003, BaseAssetQuantity: 0.4, Timestamp: 1625408400}, err: nil},
+		{trade: common.Trade{}, err: common.ErrOutOfTrades},
+	}
+	for i, expectedResult := range expectedResults {
+		actualTrade, actualErr := ti.Next()
+		if actualTrade != expectedResult.trade {
+			t.Errorf("on trade %v expected %v but got %v", i, expectedResult.trade, actualTrade)
+			t.FailNow()
+		}
+		if actualErr != expectedResult.err {
+			t.Errorf("on trade %v expected no errors but this error happened %v", i, actualErr)
+			t.FailNow()
+		}
+	}
+}
+
+func TestTradesError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"success":false,"error":"No such market: DOGE/SHIB"}`)
+	}))
+	defer ts.Close()
+
+	f := NewFTX()
+	f.overrideAPIURL(ts.URL + "/")
+	ti := f.BuildTradeIterator(context.Background(), "DOGE", "SHIB", "2021-07-04T14:14:18+00:00")
+
+	_, err := ti.Next()
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+}
@@ -1,6 +1,7 @@
 package ftx
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -35,7 +36,7 @@ func TestCandlesticks(t *testing.T) {
 
 	b := NewFTX()
 	b.overrideAPIURL(ts.URL + "/")
-	ci := b.BuildCandlestickIterator("BTC", "USDT", "2021-07-04T14:14:18+00:00")
+	ci := b.BuildCandlestickIterator(context.Background(), "BTC", "USDT", "2021-07-04T14:14:18+00:00")
 
 	expectedResults := []expected{
 		{
@@ -1,16 +1,21 @@
 package ftx
 
 import (
+	"context"
+
 	"github.com/marianogappa/signal-checker/common"
+	"github.com/marianogappa/signal-checker/httpclient"
 )
 
 type FTX struct {
 	apiURL string
-	debug  bool
+	client *httpclient.Client
 }
 
-func NewFTX() *FTX {
-	return &FTX{apiURL: "https://ftx.com/api/"}
+// NewFTX is the constructor for FTX.
+func NewFTX(opts ...httpclient.Option) *FTX {
+	client := httpclient.NewClient("ftx", httpclient.FTXLimit, opts...)
+	return &FTX{apiURL: client.BaseURL("https://ftx.com/api/"), client: client}
 }
 
 func (f *FTX) overrideAPIURL(apiURL string) {
@@ -18,13 +23,23 @@ func (f *FTX) overrideAPIURL(apiURL string) {
 }
 
 func (b *FTX) SetDebug(debug bool) {
-	b.debug = debug
+	b.client.SetDebug(debug)
+}
+
+func (f FTX) Capabilities() common.ExchangeCapabilities {
+	return common.ExchangeCapabilities{Trades: true, Resolutions: []int{3600}, Fees: common.DefaultFees[common.FTX]}
+}
+
+func (f FTX) BuildCandlestickIterator(ctx context.Context, baseAsset, quoteAsset string, initialISO8601 common.ISO8601) *common.CandlestickIterator {
+	return common.NewCandlestickIterator(f.newCandlestickIterator(ctx, baseAsset, quoteAsset, initialISO8601, 60).next)
 }
 
-func (f FTX) BuildCandlestickIterator(baseAsset, quoteAsset string, initialISO8601 common.ISO8601) *common.CandlestickIterator {
-	return common.NewCandlestickIterator(f.newCandlestickIterator(baseAsset, quoteAsset, initialISO8601).next)
+// BuildCandlestickIteratorAtResolution passes resolutionSeconds as FTX's resolution, which only takes a few values; of
+// those, only hourly candlesticks are declared.
+func (f FTX) BuildCandlestickIteratorAtResolution(ctx context.Context, baseAsset, quoteAsset string, initialISO8601 common.ISO8601, resolutionSeconds int) *common.CandlestickIterator {
+	return common.NewCandlestickIterator(f.newCandlestickIterator(ctx, baseAsset, quoteAsset, initialISO8601, resolutionSeconds).next)
 }
 
-func (f FTX) BuildTradeIterator(baseAsset, quoteAsset string, initialISO8601 common.ISO8601) *common.TradeIterator {
-	return common.NewTradeIterator(f.newTradeIterator(baseAsset, quoteAsset, initialISO8601).next)
+func (f FTX) BuildTradeIterator(ctx context.Context, baseAsset, quoteAsset string, initialISO8601 common.ISO8601) *common.TradeIterator {
+	return common.NewTradeIterator(f.newTradeIterator(ctx, baseAsset, quoteAsset, initialISO8601).next)
 }
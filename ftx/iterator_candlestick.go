@@ -1,24 +1,30 @@
 package ftx
 
 import (
+	"context"
+
 	"github.com/marianogappa/signal-checker/common"
 )
 
 type ftxCandlestickIterator struct {
+	ctx                   context.Context
 	ftx                   FTX
 	baseAsset, quoteAsset string
 	candlesticks          []common.Candlestick
 	requestFromSecs       int
+	resolutionSeconds     int
 }
 
-func (f FTX) newCandlestickIterator(baseAsset, quoteAsset string, initialISO8601 common.ISO8601) *ftxCandlestickIterator {
+func (f FTX) newCandlestickIterator(ctx context.Context, baseAsset, quoteAsset string, initialISO8601 common.ISO8601, resolutionSeconds int) *ftxCandlestickIterator {
 	// N.B. already validated
 	initial, _ := initialISO8601.Time()
 	return &ftxCandlestickIterator{
-		ftx:             f,
-		baseAsset:       baseAsset,
-		quoteAsset:      quoteAsset,
-		requestFromSecs: int(initial.Unix()),
+		ctx:               ctx,
+		ftx:               f,
+		baseAsset:         baseAsset,
+		quoteAsset:        quoteAsset,
+		requestFromSecs:   int(initial.Unix()),
+		resolutionSeconds: resolutionSeconds,
 	}
 }
 
@@ -28,7 +34,7 @@ func (it *ftxCandlestickIterator) next() (common.Candlestick, error) {
 		it.candlesticks = it.candlesticks[1:]
 		return c, nil
 	}
-	klinesResult, err := it.ftx.getKlines(it.baseAsset, it.quoteAsset, it.requestFromSecs)
+	klinesResult, err := it.ftx.getKlines(it.ctx, it.baseAsset, it.quoteAsset, it.requestFromSecs, it.resolutionSeconds)
 	if err != nil {
 		return common.Candlestick{}, err
 	}
@@ -43,7 +49,7 @@ func (it *ftxCandlestickIterator) next() (common.Candlestick, error) {
 		it.candlesticks = it.candlesticks[1:]
 	}
 	if len(it.candlesticks) > 0 {
-		it.requestFromSecs = it.candlesticks[len(it.candlesticks)-1].Timestamp + 60
+		it.requestFromSecs = it.candlesticks[len(it.candlesticks)-1].Timestamp + it.resolutionSeconds
 	}
 	return it.next()
 }
@@ -1,29 +1,76 @@
 package ftx
 
 import (
-	"errors"
+	"context"
+	"time"
 
 	"github.com/marianogappa/signal-checker/common"
 )
 
+const (
+	tradesWindowSecs = 60
+	tradesLimit      = 5000
+)
+
 type ftxTradeIterator struct {
+	ctx                   context.Context
 	ftx                   FTX
 	baseAsset, quoteAsset string
-	// trades                []common.Trade
-	requestFromMillis int
+	trades                []common.Trade
+	initialSeconds        int
+	requestFromSecs       int
+	windowSecs            int
 }
 
-func (f FTX) newTradeIterator(baseAsset, quoteAsset string, initialISO8601 common.ISO8601) *ftxTradeIterator {
+func (f FTX) newTradeIterator(ctx context.Context, baseAsset, quoteAsset string, initialISO8601 common.ISO8601) *ftxTradeIterator {
 	// N.B. already validated
 	initial, _ := initialISO8601.Time()
 	return &ftxTradeIterator{
-		ftx:               f,
-		baseAsset:         baseAsset,
-		quoteAsset:        quoteAsset,
-		requestFromMillis: int(initial.Unix()) * 1000,
+		ctx:             ctx,
+		ftx:             f,
+		baseAsset:       baseAsset,
+		quoteAsset:      quoteAsset,
+		initialSeconds:  int(initial.Unix()),
+		requestFromSecs: int(initial.Unix()),
+		windowSecs:      tradesWindowSecs,
 	}
 }
 
+// N.B. FTX returns the latest trades of the requested time window, so trades are requested in small windows, which
+// are halved if they are full (as earlier trades would be missing), and doubled if they are empty (to skip quiet
+// periods quickly).
 func (it *ftxTradeIterator) next() (common.Trade, error) {
-	return common.Trade{}, errors.New("ftx's trade iterator is not implemented yet")
+	if len(it.trades) > 0 {
+		c := it.trades[0]
+		it.trades = it.trades[1:]
+		return c, nil
+	}
+	for it.requestFromSecs <= int(time.Now().Unix()) {
+		tradesResult, err := it.ftx.getTrades(it.ctx, it.baseAsset, it.quoteAsset, it.requestFromSecs, it.requestFromSecs+it.windowSecs, tradesLimit)
+		if err != nil {
+			return common.Trade{}, err
+		}
+		if len(tradesResult.trades) >= tradesLimit && it.windowSecs > 1 {
+			it.windowSecs /= 2
+			continue
+		}
+		requestedFromSecs := it.requestFromSecs
+		it.requestFromSecs += it.windowSecs
+		if len(tradesResult.trades) == 0 {
+			it.windowSecs *= 2
+			continue
+		}
+		it.windowSecs = tradesWindowSecs
+
+		// Prune trades outside the window (they will be requested with the next one), and before the initial time.
+		for _, trade := range tradesResult.trades {
+			if trade.Timestamp >= requestedFromSecs && trade.Timestamp >= it.initialSeconds && trade.Timestamp < it.requestFromSecs {
+				it.trades = append(it.trades, trade)
+			}
+		}
+		if len(it.trades) > 0 {
+			return it.next()
+		}
+	}
+	return common.Trade{}, common.ErrOutOfTrades
 }
@@ -0,0 +1,161 @@
+// The httpclient package is the HTTP layer shared by all exchange clients.
+//
+// It limits each exchange's request rate with a token bucket that matches its documented limits (e.g. Binance's
+// request weights), and retries rate-limited (429, and Binance's 418) and 5xx responses with jittered exponential
+// backoff, honouring their Retry-After headers. When retries run out on a rate limit, the error wraps
+// common.ErrRateLimit, which checks surface as a 429 httpStatus.
+package httpclient
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/marianogappa/signal-checker/common"
+)
+
+const (
+	defaultTimeout     = 10 * time.Second
+	defaultMaxRetries  = 3
+	defaultBaseBackoff = 500 * time.Millisecond
+	defaultMaxBackoff  = 30 * time.Second
+)
+
+// Client sends an exchange's requests. It's safe for concurrent use, and all checks on the exchange should share it,
+// so that they share its rate limit.
+type Client struct {
+	name       string
+	httpClient *http.Client
+	bucket     *tokenBucket
+	baseURL    string
+	userAgent  string
+	// debug is 1 if set. It's accessed atomically, as concurrent checks set it on the shared client.
+	debug int32
+
+	// MaxRetries is the number of times a rate-limited or 5xx request is retried.
+	MaxRetries int
+
+	// BaseBackoff is the backoff before the first retry, which doubles on each following retry, up to MaxBackoff.
+	BaseBackoff time.Duration
+
+	// MaxBackoff is the longest backoff between retries, unless the response's Retry-After header asks for longer.
+	MaxBackoff time.Duration
+}
+
+// NewClient is the constructor for Client. name is used on errors and logs, e.g. "binance". Exchange constructors
+// pass their options through to it.
+func NewClient(name string, limit Limit, opts ...Option) *Client {
+	options := options{timeout: defaultTimeout}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if options.hasLimit {
+		limit = options.limit
+	}
+	return &Client{
+		name:        name,
+		httpClient:  options.buildHTTPClient(),
+		bucket:      newTokenBucket(limit),
+		baseURL:     options.baseURL,
+		userAgent:   options.userAgent,
+		MaxRetries:  defaultMaxRetries,
+		BaseBackoff: defaultBaseBackoff,
+		MaxBackoff:  defaultMaxBackoff,
+	}
+}
+
+// BaseURL is the base URL set with WithBaseURL, or defaultBaseURL if there's none.
+func (c *Client) BaseURL(defaultBaseURL string) string {
+	if c.baseURL == "" {
+		return defaultBaseURL
+	}
+	return c.baseURL
+}
+
+func (c *Client) SetDebug(debug bool) {
+	var value int32
+	if debug {
+		value = 1
+	}
+	atomic.StoreInt32(&c.debug, value)
+}
+
+// Debug is the last value set with SetDebug. It only matters when the request's context has no check logger.
+func (c *Client) Debug() bool {
+	return atomic.LoadInt32(&c.debug) == 1
+}
+
+// Do sends the request once there are weight tokens on the bucket, retrying rate-limited and 5xx responses. The
+// request must not have a body, so that it can be retried. Responses are logged to the logger of the request's check.
+//
+// When retries run out, rate-limited responses return an error wrapping common.ErrRateLimit, and 5xx responses are
+// returned as usual, for the caller to handle them.
+func (c *Client) Do(req *http.Request, weight float64) (*http.Response, error) {
+	ctx := req.Context()
+	logger := common.LoggerFrom(ctx, c.Debug())
+	if c.userAgent != "" {
+		req.Header.Set("User-Agent", c.userAgent)
+	}
+	for attempt := 0; ; attempt++ {
+		if err := c.bucket.wait(ctx, weight); err != nil {
+			return nil, err
+		}
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		logger.Printf("HTTPClient: %v %v returned %v status code\n", c.name, req.URL, resp.StatusCode)
+		if !isRateLimited(resp.StatusCode) && resp.StatusCode < 500 {
+			return resp, nil
+		}
+		if attempt >= c.MaxRetries {
+			if !isRateLimited(resp.StatusCode) {
+				return resp, nil
+			}
+			resp.Body.Close()
+			return nil, fmt.Errorf("%w: %v returned %v status code after %v retries", common.ErrRateLimit, c.name, resp.StatusCode, attempt)
+		}
+		backoff := c.backoff(attempt, resp.Header.Get("Retry-After"))
+		logger.Printf("HTTPClient: %v returned %v status code, retrying in %v (retry %v of %v)\n", c.name, resp.StatusCode, backoff, attempt+1, c.MaxRetries)
+		// Drain the body, so that the connection can be reused.
+		io.Copy(ioutil.Discard, resp.Body)
+		resp.Body.Close()
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(backoff):
+		}
+	}
+}
+
+// isRateLimited is true for 429, and for Binance's 418, which it returns when rate limits keep being ignored.
+func isRateLimited(statusCode int) bool {
+	return statusCode == http.StatusTooManyRequests || statusCode == http.StatusTeapot
+}
+
+// backoff is the Retry-After header's, if there's a valid one. Otherwise, it's a random duration between half and the
+// whole of the exponential backoff for the attempt, so that concurrent checks don't retry at the same time.
+func (c *Client) backoff(attempt int, retryAfter string) time.Duration {
+	if seconds, err := strconv.Atoi(retryAfter); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(retryAfter); err == nil {
+		if backoff := time.Until(at); backoff > 0 {
+			return backoff
+		}
+		return 0
+	}
+	backoff := c.MaxBackoff
+	if attempt < 32 && c.BaseBackoff<<uint(attempt) < c.MaxBackoff {
+		backoff = c.BaseBackoff << uint(attempt)
+	}
+	if backoff < 2 {
+		return backoff
+	}
+	return backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)))
+}
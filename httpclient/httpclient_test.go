@@ -0,0 +1,231 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/marianogappa/signal-checker/common"
+)
+
+func TestDo(t *testing.T) {
+	type test struct {
+		name               string
+		statusCodes        []int
+		maxRetries         int
+		expectedStatusCode int
+		expectedErr        error
+		expectedRequests   int
+	}
+	tss := []test{
+		{
+			name:               "Success",
+			statusCodes:        []int{200},
+			maxRetries:         3,
+			expectedStatusCode: 200,
+			expectedRequests:   1,
+		},
+		{
+			name:               "Client errors are not retried",
+			statusCodes:        []int{400},
+			maxRetries:         3,
+			expectedStatusCode: 400,
+			expectedRequests:   1,
+		},
+		{
+			name:               "Retries rate limits and server errors",
+			statusCodes:        []int{429, 418, 503, 200},
+			maxRetries:         3,
+			expectedStatusCode: 200,
+			expectedRequests:   4,
+		},
+		{
+			name:             "Rate limited after all retries",
+			statusCodes:      []int{429, 429, 429},
+			maxRetries:       2,
+			expectedErr:      common.ErrRateLimit,
+			expectedRequests: 3,
+		},
+		{
+			name:               "Server error after all retries",
+			statusCodes:        []int{500, 502, 500},
+			maxRetries:         2,
+			expectedStatusCode: 500,
+			expectedRequests:   3,
+		},
+	}
+	for _, ts := range tss {
+		t.Run(ts.name, func(t *testing.T) {
+			requests := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Retry-After", "0")
+				w.WriteHeader(ts.statusCodes[requests])
+				requests++
+			}))
+			defer server.Close()
+
+			c := NewClient("test", Limit{})
+			c.MaxRetries = ts.maxRetries
+			req, _ := http.NewRequest("GET", server.URL, nil)
+			resp, err := c.Do(req, 1)
+			if !errors.Is(err, ts.expectedErr) {
+				t.Fatalf("expected error %v but got %v", ts.expectedErr, err)
+			}
+			if err == nil {
+				resp.Body.Close()
+				if resp.StatusCode != ts.expectedStatusCode {
+					t.Fatalf("expected status code %v but got %v", ts.expectedStatusCode, resp.StatusCode)
+				}
+			}
+			if requests != ts.expectedRequests {
+				t.Fatalf("expected %v requests but there were %v", ts.expectedRequests, requests)
+			}
+		})
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	c := NewClient("test", Limit{})
+	c.BaseBackoff = 100 * time.Millisecond
+	c.MaxBackoff = 300 * time.Millisecond
+
+	if backoff := c.backoff(0, "7"); backoff != 7*time.Second {
+		t.Fatalf("expected Retry-After's 7s but got %v", backoff)
+	}
+	if backoff := c.backoff(0, time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat)); backoff != 0 {
+		t.Fatalf("expected no backoff for a Retry-After date in the past, but got %v", backoff)
+	}
+	for attempt, expectedMax := range []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 300 * time.Millisecond, 300 * time.Millisecond} {
+		backoff := c.backoff(attempt, "")
+		if backoff < expectedMax/2 || backoff > expectedMax {
+			t.Fatalf("expected backoff for attempt %v to be between %v and %v, but was %v", attempt, expectedMax/2, expectedMax, backoff)
+		}
+	}
+}
+
+func TestTokenBucket(t *testing.T) {
+	now := time.Unix(0, 0)
+	b := newTokenBucket(Limit{PerSecond: 10, Burst: 5})
+	b.last = now
+	b.now = func() time.Time { return now }
+
+	// The burst is available right away.
+	if err := b.wait(context.Background(), 5); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// After half a second, 5 tokens were refilled.
+	now = now.Add(500 * time.Millisecond)
+	if err := b.wait(context.Background(), 2); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if b.tokens != 3 {
+		t.Fatalf("expected 3 tokens but there were %v", b.tokens)
+	}
+	// Without time passing, there aren't enough tokens, so the wait lasts until the context is done.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := b.wait(ctx, 4); err != context.Canceled {
+		t.Fatalf("expected context.Canceled but got %v", err)
+	}
+}
+
+// roundTripperFunc is an http.RoundTripper that calls itself.
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestOptions(t *testing.T) {
+	userAgents := []string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgents = append(userAgents, r.UserAgent())
+	}))
+	defer server.Close()
+
+	roundTrips := 0
+	transport := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		roundTrips++
+		return http.DefaultTransport.RoundTrip(req)
+	})
+	httpClient := &http.Client{Timeout: time.Minute}
+	c := NewClient("test", Limit{},
+		WithHTTPClient(httpClient),
+		WithTransport(transport),
+		WithTimeout(5*time.Second),
+		WithBaseURL(server.URL),
+		WithUserAgent("signal-checker-test"),
+	)
+
+	baseURL := c.BaseURL("https://default/")
+	if baseURL != server.URL+"/" {
+		t.Fatalf("expected base URL %v but got %v", server.URL+"/", baseURL)
+	}
+	req, _ := http.NewRequest("GET", baseURL, nil)
+	resp, err := c.Do(req, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp.Body.Close()
+	if roundTrips != 1 {
+		t.Fatalf("expected the request to go through the transport, but there were %v round trips", roundTrips)
+	}
+	if len(userAgents) != 1 || userAgents[0] != "signal-checker-test" {
+		t.Fatalf("expected the user agent to be set, but got %v", userAgents)
+	}
+	if c.httpClient.Timeout != 5*time.Second || httpClient.Timeout != time.Minute || httpClient.Transport != nil {
+		t.Fatalf("expected a copy of the HTTP client with a 5s timeout, without changing the given client")
+	}
+	if NewClient("test", Limit{}).BaseURL("https://default/") != "https://default/" {
+		t.Fatalf("expected the default base URL without WithBaseURL")
+	}
+}
+
+func TestDoLogsToTheCheckLogger(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	logger := common.NewLogger(false, true)
+	req, _ := http.NewRequestWithContext(common.WithLogger(context.Background(), logger), "GET", server.URL+"/klines?page=2", nil)
+	resp, err := NewClient("test", Limit{}).Do(req, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp.Body.Close()
+	expectedLogs := []string{fmt.Sprintf("HTTPClient: test %v/klines?page=2 returned 200 status code", server.URL)}
+	if !reflect.DeepEqual(logger.Logs(), expectedLogs) {
+		t.Fatalf("expected logs %v but got %v", expectedLogs, logger.Logs())
+	}
+}
+
+// N.B. run with -race, as concurrent checks set debug on the shared client while others send requests.
+func TestSetDebugWhileSending(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	client := NewClient("test", Limit{})
+	done := make(chan struct{})
+	for i := 0; i < 2; i++ {
+		go func(debug bool) {
+			defer func() { done <- struct{}{} }()
+			for j := 0; j < 10; j++ {
+				client.SetDebug(debug)
+				req, _ := http.NewRequest("GET", server.URL, nil)
+				if resp, err := client.Do(req, 1); err == nil {
+					resp.Body.Close()
+				}
+			}
+		}(i == 0)
+	}
+	<-done
+	<-done
+	client.SetDebug(true)
+	if !client.Debug() {
+		t.Fatalf("expected debug to be the last value set")
+	}
+}
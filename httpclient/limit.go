@@ -0,0 +1,81 @@
+package httpclient
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Limit is an exchange's request rate limit, as a token bucket that holds up to Burst tokens, and is refilled at
+// PerSecond tokens per second. Each request takes its weight in tokens. A zero PerSecond means no limit.
+type Limit struct {
+	PerSecond float64
+	Burst     float64
+}
+
+// The documented limits of public market data endpoints of each exchange. Request weights are set by the callers.
+var (
+	// Binance: 1200 weight per minute. Klines (limit 1000) weigh 2, aggTrades weigh 1.
+	BinanceLimit = Limit{PerSecond: 20, Burst: 200}
+
+	// Binance USD-M futures: 2400 weight per minute. Klines (limit 1000) weigh 5, aggTrades weigh 20, fundingRate
+	// weighs 1.
+	BinanceUSDMFuturesLimit = Limit{PerSecond: 40, Burst: 400}
+
+	// Coinbase: 10 requests per second, with bursts of up to 15.
+	CoinbaseLimit = Limit{PerSecond: 10, Burst: 15}
+
+	// FTX: 30 requests per second.
+	FTXLimit = Limit{PerSecond: 30, Burst: 30}
+
+	// Kraken: about 1 public request per second, with a small burst.
+	KrakenLimit = Limit{PerSecond: 1, Burst: 5}
+
+	// KuCoin: 30 public requests every 3 seconds.
+	KucoinLimit = Limit{PerSecond: 10, Burst: 30}
+)
+
+type tokenBucket struct {
+	mutex  sync.Mutex
+	limit  Limit
+	tokens float64
+	last   time.Time
+	now    func() time.Time
+}
+
+func newTokenBucket(limit Limit) *tokenBucket {
+	return &tokenBucket{limit: limit, tokens: limit.Burst, last: time.Now(), now: time.Now}
+}
+
+// wait takes weight tokens from the bucket, waiting until there are enough of them, or until the context is done.
+// Weights above the bucket's burst wait for a full bucket.
+func (b *tokenBucket) wait(ctx context.Context, weight float64) error {
+	if b.limit.PerSecond <= 0 {
+		return ctx.Err()
+	}
+	if weight > b.limit.Burst {
+		weight = b.limit.Burst
+	}
+	for {
+		b.mutex.Lock()
+		now := b.now()
+		b.tokens += now.Sub(b.last).Seconds() * b.limit.PerSecond
+		if b.tokens > b.limit.Burst {
+			b.tokens = b.limit.Burst
+		}
+		b.last = now
+		if b.tokens >= weight {
+			b.tokens -= weight
+			b.mutex.Unlock()
+			return nil
+		}
+		wait := time.Duration((weight - b.tokens) / b.limit.PerSecond * float64(time.Second))
+		b.mutex.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package httpclient
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Option configures the HTTP client of an exchange, e.g. to route its requests through a proxy, or to point it at a
+// mock server. All exchanges that fetch over HTTP take options on their constructor, e.g.
+// binance.NewBinance(httpclient.WithTimeout(30 * time.Second)).
+type Option func(*options)
+
+type options struct {
+	httpClient *http.Client
+	transport  http.RoundTripper
+	timeout    time.Duration
+	hasTimeout bool
+	baseURL    string
+	userAgent  string
+	limit      Limit
+	hasLimit   bool
+}
+
+// WithHTTPClient sends requests with the given client, e.g. to reuse its connections. Its timeout is kept, unless
+// WithTimeout is also used.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(o *options) {
+		o.httpClient = httpClient
+	}
+}
+
+// WithTransport sends requests through the given RoundTripper, e.g. a transport with an egress proxy. Note that, by
+// default, requests already go through the proxy in the HTTP_PROXY and HTTPS_PROXY environment variables.
+func WithTransport(transport http.RoundTripper) Option {
+	return func(o *options) {
+		o.transport = transport
+	}
+}
+
+// WithTimeout sets the timeout of each request (10 seconds by default).
+func WithTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.timeout = timeout
+		o.hasTimeout = true
+	}
+}
+
+// WithBaseURL replaces the exchange's API URL, e.g. "http://localhost:8080/api/v3/" to point at a mock server.
+func WithBaseURL(baseURL string) Option {
+	return func(o *options) {
+		if !strings.HasSuffix(baseURL, "/") {
+			baseURL += "/"
+		}
+		o.baseURL = baseURL
+	}
+}
+
+// WithUserAgent sets the User-Agent header of the requests.
+func WithUserAgent(userAgent string) Option {
+	return func(o *options) {
+		o.userAgent = userAgent
+	}
+}
+
+// WithLimit replaces the exchange's rate limit, e.g. Limit{} to send requests without a limit to a mock server.
+func WithLimit(limit Limit) Option {
+	return func(o *options) {
+		o.limit = limit
+		o.hasLimit = true
+	}
+}
+
+// buildHTTPClient returns a copy of the given client (or a new one), with the given transport and timeout.
+func (o options) buildHTTPClient() *http.Client {
+	httpClient := &http.Client{Timeout: o.timeout}
+	if o.httpClient != nil {
+		copied := *o.httpClient
+		httpClient = &copied
+		if o.hasTimeout {
+			httpClient.Timeout = o.timeout
+		}
+	}
+	if o.transport != nil {
+		httpClient.Transport = o.transport
+	}
+	return httpClient
+}
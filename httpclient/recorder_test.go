@@ -0,0 +1,47 @@
+package httpclient
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Test", r.URL.Query().Get("page"))
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(w, "page %v", r.URL.Query().Get("page"))
+	}))
+	dir := t.TempDir()
+	recorder := NewClient("test", Limit{}, WithTransport(NewRecorder(dir, nil)))
+	for _, page := range []string{"1", "2"} {
+		req, _ := http.NewRequest("GET", server.URL+"?page="+page, nil)
+		resp, err := recorder.Do(req, 1)
+		if err != nil {
+			t.Fatalf("unexpected error recording: %v", err)
+		}
+		resp.Body.Close()
+	}
+	server.Close()
+
+	replayer := NewClient("test", Limit{}, WithTransport(NewReplayer(dir)))
+	for _, page := range []string{"1", "2"} {
+		req, _ := http.NewRequest("GET", server.URL+"?page="+page, nil)
+		resp, err := replayer.Do(req, 1)
+		if err != nil {
+			t.Fatalf("unexpected error replaying: %v", err)
+		}
+		body, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest || resp.Header.Get("X-Test") != page || string(body) != "page "+page {
+			t.Fatalf("unexpected replayed response: %v %v %v", resp.StatusCode, resp.Header, string(body))
+		}
+	}
+	req, _ := http.NewRequest("GET", server.URL+"?page=3", nil)
+	if _, err := replayer.Do(req, 1); !errors.Is(err, ErrFixtureNotFound) {
+		t.Fatalf("expected ErrFixtureNotFound but got %v", err)
+	}
+}
@@ -0,0 +1,87 @@
+package jobs
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/marianogappa/signal-checker/common"
+)
+
+// MAX_REQUEST_BYTES is the largest job submission the handler accepts.
+const MAX_REQUEST_BYTES = 10 * 1024 * 1024
+
+// ServeHTTP serves the job endpoints, mounted on "/jobs" and "/jobs/":
+//
+// POST /jobs submits a job, with either one JSON input or an array of them, and returns its ID.
+//
+// GET /jobs/{id} returns the job's status, progress and outputs.
+//
+// DELETE /jobs/{id} cancels the job.
+func (m *Manager) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	id := strings.Trim(strings.TrimPrefix(r.URL.Path, "/jobs"), "/")
+	switch {
+	case id == "" && r.Method == http.MethodPost:
+		m.serveSubmit(w, r)
+	case id != "" && r.Method == http.MethodGet:
+		job, err := m.Get(id)
+		writeJob(w, http.StatusOK, job, err)
+	case id != "" && r.Method == http.MethodDelete:
+		job, err := m.Cancel(id)
+		writeJob(w, http.StatusOK, job, err)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (m *Manager) serveSubmit(w http.ResponseWriter, r *http.Request) {
+	byts, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, MAX_REQUEST_BYTES))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	inputs := []common.SignalCheckInput{}
+	if trimmed := bytes.TrimSpace(byts); len(trimmed) > 0 && trimmed[0] == '[' {
+		err = json.Unmarshal(trimmed, &inputs)
+	} else {
+		input := common.SignalCheckInput{}
+		err = json.Unmarshal(trimmed, &input)
+		inputs = append(inputs, input)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	id, err := m.Submit(inputs)
+	if err != nil {
+		writeJob(w, http.StatusAccepted, Job{}, err)
+		return
+	}
+	job, err := m.Get(id)
+	w.Header().Set("Location", "/jobs/"+id)
+	writeJob(w, http.StatusAccepted, job, err)
+}
+
+// writeJob writes the job as JSON with the given status code, or the error with its status code.
+func writeJob(w http.ResponseWriter, statusCode int, job Job, err error) {
+	switch {
+	case errors.Is(err, ErrJobNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	case errors.Is(err, ErrNoChecks):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case errors.Is(err, ErrQueueFull):
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(job)
+}
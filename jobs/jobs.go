@@ -0,0 +1,227 @@
+// The jobs package runs checks asynchronously, for checks that take longer than an HTTP request should (e.g. long
+// signals that also calculate maxEnterUSD).
+//
+// Jobs are one or many checks, which run on a bounded pool of workers shared by all jobs. While they run, jobs report
+// the progress of each check (candlesticks processed and the current candlestick's time), and can be cancelled.
+// Finished jobs are kept in memory for a while (JOB_TTL), for clients to fetch their results.
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/marianogappa/signal-checker/common"
+	"github.com/marianogappa/signal-checker/signalchecker"
+)
+
+const (
+	QUEUED    = "queued"
+	RUNNING   = "running"
+	DONE      = "done"
+	CANCELLED = "cancelled"
+
+	// JOB_TTL is how long finished jobs are kept after they finish.
+	JOB_TTL = time.Hour
+
+	// MAX_QUEUED_CHECKS is the number of checks that can wait for a worker. Submitting more fails with ErrQueueFull.
+	MAX_QUEUED_CHECKS = 10000
+)
+
+var (
+	ErrQueueFull   = errors.New("too many checks queued; try again later")
+	ErrNoChecks    = errors.New("a job must have at least one check")
+	ErrJobNotFound = errors.New("job not found")
+)
+
+// Job is the state of a submitted job.
+type Job struct {
+	ID string `json:"id"`
+
+	// Status is one of "queued", "running", "done" or "cancelled".
+	Status string `json:"status"`
+
+	// Total is the number of checks of the job, and Done the number of finished ones.
+	Total int `json:"total"`
+	Done  int `json:"done"`
+
+	// Progress is the progress of each check, in the same order as the inputs.
+	Progress []signalchecker.Progress `json:"progress"`
+
+	// Outputs are the outputs of each check, in the same order as the inputs, or null for unfinished checks.
+	// Cancelled checks have an error output.
+	Outputs []*common.SignalCheckOutput `json:"outputs"`
+
+	CreatedAt  time.Time  `json:"createdAt"`
+	FinishedAt *time.Time `json:"finishedAt,omitempty"`
+}
+
+type job struct {
+	Job
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+type task struct {
+	job   *job
+	index int
+	input common.SignalCheckInput
+}
+
+// Manager runs jobs' checks on a fixed number of workers. It's safe for concurrent use.
+type Manager struct {
+	mutex sync.Mutex
+	jobs  map[string]*job
+	tasks chan task
+	opts  []signalchecker.Option
+	now   func() time.Time
+}
+
+// NewManager is the constructor for Manager. It starts the given number of workers, which run checks with the given
+// options.
+func NewManager(workers int, opts []signalchecker.Option) *Manager {
+	if workers < 1 {
+		workers = 1
+	}
+	m := &Manager{
+		jobs:  map[string]*job{},
+		tasks: make(chan task, MAX_QUEUED_CHECKS),
+		opts:  opts,
+		now:   time.Now,
+	}
+	for i := 0; i < workers; i++ {
+		go m.work()
+	}
+	return m
+}
+
+// Submit queues a job with a check for each input, and returns its ID.
+func (m *Manager) Submit(inputs []common.SignalCheckInput) (string, error) {
+	if len(inputs) == 0 {
+		return "", ErrNoChecks
+	}
+	id, err := newID()
+	if err != nil {
+		return "", err
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.removeExpired()
+	// N.B. only Submit sends tasks, under the mutex, so there's room for all of them.
+	if cap(m.tasks)-len(m.tasks) < len(inputs) {
+		return "", ErrQueueFull
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	j := &job{
+		Job: Job{
+			ID:        id,
+			Status:    QUEUED,
+			Total:     len(inputs),
+			Progress:  make([]signalchecker.Progress, len(inputs)),
+			Outputs:   make([]*common.SignalCheckOutput, len(inputs)),
+			CreatedAt: m.now(),
+		},
+		ctx:    ctx,
+		cancel: cancel,
+	}
+	m.jobs[id] = j
+	for i, input := range inputs {
+		m.tasks <- task{job: j, index: i, input: input}
+	}
+	return id, nil
+}
+
+// Get returns a snapshot of the job's state.
+func (m *Manager) Get(id string) (Job, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	j, ok := m.jobs[id]
+	if !ok {
+		return Job{}, ErrJobNotFound
+	}
+	return j.snapshot(), nil
+}
+
+// Cancel stops the job's running checks, and skips the queued ones. Cancelling a finished job does nothing.
+func (m *Manager) Cancel(id string) (Job, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	j, ok := m.jobs[id]
+	if !ok {
+		return Job{}, ErrJobNotFound
+	}
+	if j.Status == QUEUED || j.Status == RUNNING {
+		j.Status = CANCELLED
+		j.cancel()
+	}
+	return j.snapshot(), nil
+}
+
+func (m *Manager) work() {
+	for t := range m.tasks {
+		m.run(t)
+	}
+}
+
+func (m *Manager) run(t task) {
+	m.mutex.Lock()
+	if t.job.Status == QUEUED {
+		t.job.Status = RUNNING
+	}
+	m.mutex.Unlock()
+
+	var output common.SignalCheckOutput
+	if err := t.job.ctx.Err(); err != nil {
+		output = common.SignalCheckOutput{Input: t.input, IsError: true, HttpStatus: 500, ErrorMessage: err.Error()}
+	} else {
+		onProgress := func(progress signalchecker.Progress) {
+			m.mutex.Lock()
+			defer m.mutex.Unlock()
+			t.job.Progress[t.index] = progress
+		}
+		opts := append(append([]signalchecker.Option{}, m.opts...), signalchecker.WithProgress(onProgress))
+		output, _ = signalchecker.NewSignalChecker(t.input, opts...).CheckContext(t.job.ctx)
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	t.job.Outputs[t.index] = &output
+	t.job.Done++
+	if t.job.Done == t.job.Total {
+		finishedAt := m.now()
+		t.job.FinishedAt = &finishedAt
+		if t.job.Status == RUNNING {
+			t.job.Status = DONE
+		}
+		t.job.cancel()
+	}
+}
+
+// removeExpired removes the jobs that finished more than JOB_TTL ago. It must be called with the mutex held.
+func (m *Manager) removeExpired() {
+	for id, j := range m.jobs {
+		if j.FinishedAt != nil && m.now().Sub(*j.FinishedAt) > JOB_TTL {
+			delete(m.jobs, id)
+		}
+	}
+}
+
+// snapshot copies the job's state, so that it can be read without the mutex. It must be called with the mutex held.
+func (j *job) snapshot() Job {
+	snapshot := j.Job
+	snapshot.Progress = append([]signalchecker.Progress{}, j.Progress...)
+	snapshot.Outputs = append([]*common.SignalCheckOutput{}, j.Outputs...)
+	return snapshot
+}
+
+func newID() (string, error) {
+	byts := make([]byte, 8)
+	if _, err := rand.Read(byts); err != nil {
+		return "", fmt.Errorf("error generating job ID: %w", err)
+	}
+	return hex.EncodeToString(byts), nil
+}
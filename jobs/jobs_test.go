@@ -0,0 +1,182 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/marianogappa/signal-checker/common"
+	"github.com/marianogappa/signal-checker/fake"
+	"github.com/marianogappa/signal-checker/signalchecker"
+)
+
+// blockingExchange serves one candlestick, and then blocks until the check's context is done.
+type blockingExchange struct {
+	*fake.Fake
+}
+
+func (e blockingExchange) BuildCandlestickIterator(ctx context.Context, baseAsset, quoteAsset string, initialISO8601 common.ISO8601) *common.CandlestickIterator {
+	first := true
+	return common.NewCandlestickIterator(func() (common.Candlestick, error) {
+		if first {
+			first = false
+			tsSec, _ := initialISO8601.Seconds()
+			return common.Candlestick{Timestamp: tsSec, LowestPrice: 3, HighestPrice: 3}, nil
+		}
+		<-ctx.Done()
+		return common.Candlestick{}, ctx.Err()
+	})
+}
+
+func init() {
+	ts := common.ISO8601("2021-07-04T14:14:00Z")
+	tsSec, _ := ts.Seconds()
+	signalchecker.RegisterExchange("jobs_test", fake.NewFake([]common.Candlestick{
+		{Timestamp: tsSec, LowestPrice: 1.5, HighestPrice: 1.5},
+		{Timestamp: tsSec + 60, LowestPrice: 3, HighestPrice: 3},
+	}, nil, nil, nil))
+	signalchecker.RegisterExchange("jobs_test_blocking", blockingExchange{fake.NewFake(nil, nil, nil, nil)})
+}
+
+func testInput(exchange string) string {
+	return `{"exchange": "` + exchange + `", "baseAsset": "BTC", "quoteAsset": "USDT", "entries": [2, 1], "takeProfits": [3], "stopLoss": 0.5, "initialISO8601": "2021-07-04T14:14:00Z", "dontCalculateMaxEnterUSD": true}`
+}
+
+func request(t *testing.T, method, url, body string) (int, Job) {
+	req, _ := http.NewRequest(method, url, strings.NewReader(body))
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer resp.Body.Close()
+	job := Job{}
+	if resp.Header.Get("Content-Type") == "application/json" {
+		if err := json.NewDecoder(resp.Body).Decode(&job); err != nil {
+			t.Fatalf("unexpected error decoding job: %v", err)
+		}
+	}
+	return resp.StatusCode, job
+}
+
+// waitFor polls the job until cond is true, or fails after a second.
+func waitFor(t *testing.T, url string, cond func(Job) bool) Job {
+	deadline := time.Now().Add(time.Second)
+	for {
+		_, job := request(t, "GET", url, "")
+		if cond(job) {
+			return job
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for job: %+v", job)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestJobs(t *testing.T) {
+	server := httptest.NewServer(NewManager(2, nil))
+	defer server.Close()
+
+	statusCode, job := request(t, "POST", server.URL+"/jobs", "["+testInput("jobs_test")+","+testInput("jobs_test")+"]")
+	if statusCode != http.StatusAccepted || job.ID == "" || job.Total != 2 {
+		t.Fatalf("unexpected response to submitting a job: %v %+v", statusCode, job)
+	}
+	job = waitFor(t, server.URL+"/jobs/"+job.ID, func(job Job) bool { return job.Status == DONE })
+	if job.Done != 2 || job.FinishedAt == nil {
+		t.Fatalf("expected a finished job, but got %+v", job)
+	}
+	for i, output := range job.Outputs {
+		if output == nil || output.IsError || output.HighestTakeProfit != 1 {
+			t.Fatalf("expected check %v to take profit, but got %+v", i, output)
+		}
+		if job.Progress[i].Candlesticks != 2 || job.Progress[i].CurrentISO8601 != "2021-07-04T14:15:00Z" {
+			t.Fatalf("expected check %v to have processed 2 candlesticks, but its progress is %+v", i, job.Progress[i])
+		}
+	}
+}
+
+func TestSubmitOneCheck(t *testing.T) {
+	server := httptest.NewServer(NewManager(1, nil))
+	defer server.Close()
+
+	statusCode, job := request(t, "POST", server.URL+"/jobs", testInput("jobs_test"))
+	if statusCode != http.StatusAccepted || job.Total != 1 {
+		t.Fatalf("unexpected response to submitting a job: %v %+v", statusCode, job)
+	}
+	waitFor(t, server.URL+"/jobs/"+job.ID, func(job Job) bool { return job.Status == DONE })
+}
+
+func TestCancelJob(t *testing.T) {
+	server := httptest.NewServer(NewManager(1, nil))
+	defer server.Close()
+
+	// The second check is queued behind the first one, which blocks.
+	_, job := request(t, "POST", server.URL+"/jobs", "["+testInput("jobs_test_blocking")+","+testInput("jobs_test")+"]")
+	url := server.URL + "/jobs/" + job.ID
+	waitFor(t, url, func(job Job) bool { return job.Status == RUNNING && job.Progress[0].Candlesticks == 1 })
+
+	statusCode, job := request(t, "DELETE", url, "")
+	if statusCode != http.StatusOK || job.Status != CANCELLED {
+		t.Fatalf("unexpected response to cancelling a job: %v %+v", statusCode, job)
+	}
+	job = waitFor(t, url, func(job Job) bool { return job.Done == job.Total })
+	if job.Status != CANCELLED {
+		t.Fatalf("expected a cancelled job, but got %+v", job)
+	}
+	for i, output := range job.Outputs {
+		if !output.IsError || output.ErrorMessage != context.Canceled.Error() {
+			t.Fatalf("expected check %v to be cancelled, but got %+v", i, output)
+		}
+	}
+}
+
+func TestJobErrors(t *testing.T) {
+	server := httptest.NewServer(NewManager(1, nil))
+	defer server.Close()
+
+	type test struct {
+		name               string
+		method             string
+		path               string
+		body               string
+		expectedStatusCode int
+	}
+	tss := []test{
+		{name: "Unknown job", method: "GET", path: "/jobs/unknown", expectedStatusCode: http.StatusNotFound},
+		{name: "Cancel unknown job", method: "DELETE", path: "/jobs/unknown", expectedStatusCode: http.StatusNotFound},
+		{name: "No checks", method: "POST", path: "/jobs", body: "[]", expectedStatusCode: http.StatusBadRequest},
+		{name: "Invalid JSON", method: "POST", path: "/jobs", body: "{", expectedStatusCode: http.StatusBadRequest},
+		{name: "Method not allowed", method: "GET", path: "/jobs", expectedStatusCode: http.StatusMethodNotAllowed},
+	}
+	for _, ts := range tss {
+		t.Run(ts.name, func(t *testing.T) {
+			statusCode, _ := request(t, ts.method, server.URL+ts.path, ts.body)
+			if statusCode != ts.expectedStatusCode {
+				t.Fatalf("expected status code %v but got %v", ts.expectedStatusCode, statusCode)
+			}
+		})
+	}
+}
+
+func TestRemovesExpiredJobs(t *testing.T) {
+	m := NewManager(1, nil)
+	now := time.Now()
+	m.now = func() time.Time { return now }
+	finishedAt := now.Add(-JOB_TTL - time.Second)
+	m.jobs["expired"] = &job{Job: Job{ID: "expired", Status: DONE, FinishedAt: &finishedAt}}
+	m.jobs["running"] = &job{Job: Job{ID: "running", Status: RUNNING}}
+
+	if _, err := m.Submit([]common.SignalCheckInput{{Exchange: "jobs_test"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := m.Get("expired"); err != ErrJobNotFound {
+		t.Fatalf("expected the expired job to be removed, but got %v", err)
+	}
+	if _, err := m.Get("running"); err != nil {
+		t.Fatalf("expected the running job to be kept, but got %v", err)
+	}
+}
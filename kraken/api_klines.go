@@ -1,15 +1,14 @@
 package kraken
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io/ioutil"
-	"log"
 	"net/http"
 	"strconv"
 	"strings"
-	"time"
 
 	"github.com/marianogappa/signal-checker/common"
 )
@@ -173,8 +172,8 @@ type klinesResult struct {
 	nextSince          int
 }
 
-func (k Kraken) getKlines(baseAsset string, quoteAsset string, startTimeSecs int) (klinesResult, error) {
-	req, _ := http.NewRequest("GET", fmt.Sprintf("%vpublic/OHLC", k.apiURL), nil)
+func (k Kraken) getKlines(ctx context.Context, baseAsset string, quoteAsset string, startTimeSecs int) (klinesResult, error) {
+	req, _ := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%vpublic/OHLC", k.apiURL), nil)
 	pair := fmt.Sprintf("%v%v", strings.ToUpper(baseAsset), strings.ToUpper(quoteAsset))
 
 	q := req.URL.Query()
@@ -184,9 +183,7 @@ func (k Kraken) getKlines(baseAsset string, quoteAsset string, startTimeSecs int
 
 	req.URL.RawQuery = q.Encode()
 
-	client := &http.Client{Timeout: 10 * time.Second}
-
-	resp, err := client.Do(req)
+	resp, err := k.client.Do(req, 1)
 	if err != nil {
 		return klinesResult{err: err}, err
 	}
@@ -211,6 +208,10 @@ func (k Kraken) getKlines(baseAsset string, quoteAsset string, startTimeSecs int
 		return klinesResult{err: err, httpStatus: 500}, err
 	}
 
+	if isRateLimitError(maybeResponse.Error) {
+		err := fmt.Errorf("%w: kraken returned errors: %v", common.ErrRateLimit, maybeResponse.Error)
+		return klinesResult{httpStatus: 429, krakenErrorMessage: fmt.Sprintf("%v", maybeResponse.Error), err: err}, err
+	}
 	if len(maybeResponse.Error) > 0 {
 		return klinesResult{
 			httpStatus:         500,
@@ -237,7 +238,7 @@ func (k Kraken) getKlines(baseAsset string, quoteAsset string, startTimeSecs int
 		}, wrappedErr
 	}
 
-	log.Printf("Kraken candlestick request successful! Candlestick count: %v\n", len(candlesticks))
+	common.LoggerFrom(ctx, k.client.Debug()).Printf("Kraken candlestick request successful! Candlestick count: %v\n", len(candlesticks))
 
 	return klinesResult{
 		candlesticks: candlesticks,
@@ -0,0 +1,186 @@
+package kraken
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/marianogappa/signal-checker/common"
+)
+
+// {
+//   "error": [],
+//   "result": {
+//     "XXBTZUSD": [
+//       ["34221.60000","0.00260000",1625623260.1234,"b","l","",9214]
+//     ],
+//     "last": "1625623260123456789"
+//   }
+// }
+type tradesResponse struct {
+	Error  []string                   `json:"error"`
+	Result map[string]json.RawMessage `json:"result"`
+}
+
+func (r tradesResponse) findDataKey() (string, error) {
+	// N.B. BTC is aliased to XBT on Kraken, so don't try to find "${baseAsset}${quoteAsset}" here.
+	for key := range r.Result {
+		if key != "last" {
+			return key, nil
+		}
+	}
+	return "", errors.New("no data key found")
+}
+
+func (r tradesResponse) getNextSince() (string, error) {
+	var nextSince interface{}
+	if err := json.Unmarshal(r.Result["last"], &nextSince); err != nil {
+		return "", fmt.Errorf("'next since' was not valid: [%v]! Invalid syntax from Kraken", string(r.Result["last"]))
+	}
+	switch v := nextSince.(type) {
+	case string:
+		return v, nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', 0, 64), nil
+	}
+	return "", fmt.Errorf("'next since' was not valid: [%v]! Invalid syntax from Kraken", string(r.Result["last"]))
+}
+
+func (r tradesResponse) toTrades() ([]common.Trade, error) {
+	dataKey, err := r.findDataKey()
+	if err != nil {
+		return []common.Trade{}, err
+	}
+	rawTrades := [][]interface{}{}
+	if err := json.Unmarshal(r.Result[dataKey], &rawTrades); err != nil {
+		return []common.Trade{}, fmt.Errorf("data key [%v] did not contain an array of trades", dataKey)
+	}
+
+	trades := make([]common.Trade, len(rawTrades))
+	for i, raw := range rawTrades {
+		if len(raw) < 3 {
+			return trades, fmt.Errorf("trade %v has less than 3 fields! Invalid syntax from Kraken", i)
+		}
+		rawPrice, ok := raw[0].(string)
+		if !ok {
+			return trades, fmt.Errorf("trade %v has non-string price! Invalid syntax from Kraken", i)
+		}
+		price, err := strconv.ParseFloat(rawPrice, 64)
+		if err != nil {
+			return trades, fmt.Errorf("trade %v had price = %v! Invalid syntax from Kraken", i, rawPrice)
+		}
+		rawVolume, ok := raw[1].(string)
+		if !ok {
+			return trades, fmt.Errorf("trade %v has non-string volume! Invalid syntax from Kraken", i)
+		}
+		volume, err := strconv.ParseFloat(rawVolume, 64)
+		if err != nil {
+			return trades, fmt.Errorf("trade %v had volume = %v! Invalid syntax from Kraken", i, rawVolume)
+		}
+		rawTime, ok := raw[2].(float64)
+		if !ok {
+			return trades, fmt.Errorf("trade %v has non-numeric time! Invalid syntax from Kraken", i)
+		}
+		trades[i] = common.Trade{
+			BaseAssetPrice:    common.JsonFloat64(price),
+			BaseAssetQuantity: common.JsonFloat64(volume),
+			Timestamp:         int(rawTime),
+		}
+	}
+	return trades, nil
+}
+
+type tradesResult struct {
+	trades             []common.Trade
+	err                error
+	krakenErrorMessage string
+	httpStatus         int
+	nextSince          string
+}
+
+// getTrades requests the trades since the given (nanosecond precision) cursor. Use "nextSince" from the result to get
+// the following trades.
+func (k Kraken) getTrades(ctx context.Context, baseAsset string, quoteAsset string, since string) (tradesResult, error) {
+	req, _ := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%vpublic/Trades", k.apiURL), nil)
+	pair := fmt.Sprintf("%v%v", strings.ToUpper(baseAsset), strings.ToUpper(quoteAsset))
+
+	q := req.URL.Query()
+	q.Add("pair", pair)
+	q.Add("since", since)
+
+	req.URL.RawQuery = q.Encode()
+
+	resp, err := k.client.Do(req, 1)
+	if err != nil {
+		return tradesResult{err: err}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		byts, _ := ioutil.ReadAll(resp.Body)
+		err := fmt.Errorf("kraken returned %v status code with payload [%v]", resp.StatusCode, string(byts))
+		return tradesResult{httpStatus: 500, err: err}, err
+	}
+
+	byts, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		err := fmt.Errorf("kraken returned broken body response! Was: %v", string(byts))
+		return tradesResult{err: err, httpStatus: 500}, err
+	}
+
+	maybeResponse := tradesResponse{}
+	err = json.Unmarshal(byts, &maybeResponse)
+	if err != nil {
+		err := fmt.Errorf("kraken returned invalid JSON response! Was: %v", string(byts))
+		return tradesResult{err: err, httpStatus: 500}, err
+	}
+
+	if isRateLimitError(maybeResponse.Error) {
+		err := fmt.Errorf("%w: kraken returned errors: %v", common.ErrRateLimit, maybeResponse.Error)
+		return tradesResult{httpStatus: 429, krakenErrorMessage: fmt.Sprintf("%v", maybeResponse.Error), err: err}, err
+	}
+	if len(maybeResponse.Error) > 0 {
+		err := fmt.Errorf("kraken returned errors: %v", maybeResponse.Error)
+		return tradesResult{
+			httpStatus:         500,
+			krakenErrorMessage: fmt.Sprintf("%v", maybeResponse.Error),
+			err:                err,
+		}, err
+	}
+
+	trades, err := maybeResponse.toTrades()
+	if err != nil {
+		wrappedErr := fmt.Errorf("error unmarshalling trades from successful response data from Kraken: %v", err)
+		return tradesResult{
+			httpStatus: 500,
+			err:        wrappedErr,
+		}, wrappedErr
+	}
+
+	nextSince, err := maybeResponse.getNextSince()
+	if err != nil {
+		wrappedErr := fmt.Errorf("error unmarshalling nextSince from successful response data from Kraken: %v", err)
+		return tradesResult{
+			httpStatus: 500,
+			err:        wrappedErr,
+		}, wrappedErr
+	}
+
+	if len(trades) == 0 {
+		return tradesResult{
+			httpStatus: 200,
+			err:        common.ErrOutOfTrades,
+		}, common.ErrOutOfTrades
+	}
+
+	return tradesResult{
+		trades:     trades,
+		nextSince:  nextSince,
+		httpStatus: 200,
+	}, nil
+}
@@ -0,0 +1,109 @@
+package kraken
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/marianogappa/signal-checker/common"
+)
+
+type expectedTrade struct {
+	trade common.Trade
+	err   error
+}
+
+func TestTrades(t *testing.T) {
+	i := 0
+	requestedSinces := []string{}
+	replies := []string{
+		`{"error":[],"result":{"XXBTZUSD":[
+			["34221.60000","0.00260000",1625408058.1234,"b","l","",9214],
+			["34221.70000","0.10000000",1625408059.5678,"s","m","",9215]
+		],"last":"1625408059567800000"}}`,
+		`{"error":[],"result":{"XXBTZUSD":[
+			["34222.10000","0.50000000",1625408060.0001,"b","m","",9216]
+		],"last":"1625408060000100000"}}`,
+		`{"error":[],"result":{"XXBTZUSD":[],"last":"1625408060000100000"}}`,
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestedSinces = append(requestedSinces, r.URL.Query().Get("since"))
+		fmt.Fprintln(w, replies[i%len(replies)])
+		i++
+	}))
+	defer ts.Close()
+
+	k := NewKraken()
+	k.overrideAPIURL(ts.URL + "/")
+	ti := k.BuildTradeIterator(context.Background(), "BTC", "USD", "2021-07-04T14:14:18+00:00")
+
+	expectedResults := []expectedTrade{
+		{trade: common.Trade{BaseAssetPrice: 34221.6, BaseAssetQuantity: 0.0026, Timestamp: 1625408058}, err: nil},
+		{trade: common.Trade{BaseAssetPrice: 34221.7, BaseAssetQuantity: 0.1, Timestamp: 1625408059}, err: nil},
+		{trade: common.Trade{BaseAssetPrice: 34222.1, BaseAssetQuantity: 0.5, Timestamp: 1625408060}, err: nil},
+		{trade: common.Trade{}, err: common.ErrOutOfTrades},
+	}
+	for i, expectedResult := range expectedResults {
+		actualTrade, actualErr := ti.Next()
+		if actualTrade != expectedResult.trade {
+			t.Errorf("on trade %v expected %v but got %v", i, expectedResult.trade, actualTrade)
+			t.FailNow()
+		}
+		if actualErr != expectedResult.err {
+			t.Errorf("on trade %v expected no errors but this error happened %v", i, actualErr)
+			t.FailNow()
+		}
+	}
+	expectedSinces := []string{"1625408058000000000", "1625408059567800000", "1625408060000100000"}
+	for i, expectedSince := range expectedSinces {
+		if requestedSinces[i] != expectedSince {
+			t.Errorf("on request %v expected since = %v but got %v", i, expectedSince, requestedSinces[i])
+		}
+	}
+}
+
+func TestTradesPrunesEarlierTrades(t *testing.T) {
+	replies := []string{
+		`{"error":[],"result":{"XXBTZUSD":[
+			["34221.60000","0.00260000",1625408000.1234,"b","l","",9214],
+			["34221.70000","0.10000000",1625408058.5678,"s","m","",9215]
+		],"last":"1625408058567800000"}}`,
+		`{"error":[],"result":{"XXBTZUSD":[],"last":"1625408058567800000"}}`,
+	}
+	i := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, replies[i%len(replies)])
+		i++
+	}))
+	defer ts.Close()
+
+	k := NewKraken()
+	k.overrideAPIURL(ts.URL + "/")
+	ti := k.BuildTradeIterator(context.Background(), "BTC", "USD", "2021-07-04T14:14:18+00:00")
+
+	trade, err := ti.Next()
+	if err != nil {
+		t.Fatalf("expected no error but got %v", err)
+	}
+	if trade.Timestamp != 1625408058 {
+		t.Fatalf("expected earlier trade to be pruned, but got %v", trade)
+	}
+}
+
+func TestTradesError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"error":["EQuery:Unknown asset pair"]}`)
+	}))
+	defer ts.Close()
+
+	k := NewKraken()
+	k.overrideAPIURL(ts.URL + "/")
+	ti := k.BuildTradeIterator(context.Background(), "DOGE", "SHIB", "2021-07-04T14:14:18+00:00")
+
+	_, err := ti.Next()
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+}
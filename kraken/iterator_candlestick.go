@@ -1,20 +1,24 @@
 package kraken
 
 import (
+	"context"
+
 	"github.com/marianogappa/signal-checker/common"
 )
 
 type krakenCandlestickIterator struct {
+	ctx                   context.Context
 	kraken                Kraken
 	baseAsset, quoteAsset string
 	candlesticks          []common.Candlestick
 	requestFromSecs       int
 }
 
-func (k Kraken) newCandlestickIterator(baseAsset, quoteAsset string, initialISO8601 common.ISO8601) *krakenCandlestickIterator {
+func (k Kraken) newCandlestickIterator(ctx context.Context, baseAsset, quoteAsset string, initialISO8601 common.ISO8601) *krakenCandlestickIterator {
 	// N.B. already validated
 	initial, _ := initialISO8601.Time()
 	return &krakenCandlestickIterator{
+		ctx:             ctx,
 		kraken:          k,
 		baseAsset:       baseAsset,
 		quoteAsset:      quoteAsset,
@@ -28,7 +32,7 @@ func (it *krakenCandlestickIterator) next() (common.Candlestick, error) {
 		it.candlesticks = it.candlesticks[1:]
 		return c, nil
 	}
-	klinesResult, err := it.kraken.getKlines(it.baseAsset, it.quoteAsset, it.requestFromSecs)
+	klinesResult, err := it.kraken.getKlines(it.ctx, it.baseAsset, it.quoteAsset, it.requestFromSecs)
 	if err != nil {
 		return common.Candlestick{}, err
 	}
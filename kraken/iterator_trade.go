@@ -1,29 +1,56 @@
 package kraken
 
 import (
-	"errors"
+	"context"
+	"fmt"
 
 	"github.com/marianogappa/signal-checker/common"
 )
 
 type krakenTradeIterator struct {
+	ctx                   context.Context
 	kraken                Kraken
 	baseAsset, quoteAsset string
-	// trades                []common.Trade
-	requestFromMillis int
+	trades                []common.Trade
+	requestSince          string
+	initialSeconds        int
 }
 
-func (k Kraken) newTradeIterator(baseAsset, quoteAsset string, initialISO8601 common.ISO8601) *krakenTradeIterator {
+func (k Kraken) newTradeIterator(ctx context.Context, baseAsset, quoteAsset string, initialISO8601 common.ISO8601) *krakenTradeIterator {
 	// N.B. already validated
 	initial, _ := initialISO8601.Time()
+	initialSeconds := int(initial.Unix())
 	return &krakenTradeIterator{
-		kraken:            k,
-		baseAsset:         baseAsset,
-		quoteAsset:        quoteAsset,
-		requestFromMillis: int(initial.Unix()) * 1000,
+		ctx:            ctx,
+		kraken:         k,
+		baseAsset:      baseAsset,
+		quoteAsset:     quoteAsset,
+		requestSince:   fmt.Sprintf("%v000000000", initialSeconds),
+		initialSeconds: initialSeconds,
 	}
 }
 
 func (it *krakenTradeIterator) next() (common.Trade, error) {
-	return common.Trade{}, errors.New("kraken's trade iterator is not implemented yet")
+	if len(it.trades) > 0 {
+		c := it.trades[0]
+		it.trades = it.trades[1:]
+		return c, nil
+	}
+	tradesResult, err := it.kraken.getTrades(it.ctx, it.baseAsset, it.quoteAsset, it.requestSince)
+	if err != nil {
+		return common.Trade{}, err
+	}
+	// If the cursor didn't move, there are no newer trades.
+	if tradesResult.nextSince == it.requestSince {
+		return common.Trade{}, common.ErrOutOfTrades
+	}
+	it.trades = tradesResult.trades
+	// Some exchanges return earlier trades to the requested time. Prune them.
+	// Note that this may remove all items, but this does not necessarily mean we are out of trades.
+	// In this case we just need to fetch again.
+	for len(it.trades) > 0 && it.trades[0].Timestamp < it.initialSeconds {
+		it.trades = it.trades[1:]
+	}
+	it.requestSince = tradesResult.nextSince
+	return it.next()
 }
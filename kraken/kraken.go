@@ -1,16 +1,22 @@
 package kraken
 
 import (
+	"context"
+	"strings"
+
 	"github.com/marianogappa/signal-checker/common"
+	"github.com/marianogappa/signal-checker/httpclient"
 )
 
 type Kraken struct {
 	apiURL string
-	debug  bool
+	client *httpclient.Client
 }
 
-func NewKraken() *Kraken {
-	return &Kraken{apiURL: "https://api.kraken.com/0/"}
+// NewKraken is the constructor for Kraken.
+func NewKraken(opts ...httpclient.Option) *Kraken {
+	client := httpclient.NewClient("kraken", httpclient.KrakenLimit, opts...)
+	return &Kraken{apiURL: client.BaseURL("https://api.kraken.com/0/"), client: client}
 }
 
 func (k *Kraken) overrideAPIURL(apiURL string) {
@@ -18,13 +24,28 @@ func (k *Kraken) overrideAPIURL(apiURL string) {
 }
 
 func (b *Kraken) SetDebug(debug bool) {
-	b.debug = debug
+	b.client.SetDebug(debug)
+}
+
+func (k Kraken) Capabilities() common.ExchangeCapabilities {
+	return common.ExchangeCapabilities{Trades: true, Fees: common.DefaultFees[common.KRAKEN]}
+}
+
+func (k Kraken) BuildCandlestickIterator(ctx context.Context, baseAsset, quoteAsset string, initialISO8601 common.ISO8601) *common.CandlestickIterator {
+	return common.NewCandlestickIterator(k.newCandlestickIterator(ctx, baseAsset, quoteAsset, initialISO8601).next)
 }
 
-func (k Kraken) BuildCandlestickIterator(baseAsset, quoteAsset string, initialISO8601 common.ISO8601) *common.CandlestickIterator {
-	return common.NewCandlestickIterator(k.newCandlestickIterator(baseAsset, quoteAsset, initialISO8601).next)
+func (k Kraken) BuildTradeIterator(ctx context.Context, baseAsset, quoteAsset string, initialISO8601 common.ISO8601) *common.TradeIterator {
+	return common.NewTradeIterator(k.newTradeIterator(ctx, baseAsset, quoteAsset, initialISO8601).next)
 }
 
-func (k Kraken) BuildTradeIterator(baseAsset, quoteAsset string, initialISO8601 common.ISO8601) *common.TradeIterator {
-	return common.NewTradeIterator(k.newTradeIterator(baseAsset, quoteAsset, initialISO8601).next)
+// isRateLimitError is true if Kraken's errors say that requests are being rate limited, which Kraken does with a
+// successful status code.
+func isRateLimitError(errs []string) bool {
+	for _, err := range errs {
+		if strings.HasPrefix(err, "EAPI:Rate limit exceeded") || strings.HasPrefix(err, "EGeneral:Too many requests") {
+			return true
+		}
+	}
+	return false
 }
@@ -1,13 +1,13 @@
 package kucoin
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"strconv"
 	"strings"
-	"time"
 
 	"github.com/marianogappa/signal-checker/common"
 )
@@ -91,6 +91,9 @@ func responseToCandlesticks(data [][]string) ([]common.Candlestick, error) {
 	return candlesticks, nil
 }
 
+// klinesTypes are the candlestick types for each supported resolution in seconds.
+var klinesTypes = map[int]string{60: "1min", 3600: "1hour"}
+
 type klinesResult struct {
 	candlesticks       []common.Candlestick
 	err                error
@@ -99,20 +102,18 @@ type klinesResult struct {
 	httpStatus         int
 }
 
-func (k Kucoin) getKlines(baseAsset string, quoteAsset string, startTimeSecs int) (klinesResult, error) {
-	req, _ := http.NewRequest("GET", fmt.Sprintf("%vmarket/candles", k.apiURL), nil)
+func (k Kucoin) getKlines(ctx context.Context, baseAsset string, quoteAsset string, startTimeSecs int, resolutionSeconds int) (klinesResult, error) {
+	req, _ := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%vmarket/candles", k.apiURL), nil)
 	symbol := fmt.Sprintf("%v-%v", strings.ToUpper(baseAsset), strings.ToUpper(quoteAsset))
 
 	q := req.URL.Query()
 	q.Add("symbol", symbol)
-	q.Add("type", "1min")
+	q.Add("type", klinesTypes[resolutionSeconds])
 	q.Add("startAt", fmt.Sprintf("%v", startTimeSecs))
 
 	req.URL.RawQuery = q.Encode()
 
-	client := &http.Client{Timeout: 10 * time.Second}
-
-	resp, err := client.Do(req)
+	resp, err := k.client.Do(req, 1)
 	if err != nil {
 		return klinesResult{err: err}, err
 	}
@@ -131,6 +132,10 @@ func (k Kucoin) getKlines(baseAsset string, quoteAsset string, startTimeSecs int
 
 	maybeResponse := response{}
 	err = json.Unmarshal(byts, &maybeResponse)
+	if err == nil && maybeResponse.Code == ERR_TOO_MANY_REQUESTS {
+		err := fmt.Errorf("%w: kucoin returned error code! Code: %v, Message: %v", common.ErrRateLimit, maybeResponse.Code, maybeResponse.Msg)
+		return klinesResult{kucoinErrorCode: maybeResponse.Code, kucoinErrorMessage: maybeResponse.Msg, httpStatus: 429, err: err}, err
+	}
 	if err == nil && (maybeResponse.Code != "200000" || maybeResponse.Msg != "") {
 		err := fmt.Errorf("kucoin returned error code! Code: %v, Message: %v", maybeResponse.Code, maybeResponse.Msg)
 		return klinesResult{
@@ -0,0 +1,131 @@
+package kucoin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/marianogappa/signal-checker/common"
+)
+
+// {
+//   "code": "200000",
+//   "data": [
+//     {
+//       "sequence": "1545896668571",
+//       "price": "0.07",
+//       "size": "0.004",
+//       "side": "buy",
+//       "time": 1545904567062140823
+//     }
+//   ]
+// }
+type kucoinTrade struct {
+	Sequence string `json:"sequence"`
+	Price    string `json:"price"`
+	Size     string `json:"size"`
+	Side     string `json:"side"`
+	Time     int64  `json:"time"`
+}
+
+func (t kucoinTrade) toTrade() (common.Trade, error) {
+	price, err := strconv.ParseFloat(t.Price, 64)
+	if err != nil {
+		return common.Trade{}, err
+	}
+	size, err := strconv.ParseFloat(t.Size, 64)
+	if err != nil {
+		return common.Trade{}, err
+	}
+	return common.Trade{
+		BaseAssetPrice:    common.JsonFloat64(price),
+		BaseAssetQuantity: common.JsonFloat64(size),
+		Timestamp:         int(t.Time / int64(time.Second)),
+	}, nil
+}
+
+type tradesResponse struct {
+	Code string        `json:"code"`
+	Msg  string        `json:"msg"`
+	Data []kucoinTrade `json:"data"`
+}
+
+type tradesResult struct {
+	trades             []common.Trade
+	err                error
+	kucoinErrorCode    string
+	kucoinErrorMessage string
+	httpStatus         int
+}
+
+// getTrades requests the latest trades, in ascending order. N.B. KuCoin only provides the latest 100 trades.
+func (k Kucoin) getTrades(ctx context.Context, baseAsset string, quoteAsset string) (tradesResult, error) {
+	req, _ := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%vmarket/histories", k.apiURL), nil)
+	symbol := fmt.Sprintf("%v-%v", strings.ToUpper(baseAsset), strings.ToUpper(quoteAsset))
+
+	q := req.URL.Query()
+	q.Add("symbol", symbol)
+
+	req.URL.RawQuery = q.Encode()
+
+	resp, err := k.client.Do(req, 1)
+	if err != nil {
+		return tradesResult{err: err}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		err := fmt.Errorf("kucoin returned %v status code", resp.StatusCode)
+		return tradesResult{httpStatus: resp.StatusCode, err: err}, err
+	}
+
+	byts, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		err := fmt.Errorf("kucoin returned broken body response! Was: %v", string(byts))
+		return tradesResult{err: err, httpStatus: resp.StatusCode}, err
+	}
+
+	maybeResponse := tradesResponse{}
+	err = json.Unmarshal(byts, &maybeResponse)
+	if err == nil && maybeResponse.Code == ERR_TOO_MANY_REQUESTS {
+		err := fmt.Errorf("%w: kucoin returned error code! Code: %v, Message: %v", common.ErrRateLimit, maybeResponse.Code, maybeResponse.Msg)
+		return tradesResult{kucoinErrorCode: maybeResponse.Code, kucoinErrorMessage: maybeResponse.Msg, httpStatus: 429, err: err}, err
+	}
+	if err == nil && (maybeResponse.Code != "200000" || maybeResponse.Msg != "") {
+		err := fmt.Errorf("kucoin returned error code! Code: %v, Message: %v", maybeResponse.Code, maybeResponse.Msg)
+		return tradesResult{
+			kucoinErrorCode:    maybeResponse.Code,
+			kucoinErrorMessage: maybeResponse.Msg,
+			httpStatus:         500,
+			err:                err,
+		}, err
+	}
+	if err != nil {
+		err := fmt.Errorf("kucoin returned invalid JSON response! Was: %v", string(byts))
+		return tradesResult{err: err, httpStatus: 500}, err
+	}
+
+	sort.SliceStable(maybeResponse.Data, func(i, j int) bool {
+		return maybeResponse.Data[i].Time < maybeResponse.Data[j].Time
+	})
+	trades := make([]common.Trade, len(maybeResponse.Data))
+	for i, kucoinTrade := range maybeResponse.Data {
+		trade, err := kucoinTrade.toTrade()
+		if err != nil {
+			err := fmt.Errorf("trade %v is invalid! Err was %v. Invalid syntax from Kucoin", i, err)
+			return tradesResult{httpStatus: 500, err: err}, err
+		}
+		trades[i] = trade
+	}
+
+	return tradesResult{
+		trades:     trades,
+		httpStatus: 200,
+	}, nil
+}
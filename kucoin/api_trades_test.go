@@ -0,0 +1,88 @@
+package kucoin
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/marianogappa/signal-checker/common"
+)
+
+type expectedTrade struct {
+	trade common.Trade
+	err   error
+}
+
+func TestTrades(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"code":"200000","data":[
+			{"sequence":"1545896668573","price":"34002.1","size":"0.3","side":"sell","time":1625408059000000001},
+			{"sequence":"1545896668572","price":"34001.1","size":"0.2","side":"buy","time":1625408058000000001},
+			{"sequence":"1545896668571","price":"34000.1","size":"0.1","side":"buy","time":1625408057000000001}
+		]}`)
+	}))
+	defer ts.Close()
+
+	k := NewKucoin()
+	k.overrideAPIURL(ts.URL + "/")
+	ti := k.BuildTradeIterator(context.Background(), "BTC", "USDT", "2021-07-04T14:14:18+00:00")
+
+	expectedResults := []expectedTrade{
+		{trade: common.Trade{BaseAssetPrice: 34001.1, BaseAssetQuantity: 0.2, Timestamp: 1625408058}, err: nil},
+		{trade: common.Trade{BaseAssetPrice: 34002.1, BaseAssetQuantity: 0.3, Timestamp: 1625408059}, err: nil},
+		{trade: common.Trade{}, err: common.ErrOutOfTrades},
+	}
+	for i, expectedResult := range expectedResults {
+		actualTrade, actualErr := ti.Next()
+		if actualTrade != expectedResult.trade {
+			t.Errorf("on trade %v expected %v but got %v", i, expectedResult.trade, actualTrade)
+			t.FailNow()
+		}
+		if actualErr != expectedResult.err {
+			t.Errorf("on trade %v expected no errors but this error happened %v", i, actualErr)
+			t.FailNow()
+		}
+	}
+}
+
+func TestTradesDontReachBackToInitial(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"code":"200000","data":[
+			{"sequence":"1545896668573","price":"34002.1","size":"0.3","side":"sell","time":1625408059000000001}
+		]}`)
+	}))
+	defer ts.Close()
+
+	k := NewKucoin()
+	k.overrideAPIURL(ts.URL + "/")
+	ti := k.BuildTradeIterator(context.Background(), "BTC", "USDT", "2021-07-04T14:14:18+00:00")
+
+	_, err := ti.Next()
+	if err == nil || err == common.ErrOutOfTrades {
+		t.Fatalf("expected an error because trades don't reach back to the initial time, but got %v", err)
+	}
+}
+
+func TestTradesError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"code":"400100","msg":"This pair is not provided at present"}`)
+	}))
+	defer ts.Close()
+
+	k := NewKucoin()
+	k.overrideAPIURL(ts.URL + "/")
+	ti := k.BuildTradeIterator(context.Background(), "DOGE", "SHIB", "2021-07-04T14:14:18+00:00")
+
+	_, err := ti.Next()
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+}
+
+func TestDoesNotDeclareTrades(t *testing.T) {
+	if common.CapabilitiesOf(NewKucoin()).Trades {
+		t.Fatalf("expected KuCoin not to declare trades, as it only provides its latest ones")
+	}
+}
@@ -1,26 +1,30 @@
 package kucoin
 
 import (
-	"fmt"
+	"context"
 
 	"github.com/marianogappa/signal-checker/common"
 )
 
 type kucoinCandlestickIterator struct {
+	ctx                   context.Context
 	kucoin                Kucoin
 	baseAsset, quoteAsset string
 	candlesticks          []common.Candlestick
 	requestFromSecs       int
+	resolutionSeconds     int
 }
 
-func (k Kucoin) newCandlestickIterator(baseAsset, quoteAsset string, initialISO8601 common.ISO8601) *kucoinCandlestickIterator {
+func (k Kucoin) newCandlestickIterator(ctx context.Context, baseAsset, quoteAsset string, initialISO8601 common.ISO8601, resolutionSeconds int) *kucoinCandlestickIterator {
 	// N.B. already validated
 	initial, _ := initialISO8601.Time()
 	return &kucoinCandlestickIterator{
-		kucoin:          k,
-		baseAsset:       baseAsset,
-		quoteAsset:      quoteAsset,
-		requestFromSecs: int(initial.Unix()),
+		ctx:               ctx,
+		kucoin:            k,
+		baseAsset:         baseAsset,
+		quoteAsset:        quoteAsset,
+		requestFromSecs:   int(initial.Unix()),
+		resolutionSeconds: resolutionSeconds,
 	}
 }
 
@@ -31,7 +35,7 @@ func (it *kucoinCandlestickIterator) next() (common.Candlestick, error) {
 		it.candlesticks = it.candlesticks[:len(it.candlesticks)-1]
 		return c, nil
 	}
-	klinesResult, err := it.kucoin.getKlines(it.baseAsset, it.quoteAsset, it.requestFromSecs)
+	klinesResult, err := it.kucoin.getKlines(it.ctx, it.baseAsset, it.quoteAsset, it.requestFromSecs, it.resolutionSeconds)
 	if err != nil {
 		return common.Candlestick{}, err
 	}
@@ -43,11 +47,10 @@ func (it *kucoinCandlestickIterator) next() (common.Candlestick, error) {
 	// Note that this may remove all items, but this does not necessarily mean we are out of candlesticks.
 	// In this case we just need to fetch again.
 	for len(it.candlesticks) > 0 && it.candlesticks[len(it.candlesticks)-1].Timestamp < it.requestFromSecs {
-		fmt.Println(it.candlesticks[len(it.candlesticks)-1].Timestamp, it.requestFromSecs)
 		it.candlesticks = it.candlesticks[:len(it.candlesticks)-1]
 	}
 	if len(it.candlesticks) > 0 {
-		it.requestFromSecs = it.candlesticks[0].Timestamp + 60
+		it.requestFromSecs = it.candlesticks[0].Timestamp + it.resolutionSeconds
 	}
 
 	return it.next()
@@ -1,29 +1,58 @@
 package kucoin
 
 import (
-	"errors"
+	"context"
+	"fmt"
 
 	"github.com/marianogappa/signal-checker/common"
 )
 
 type kucoinTradeIterator struct {
+	ctx                   context.Context
 	kucoin                Kucoin
 	baseAsset, quoteAsset string
-	// trades                []common.Trade
-	requestFromMillis int
+	trades                []common.Trade
+	initialSeconds        int
+	requested             bool
 }
 
-func (k Kucoin) newTradeIterator(baseAsset, quoteAsset string, initialISO8601 common.ISO8601) *kucoinTradeIterator {
+func (k Kucoin) newTradeIterator(ctx context.Context, baseAsset, quoteAsset string, initialISO8601 common.ISO8601) *kucoinTradeIterator {
 	// N.B. already validated
 	initial, _ := initialISO8601.Time()
 	return &kucoinTradeIterator{
-		kucoin:            k,
-		baseAsset:         baseAsset,
-		quoteAsset:        quoteAsset,
-		requestFromMillis: int(initial.Unix()) * 1000,
+		ctx:            ctx,
+		kucoin:         k,
+		baseAsset:      baseAsset,
+		quoteAsset:     quoteAsset,
+		initialSeconds: int(initial.Unix()),
 	}
 }
 
+// N.B. KuCoin has no public historical trades endpoint: only the latest 100 trades are available. So this iterator
+// fails unless those trades reach back to the initial time.
 func (it *kucoinTradeIterator) next() (common.Trade, error) {
-	return common.Trade{}, errors.New("kucoin's trade iterator is not implemented yet")
+	if len(it.trades) > 0 {
+		c := it.trades[0]
+		it.trades = it.trades[1:]
+		return c, nil
+	}
+	if it.requested {
+		return common.Trade{}, common.ErrOutOfTrades
+	}
+	it.requested = true
+	tradesResult, err := it.kucoin.getTrades(it.ctx, it.baseAsset, it.quoteAsset)
+	if err != nil {
+		return common.Trade{}, err
+	}
+	it.trades = tradesResult.trades
+	if len(it.trades) == 0 {
+		return common.Trade{}, common.ErrOutOfTrades
+	}
+	if it.trades[0].Timestamp > it.initialSeconds {
+		return common.Trade{}, fmt.Errorf("kucoin only provides the latest trades, and they don't reach back to %v", it.initialSeconds)
+	}
+	for len(it.trades) > 0 && it.trades[0].Timestamp < it.initialSeconds {
+		it.trades = it.trades[1:]
+	}
+	return it.next()
 }
@@ -1,16 +1,21 @@
 package kucoin
 
 import (
+	"context"
+
 	"github.com/marianogappa/signal-checker/common"
+	"github.com/marianogappa/signal-checker/httpclient"
 )
 
 type Kucoin struct {
 	apiURL string
-	debug  bool
+	client *httpclient.Client
 }
 
-func NewKucoin() *Kucoin {
-	return &Kucoin{apiURL: "https://api.kucoin.com/api/v1/"}
+// NewKucoin is the constructor for Kucoin.
+func NewKucoin(opts ...httpclient.Option) *Kucoin {
+	client := httpclient.NewClient("kucoin", httpclient.KucoinLimit, opts...)
+	return &Kucoin{apiURL: client.BaseURL("https://api.kucoin.com/api/v1/"), client: client}
 }
 
 func (k *Kucoin) overrideAPIURL(apiURL string) {
@@ -18,13 +23,27 @@ func (k *Kucoin) overrideAPIURL(apiURL string) {
 }
 
 func (b *Kucoin) SetDebug(debug bool) {
-	b.debug = debug
+	b.client.SetDebug(debug)
+}
+
+// Capabilities don't include trades, as KuCoin only provides its latest ones, so checks don't ask for them.
+func (k Kucoin) Capabilities() common.ExchangeCapabilities {
+	return common.ExchangeCapabilities{Trades: false, Resolutions: []int{3600}, Fees: common.DefaultFees[common.KUCOIN]}
 }
 
-func (k Kucoin) BuildCandlestickIterator(baseAsset, quoteAsset string, initialISO8601 common.ISO8601) *common.CandlestickIterator {
-	return common.NewCandlestickIterator(k.newCandlestickIterator(baseAsset, quoteAsset, initialISO8601).next)
+func (k Kucoin) BuildCandlestickIterator(ctx context.Context, baseAsset, quoteAsset string, initialISO8601 common.ISO8601) *common.CandlestickIterator {
+	return common.NewCandlestickIterator(k.newCandlestickIterator(ctx, baseAsset, quoteAsset, initialISO8601, 60).next)
 }
 
-func (k Kucoin) BuildTradeIterator(baseAsset, quoteAsset string, initialISO8601 common.ISO8601) *common.TradeIterator {
-	return common.NewTradeIterator(k.newTradeIterator(baseAsset, quoteAsset, initialISO8601).next)
+// BuildCandlestickIteratorAtResolution only supports hourly candlesticks, the only ones mapped to a kline type.
+func (k Kucoin) BuildCandlestickIteratorAtResolution(ctx context.Context, baseAsset, quoteAsset string, initialISO8601 common.ISO8601, resolutionSeconds int) *common.CandlestickIterator {
+	return common.NewCandlestickIterator(k.newCandlestickIterator(ctx, baseAsset, quoteAsset, initialISO8601, resolutionSeconds).next)
 }
+
+// BuildTradeIterator can't serve historical signals: KuCoin has no public historical trades endpoint, and only
+// provides its latest 100 trades, so it fails unless those reach back to initialISO8601.
+func (k Kucoin) BuildTradeIterator(ctx context.Context, baseAsset, quoteAsset string, initialISO8601 common.ISO8601) *common.TradeIterator {
+	return common.NewTradeIterator(k.newTradeIterator(ctx, baseAsset, quoteAsset, initialISO8601).next)
+}
+
+const ERR_TOO_MANY_REQUESTS = "429000"
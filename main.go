@@ -10,46 +10,184 @@ package main
 
 import (
 	"encoding/json"
+	"flag"
 	"fmt"
+	"io/ioutil"
 	"log"
 	"net/http"
 	"os"
 	"strconv"
 
 	"github.com/marianogappa/signal-checker/common"
+	"github.com/marianogappa/signal-checker/jobs"
+	"github.com/marianogappa/signal-checker/portfolio"
+	"github.com/marianogappa/signal-checker/report"
 	"github.com/marianogappa/signal-checker/signalchecker"
+	"github.com/marianogappa/signal-checker/signalparser"
 )
 
+var workers = flag.Int("workers", 4, "number of signals checked concurrently on batch mode, and on the server's jobs")
+
+var cacheDir = flag.String("cache-dir", "", "directory to cache candlesticks on, so that re-checking the same time ranges doesn't fetch them again")
+
+var recordDir = flag.String("record-dir", "", "directory to record every exchange HTTP response on, to replay checks later with -replay-dir")
+
+var replayDir = flag.String("replay-dir", "", "directory to replay exchange HTTP responses from (recorded with -record-dir), without network access")
+
+func checkerOptions() []signalchecker.Option {
+	opts := []signalchecker.Option{}
+	if *cacheDir != "" {
+		opts = append(opts, signalchecker.WithCandlestickCache(*cacheDir))
+	}
+	if *recordDir != "" && *replayDir != "" {
+		log.Fatal("-record-dir and -replay-dir can't be used together")
+	}
+	if *recordDir != "" {
+		opts = append(opts, signalchecker.WithHTTPRecording(*recordDir))
+	}
+	if *replayDir != "" {
+		opts = append(opts, signalchecker.WithHTTPReplay(*replayDir))
+	}
+	return opts
+}
+
 func serve(args []string) {
 	port := 8080
-	if len(args) >= 3 {
-		port, _ = strconv.Atoi(args[2])
+	if len(args) >= 2 {
+		port, _ = strconv.Atoi(args[1])
 	}
+	opts := checkerOptions()
 	mux := http.NewServeMux()
-	mux.HandleFunc("/check", serveCheck)
+	mux.HandleFunc("/check", serveCheck(opts))
+	mux.HandleFunc("/check/stream", serveCheckStream(opts, streamHeartbeatInterval))
+	jobManager := jobs.NewManager(*workers, opts)
+	mux.Handle("/jobs", jobManager)
+	mux.Handle("/jobs/", jobManager)
 
 	if err := http.ListenAndServe(fmt.Sprintf(":%v", port), mux); err != nil {
 		log.Fatal(err)
 	}
 }
 
-func serveCheck(w http.ResponseWriter, r *http.Request) {
-	var input common.SignalCheckInput
-	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		return
+// openArgFile opens the file in args[1], or stdin if there's none or it's "-".
+func openArgFile(args []string) *os.File {
+	if len(args) < 2 || args[1] == "-" {
+		return os.Stdin
+	}
+	file, err := os.Open(args[1])
+	if err != nil {
+		log.Fatal(err)
+	}
+	return file
+}
+
+// batch checks one JSON input per line from the file in args[1] (or stdin, if there's none or it's "-"), and prints
+// one JSON output per line, followed by a summary.
+func batch(args []string) {
+	r := openArgFile(args)
+	defer r.Close()
+	if _, err := checkBatch(r, os.Stdout, *workers, checkerOptions()); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// printReport prints the performance report of one JSON output per line from the file in args[1] (or stdin, if there's
+// none or it's "-"), e.g. the output of a batch.
+func printReport(args []string) {
+	r := openArgFile(args)
+	defer r.Close()
+	outputs, err := readOutputs(r)
+	if err != nil {
+		log.Fatal(err)
+	}
+	byts, err := json.MarshalIndent(report.Build(outputs), "", "  ")
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(string(byts))
+}
+
+// simulatePortfolio prints the result of following the signals on the JSON portfolio input in args[1] with a single
+// account.
+func simulatePortfolio(args []string) {
+	if len(args) < 2 {
+		log.Fatal("usage: signal-checker portfolio '{json portfolio input}'")
+	}
+	input := portfolio.Input{}
+	if err := json.Unmarshal([]byte(args[1]), &input); err != nil {
+		log.Fatal(err)
+	}
+	output, err := portfolio.Run(input, checkerOptions()...)
+	if err != nil {
+		log.Fatal(err)
+	}
+	byts, err := json.MarshalIndent(output, "", "  ")
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(string(byts))
+}
+
+// parse prints the JSON input understood from the signal message in args[1] (or stdin, if there's none or it's "-"),
+// along with what couldn't be understood.
+func parse(args []string) {
+	message := ""
+	if len(args) >= 2 && args[1] != "-" {
+		message = args[1]
+	} else {
+		byts, err := ioutil.ReadAll(os.Stdin)
+		if err != nil {
+			log.Fatal(err)
+		}
+		message = string(byts)
+	}
+	byts, err := json.MarshalIndent(signalparser.Parse(message), "", "  ")
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(string(byts))
+}
+
+// serveCheck returns the handler of checks. All checks share the options, e.g. so that they share recording exchanges.
+func serveCheck(opts []signalchecker.Option) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var input common.SignalCheckInput
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		output, _ := signalchecker.NewSignalChecker(input, opts...).CheckContext(r.Context())
+		w.WriteHeader(output.HttpStatus)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(output)
 	}
-	output, _ := signalchecker.NewSignalChecker(input).Check()
-	w.WriteHeader(output.HttpStatus)
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(output)
 }
 
 func main() {
 	log.SetFlags(log.LstdFlags | log.Lshortfile)
-	inputStr := os.Args[1]
+	flag.Parse()
+	if flag.NArg() < 1 {
+		log.Fatal("usage: signal-checker [-cache-dir dir] [-workers n] ('{json input}' | batch [file.jsonl] | report [file.jsonl] | portfolio '{json portfolio input}' | parse ['signal message'] | serve [port])")
+	}
+	inputStr := flag.Arg(0)
 	if inputStr == "serve" {
-		serve(os.Args)
+		serve(flag.Args())
+	}
+	if inputStr == "batch" {
+		batch(flag.Args())
+		return
+	}
+	if inputStr == "report" {
+		printReport(flag.Args())
+		return
+	}
+	if inputStr == "portfolio" {
+		simulatePortfolio(flag.Args())
+		return
+	}
+	if inputStr == "parse" {
+		parse(flag.Args())
+		return
 	}
 
 	input := common.SignalCheckInput{}
@@ -57,7 +195,7 @@ func main() {
 		log.Fatal(err)
 	}
 
-	output, _ := signalchecker.NewSignalChecker(input).Check()
+	output, _ := signalchecker.NewSignalChecker(input, checkerOptions()...).Check()
 	byts, err := json.MarshalIndent(output, "", "  ")
 	if err != nil {
 		log.Fatal(err)
@@ -0,0 +1,351 @@
+package offline
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/marianogappa/signal-checker/common"
+)
+
+const (
+	formatCSV       = "csv"
+	formatJSONLines = "jsonl"
+)
+
+type fileContent struct {
+	format string
+	byts   []byte
+}
+
+func formatOf(name string) string {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".csv":
+		return formatCSV
+	case ".jsonl", ".ndjson":
+		return formatJSONLines
+	}
+	return ""
+}
+
+// readFiles calls f with the content of every supported file in dir (and within the zip files in it), sorted by name.
+// Unsupported files are ignored.
+func readFiles(dir string, f func(name string, content fileContent) error) error {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
+		path := filepath.Join(dir, file.Name())
+		if strings.ToLower(filepath.Ext(file.Name())) == ".zip" {
+			if err := readZipFile(path, f); err != nil {
+				return err
+			}
+			continue
+		}
+		format := formatOf(file.Name())
+		if format == "" {
+			continue
+		}
+		byts, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if err := f(path, fileContent{format: format, byts: byts}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func readZipFile(path string, f func(name string, content fileContent) error) error {
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	for _, zipped := range r.File {
+		format := formatOf(zipped.Name)
+		if format == "" {
+			continue
+		}
+		rc, err := zipped.Open()
+		if err != nil {
+			return err
+		}
+		byts, err := ioutil.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return err
+		}
+		if err := f(fmt.Sprintf("%v/%v", path, zipped.Name), fileContent{format: format, byts: byts}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c fileContent) candlesticks() ([]common.Candlestick, error) {
+	if c.format == formatJSONLines {
+		candlesticks := []common.Candlestick{}
+		err := readJSONLines(c.byts, func(line []byte) error {
+			candlestick := common.Candlestick{}
+			if err := json.Unmarshal(line, &candlestick); err != nil {
+				return err
+			}
+			candlesticks = append(candlesticks, candlestick)
+			return nil
+		})
+		return candlesticks, err
+	}
+	rows, err := readCSV(c.byts, candlestickColumnAliases, binanceKlineColumns, []string{"t", "o", "c", "l", "h"})
+	if err != nil {
+		return nil, err
+	}
+	candlesticks := []common.Candlestick{}
+	for _, row := range rows {
+		candlestick := common.Candlestick{}
+		candlestick.Timestamp, err = row.timestamp("t")
+		if err == nil {
+			candlestick.OpenPrice, err = row.float("o")
+		}
+		if err == nil {
+			candlestick.ClosePrice, err = row.float("c")
+		}
+		if err == nil {
+			candlestick.LowestPrice, err = row.float("l")
+		}
+		if err == nil {
+			candlestick.HighestPrice, err = row.float("h")
+		}
+		if err == nil {
+			candlestick.Volume, err = row.float("v")
+		}
+		if err == nil {
+			candlestick.NumberOfTrades, err = row.int("n")
+		}
+		if err != nil {
+			return nil, err
+		}
+		candlesticks = append(candlesticks, candlestick)
+	}
+	return candlesticks, nil
+}
+
+func (c fileContent) trades() ([]common.Trade, error) {
+	if c.format == formatJSONLines {
+		trades := []common.Trade{}
+		err := readJSONLines(c.byts, func(line []byte) error {
+			trade := common.Trade{}
+			if err := json.Unmarshal(line, &trade); err != nil {
+				return err
+			}
+			trades = append(trades, trade)
+			return nil
+		})
+		return trades, err
+	}
+	rows, err := readCSV(c.byts, tradeColumnAliases, binanceTradeColumns, []string{"t", "p"})
+	if err != nil {
+		return nil, err
+	}
+	trades := []common.Trade{}
+	for _, row := range rows {
+		trade := common.Trade{}
+		trade.Timestamp, err = row.timestamp("t")
+		if err == nil {
+			trade.BaseAssetPrice, err = row.float("p")
+		}
+		if err == nil {
+			trade.BaseAssetQuantity, err = row.float("q")
+		}
+		if err != nil {
+			return nil, err
+		}
+		trades = append(trades, trade)
+	}
+	return trades, nil
+}
+
+func readJSONLines(byts []byte, f func(line []byte) error) error {
+	scanner := bufio.NewScanner(bytes.NewReader(byts))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		if err := f(line); err != nil {
+			return fmt.Errorf("line %v: %v", lineNumber, err)
+		}
+	}
+	return scanner.Err()
+}
+
+// columns maps column names to their index on a CSV row.
+type columns map[string]int
+
+// The header names accepted for each column, as in the JSON format of common.Candlestick, and as in Binance's data
+// dumps, which only have a header row on some files.
+var candlestickColumnAliases = map[string]string{
+	"t": "t", "open_time": "t",
+	"o": "o", "open": "o",
+	"c": "c", "close": "c",
+	"l": "l", "low": "l",
+	"h": "h", "high": "h",
+	"v": "v", "volume": "v",
+	"n": "n", "count": "n",
+}
+
+var tradeColumnAliases = map[string]string{
+	"t": "t", "time": "t",
+	"baseassetprice": "p", "price": "p",
+	"baseassetquantity": "q", "qty": "q",
+}
+
+// Binance's data dumps without a header row.
+var (
+	// open_time, open, high, low, close, volume, close_time, quote_volume, count, taker_buy_volume,
+	// taker_buy_quote_volume, ignore
+	binanceKlineColumns = columns{"t": 0, "o": 1, "h": 2, "l": 3, "c": 4, "v": 5, "n": 8}
+
+	// id, price, qty, quote_qty, time, is_buyer_maker, is_best_match
+	binanceTradeColumns = columns{"p": 1, "q": 2, "t": 4}
+)
+
+type csvRow struct {
+	fields  []string
+	columns columns
+	line    int
+}
+
+// readCSV reads the rows of a CSV file. If the first row is a header, its column names are mapped using aliases.
+// Otherwise, the columns are assumed to be as in headerlessColumns.
+func readCSV(byts []byte, aliases map[string]string, headerlessColumns columns, required []string) ([]csvRow, error) {
+	r := csv.NewReader(bytes.NewReader(byts))
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+	rows := []csvRow{}
+	var cols columns
+	line := 0
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		line++
+		if err != nil {
+			return nil, err
+		}
+		if cols == nil {
+			if _, err := strconv.ParseFloat(strings.TrimSpace(record[0]), 64); err != nil {
+				cols = columns{}
+				for i, name := range record {
+					if alias, ok := aliases[strings.ToLower(strings.TrimSpace(name))]; ok {
+						cols[alias] = i
+					}
+				}
+				for _, name := range required {
+					if _, ok := cols[name]; !ok {
+						return nil, fmt.Errorf("the header row has no column for %q", name)
+					}
+				}
+				continue
+			}
+			cols = headerlessColumns
+		}
+		rows = append(rows, csvRow{fields: record, columns: cols, line: line})
+	}
+	return rows, nil
+}
+
+// field returns the value of the column, or "" if the row doesn't have it.
+func (r csvRow) field(name string) string {
+	i, ok := r.columns[name]
+	if !ok || i >= len(r.fields) {
+		return ""
+	}
+	return strings.TrimSpace(r.fields[i])
+}
+
+func (r csvRow) float(name string) (common.JsonFloat64, error) {
+	field := r.field(name)
+	if field == "" {
+		return 0, nil
+	}
+	f, err := strconv.ParseFloat(field, 64)
+	if err != nil {
+		return 0, fmt.Errorf("line %v: invalid %q: %v", r.line, name, err)
+	}
+	return common.JsonFloat64(f), nil
+}
+
+func (r csvRow) int(name string) (int, error) {
+	field := r.field(name)
+	if field == "" {
+		return 0, nil
+	}
+	i, err := strconv.Atoi(field)
+	if err != nil {
+		return 0, fmt.Errorf("line %v: invalid %q: %v", r.line, name, err)
+	}
+	return i, nil
+}
+
+// timestamp returns the column as seconds, converting it from milliseconds or microseconds if necessary.
+func (r csvRow) timestamp(name string) (int, error) {
+	if r.field(name) == "" {
+		return 0, fmt.Errorf("line %v: missing %q", r.line, name)
+	}
+	t, err := r.int(name)
+	if err != nil {
+		return 0, err
+	}
+	return normalizeTimestamp(t), nil
+}
+
+func normalizeTimestamp(t int) int {
+	switch {
+	case t >= 1e14:
+		return t / 1e6
+	case t >= 1e11:
+		return t / 1e3
+	}
+	return t
+}
+
+// sortCandlesticks sorts candlesticks by timestamp, discarding repeated ones.
+func sortCandlesticks(candlesticks []common.Candlestick) []common.Candlestick {
+	sort.SliceStable(candlesticks, func(i, j int) bool {
+		return candlesticks[i].Timestamp < candlesticks[j].Timestamp
+	})
+	unique := []common.Candlestick{}
+	for _, candlestick := range candlesticks {
+		if len(unique) > 0 && unique[len(unique)-1].Timestamp == candlestick.Timestamp {
+			continue
+		}
+		unique = append(unique, candlestick)
+	}
+	return unique
+}
+
+func sortTrades(trades []common.Trade) []common.Trade {
+	sort.SliceStable(trades, func(i, j int) bool {
+		return trades[i].Timestamp < trades[j].Timestamp
+	})
+	return trades
+}
@@ -0,0 +1,188 @@
+// The offline package contains an exchange that reads candlesticks and trades from local files rather than requesting
+// them to an exchange, so that signals can be checked without network access.
+//
+// Files are read from the following directories under the data directory:
+//
+//   - "{BASE}-{QUOTE}/candlesticks": 1m candlesticks.
+//   - "{BASE}-{QUOTE}/trades": trades (optional; only used for the "exact" intraCandleResolution and for calculating the
+//     maximum amount that could have been invested).
+//
+// Supported files are:
+//
+//   - JSON Lines (".jsonl" or ".ndjson"), one common.Candlestick or common.Trade per line.
+//   - CSV (".csv"), with a header row naming the columns as in the JSON format of common.Candlestick (i.e. t, o, c, l,
+//     h, v, n) or common.Trade (i.e. BaseAssetPrice, BaseAssetQuantity, t).
+//   - Binance's public data dumps (https://data.binance.vision) of klines and trades, either as CSV or as the
+//     downloaded ".zip" files.
+//
+// Files can be split in any way (e.g. by month), and may overlap: everything is sorted by timestamp, and repeated
+// candlesticks are discarded. Timestamps in milliseconds or microseconds are converted to seconds.
+package offline
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/marianogappa/signal-checker/common"
+)
+
+type Offline struct {
+	dataDir string
+	debug   bool
+
+	// Files are read once per pair, as checks build many iterators (e.g. one per zoomed hour, or per ambiguous
+	// candlestick on the "exact" intraCandleResolution).
+	mu           sync.Mutex
+	candlesticks map[string][]common.Candlestick
+	trades       map[string][]common.Trade
+}
+
+func NewOffline(dataDir string) *Offline {
+	return &Offline{dataDir: dataDir, candlesticks: map[string][]common.Candlestick{}, trades: map[string][]common.Trade{}}
+}
+
+func (o *Offline) SetDebug(debug bool) {
+	o.debug = debug
+}
+
+// Capabilities has no default fees, as the files may come from any exchange.
+func (o *Offline) Capabilities() common.ExchangeCapabilities {
+	return common.ExchangeCapabilities{Trades: true}
+}
+
+func (o *Offline) BuildCandlestickIterator(ctx context.Context, baseAsset, quoteAsset string, initialISO8601 common.ISO8601) *common.CandlestickIterator {
+	// N.B. already validated
+	initialSeconds, _ := initialISO8601.Seconds()
+	var (
+		candlesticks []common.Candlestick
+		loaded       bool
+	)
+	return common.NewCandlestickIterator(func() (common.Candlestick, error) {
+		if !loaded {
+			var err error
+			candlesticks, err = o.loadCandlesticks(ctx, baseAsset, quoteAsset)
+			if err != nil {
+				return common.Candlestick{}, err
+			}
+			candlesticks = candlesticks[sort.Search(len(candlesticks), func(i int) bool { return candlesticks[i].Timestamp >= initialSeconds }):]
+			loaded = true
+		}
+		if len(candlesticks) == 0 {
+			return common.Candlestick{}, common.ErrOutOfCandlesticks
+		}
+		candlestick := candlesticks[0]
+		candlesticks = candlesticks[1:]
+		return candlestick, nil
+	})
+}
+
+func (o *Offline) BuildTradeIterator(ctx context.Context, baseAsset, quoteAsset string, initialISO8601 common.ISO8601) *common.TradeIterator {
+	// N.B. already validated
+	initialSeconds, _ := initialISO8601.Seconds()
+	var (
+		trades []common.Trade
+		loaded bool
+	)
+	return common.NewTradeIterator(func() (common.Trade, error) {
+		if !loaded {
+			var err error
+			trades, err = o.loadTrades(ctx, baseAsset, quoteAsset)
+			if err != nil {
+				return common.Trade{}, err
+			}
+			trades = trades[sort.Search(len(trades), func(i int) bool { return trades[i].Timestamp >= initialSeconds }):]
+			loaded = true
+		}
+		if len(trades) == 0 {
+			return common.Trade{}, common.ErrOutOfTrades
+		}
+		trade := trades[0]
+		trades = trades[1:]
+		return trade, nil
+	})
+}
+
+// loadCandlesticks returns the pair's sorted candlesticks, reading them only the first time. The returned slice is
+// shared, so it must not be modified.
+func (o *Offline) loadCandlesticks(ctx context.Context, baseAsset, quoteAsset string) ([]common.Candlestick, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	key := o.pairDir(baseAsset, quoteAsset, "candlesticks")
+	if candlesticks, ok := o.candlesticks[key]; ok {
+		return candlesticks, nil
+	}
+	candlesticks, err := o.readCandlesticks(ctx, baseAsset, quoteAsset)
+	if err != nil {
+		return nil, err
+	}
+	o.candlesticks[key] = candlesticks
+	return candlesticks, nil
+}
+
+// loadTrades is like loadCandlesticks, but for trades.
+func (o *Offline) loadTrades(ctx context.Context, baseAsset, quoteAsset string) ([]common.Trade, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	key := o.pairDir(baseAsset, quoteAsset, "trades")
+	if trades, ok := o.trades[key]; ok {
+		return trades, nil
+	}
+	trades, err := o.readTrades(ctx, baseAsset, quoteAsset)
+	if err != nil {
+		return nil, err
+	}
+	o.trades[key] = trades
+	return trades, nil
+}
+
+func (o *Offline) pairDir(baseAsset, quoteAsset, kind string) string {
+	return filepath.Join(o.dataDir, fmt.Sprintf("%v-%v", strings.ToUpper(baseAsset), strings.ToUpper(quoteAsset)), kind)
+}
+
+func (o *Offline) readCandlesticks(ctx context.Context, baseAsset, quoteAsset string) ([]common.Candlestick, error) {
+	dir := o.pairDir(baseAsset, quoteAsset, "candlesticks")
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return nil, fmt.Errorf("offline: no candlesticks for %v-%v, as %v does not exist", baseAsset, quoteAsset, dir)
+	}
+	candlesticks := []common.Candlestick{}
+	err := readFiles(dir, func(name string, content fileContent) error {
+		common.LoggerFrom(ctx, o.debug).Printf("Offline: reading candlesticks from %v\n", name)
+		cs, err := content.candlesticks()
+		if err != nil {
+			return fmt.Errorf("offline: %v: %v", name, err)
+		}
+		candlesticks = append(candlesticks, cs...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return sortCandlesticks(candlesticks), nil
+}
+
+// readTrades returns no trades if there's no trades directory, as trades are optional.
+func (o *Offline) readTrades(ctx context.Context, baseAsset, quoteAsset string) ([]common.Trade, error) {
+	dir := o.pairDir(baseAsset, quoteAsset, "trades")
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return []common.Trade{}, nil
+	}
+	trades := []common.Trade{}
+	err := readFiles(dir, func(name string, content fileContent) error {
+		common.LoggerFrom(ctx, o.debug).Printf("Offline: reading trades from %v\n", name)
+		ts, err := content.trades()
+		if err != nil {
+			return fmt.Errorf("offline: %v: %v", name, err)
+		}
+		trades = append(trades, ts...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return sortTrades(trades), nil
+}
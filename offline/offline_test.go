@@ -0,0 +1,289 @@
+package offline
+
+import (
+	"archive/zip"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/marianogappa/signal-checker/common"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if filepath.Ext(name) == ".zip" {
+			writeZip(t, path, content)
+			continue
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// writeZip writes a zip file with a single csv file, like Binance's data dumps.
+func writeZip(t *testing.T, path string, content string) {
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	w := zip.NewWriter(file)
+	f, err := w.Create(filepath.Base(path[:len(path)-len(".zip")]) + ".csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Write([]byte(content)); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCandlesticks(t *testing.T) {
+	type test struct {
+		name     string
+		files    map[string]string
+		initial  common.ISO8601
+		expected []common.Candlestick
+		isErr    bool
+	}
+	tss := []test{
+		{
+			name: "JSON Lines",
+			files: map[string]string{
+				"BTC-USDT/candlesticks/2021-07.jsonl": `{"t":1625408040,"o":1,"c":2,"l":0.5,"h":3,"v":10,"n":5}
+
+{"t":1625408100,"o":2,"c":3,"l":1.5,"h":4,"v":20}
+`,
+			},
+			initial: "2021-07-04T14:14:00Z",
+			expected: []common.Candlestick{
+				{Timestamp: 1625408040, OpenPrice: 1, ClosePrice: 2, LowestPrice: 0.5, HighestPrice: 3, Volume: 10, NumberOfTrades: 5},
+				{Timestamp: 1625408100, OpenPrice: 2, ClosePrice: 3, LowestPrice: 1.5, HighestPrice: 4, Volume: 20},
+			},
+		},
+		{
+			name: "CSV with header, in any column order",
+			files: map[string]string{
+				"BTC-USDT/candlesticks/2021-07.csv": "t,h,l,o,c,v\n1625408040,3,0.5,1,2,10\n1625408100,4,1.5,2,3,20\n",
+			},
+			initial: "2021-07-04T14:14:00Z",
+			expected: []common.Candlestick{
+				{Timestamp: 1625408040, OpenPrice: 1, ClosePrice: 2, LowestPrice: 0.5, HighestPrice: 3, Volume: 10},
+				{Timestamp: 1625408100, OpenPrice: 2, ClosePrice: 3, LowestPrice: 1.5, HighestPrice: 4, Volume: 20},
+			},
+		},
+		{
+			name: "Binance data dump CSV without header, in milliseconds",
+			files: map[string]string{
+				"BTC-USDT/candlesticks/BTCUSDT-1m-2021-07.csv": "1625408040000,1,3,0.5,2,10,1625408099999,20,5,4,8,0\n",
+			},
+			initial: "2021-07-04T14:14:00Z",
+			expected: []common.Candlestick{
+				{Timestamp: 1625408040, OpenPrice: 1, ClosePrice: 2, LowestPrice: 0.5, HighestPrice: 3, Volume: 10, NumberOfTrades: 5},
+			},
+		},
+		{
+			name: "Binance data dump CSV with header, in microseconds",
+			files: map[string]string{
+				"BTC-USDT/candlesticks/BTCUSDT-1m-2025-01.csv": "open_time,open,high,low,close,volume,close_time,quote_volume,count,taker_buy_volume,taker_buy_quote_volume,ignore\n1625408040000000,1,3,0.5,2,10,1625408099999999,20,5,4,8,0\n",
+			},
+			initial: "2021-07-04T14:14:00Z",
+			expected: []common.Candlestick{
+				{Timestamp: 1625408040, OpenPrice: 1, ClosePrice: 2, LowestPrice: 0.5, HighestPrice: 3, Volume: 10, NumberOfTrades: 5},
+			},
+		},
+		{
+			name: "Binance data dump zip",
+			files: map[string]string{
+				"BTC-USDT/candlesticks/BTCUSDT-1m-2021-07.zip": "1625408040000,1,3,0.5,2,10,1625408099999,20,5,4,8,0\n",
+			},
+			initial: "2021-07-04T14:14:00Z",
+			expected: []common.Candlestick{
+				{Timestamp: 1625408040, OpenPrice: 1, ClosePrice: 2, LowestPrice: 0.5, HighestPrice: 3, Volume: 10, NumberOfTrades: 5},
+			},
+		},
+		{
+			name: "Overlapping files are sorted, and repeated candlesticks discarded",
+			files: map[string]string{
+				"BTC-USDT/candlesticks/a.jsonl": `{"t":1625408100,"o":2,"c":2,"l":2,"h":2}`,
+				"BTC-USDT/candlesticks/b.jsonl": `{"t":1625408040,"o":1,"c":1,"l":1,"h":1}
+{"t":1625408100,"o":2,"c":2,"l":2,"h":2}`,
+				"BTC-USDT/candlesticks/README.md": "ignored",
+			},
+			initial: "2021-07-04T14:14:00Z",
+			expected: []common.Candlestick{
+				{Timestamp: 1625408040, OpenPrice: 1, ClosePrice: 1, LowestPrice: 1, HighestPrice: 1},
+				{Timestamp: 1625408100, OpenPrice: 2, ClosePrice: 2, LowestPrice: 2, HighestPrice: 2},
+			},
+		},
+		{
+			name: "Candlesticks before the initial time are skipped",
+			files: map[string]string{
+				"BTC-USDT/candlesticks/2021-07.csv": "t,o,c,l,h\n1625408040,1,1,1,1\n1625408100,2,2,2,2\n",
+			},
+			initial: "2021-07-04T14:14:18Z",
+			expected: []common.Candlestick{
+				{Timestamp: 1625408100, OpenPrice: 2, ClosePrice: 2, LowestPrice: 2, HighestPrice: 2},
+			},
+		},
+		{
+			name: "CSV header without a required column",
+			files: map[string]string{
+				"BTC-USDT/candlesticks/2021-07.csv": "t,o,c,l\n1625408040,1,1,1\n",
+			},
+			initial: "2021-07-04T14:14:00Z",
+			isErr:   true,
+		},
+		{
+			name: "Invalid price",
+			files: map[string]string{
+				"BTC-USDT/candlesticks/2021-07.csv": "t,o,c,l,h\n1625408040,1,1,1,one\n",
+			},
+			initial: "2021-07-04T14:14:00Z",
+			isErr:   true,
+		},
+		{
+			name:    "No data for the pair",
+			files:   map[string]string{"ETH-USDT/candlesticks/2021-07.csv": "t,o,c,l,h\n1625408040,1,1,1,1\n"},
+			initial: "2021-07-04T14:14:00Z",
+			isErr:   true,
+		},
+	}
+	for _, ts := range tss {
+		t.Run(ts.name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "signal-checker-offline")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(dir)
+			writeFiles(t, dir, ts.files)
+
+			it := NewOffline(dir).BuildCandlestickIterator(context.Background(), "BTC", "USDT", ts.initial)
+			actual := []common.Candlestick{}
+			for {
+				candlestick, err := it.Next()
+				if err == common.ErrOutOfCandlesticks {
+					break
+				}
+				if err != nil && ts.isErr {
+					return
+				}
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				actual = append(actual, candlestick)
+			}
+			if ts.isErr {
+				t.Fatalf("expected an error but got %v", actual)
+			}
+			if !reflect.DeepEqual(ts.expected, actual) {
+				t.Fatalf("expected %v but got %v", ts.expected, actual)
+			}
+		})
+	}
+}
+
+func TestTrades(t *testing.T) {
+	type test struct {
+		name     string
+		files    map[string]string
+		expected []common.Trade
+	}
+	tss := []test{
+		{
+			name: "JSON Lines",
+			files: map[string]string{
+				"BTC-USDT/trades/2021-07.jsonl": `{"BaseAssetPrice":1.5,"BaseAssetQuantity":2,"t":1625408041}`,
+			},
+			expected: []common.Trade{{BaseAssetPrice: 1.5, BaseAssetQuantity: 2, Timestamp: 1625408041}},
+		},
+		{
+			name: "CSV with header",
+			files: map[string]string{
+				"BTC-USDT/trades/2021-07.csv": "t,BaseAssetPrice,BaseAssetQuantity\n1625408041,1.5,2\n",
+			},
+			expected: []common.Trade{{BaseAssetPrice: 1.5, BaseAssetQuantity: 2, Timestamp: 1625408041}},
+		},
+		{
+			name: "Binance data dump CSV without header",
+			files: map[string]string{
+				"BTC-USDT/trades/BTCUSDT-trades-2021-07.csv": "1,1.5,2,3,1625408041000,True,True\n2,1.6,1,1.6,1625408040000,False,True\n",
+			},
+			expected: []common.Trade{
+				{BaseAssetPrice: 1.6, BaseAssetQuantity: 1, Timestamp: 1625408040},
+				{BaseAssetPrice: 1.5, BaseAssetQuantity: 2, Timestamp: 1625408041},
+			},
+		},
+		{
+			name:     "No trades",
+			files:    map[string]string{"BTC-USDT/candlesticks/2021-07.csv": "t,o,c,l,h\n1625408040,1,1,1,1\n"},
+			expected: []common.Trade{},
+		},
+	}
+	for _, ts := range tss {
+		t.Run(ts.name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "signal-checker-offline")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(dir)
+			writeFiles(t, dir, ts.files)
+
+			it := NewOffline(dir).BuildTradeIterator(context.Background(), "BTC", "USDT", "2021-07-04T14:14:00Z")
+			actual := []common.Trade{}
+			for {
+				trade, err := it.Next()
+				if err == common.ErrOutOfTrades {
+					break
+				}
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				actual = append(actual, trade)
+			}
+			if !reflect.DeepEqual(ts.expected, actual) {
+				t.Fatalf("expected %v but got %v", ts.expected, actual)
+			}
+		})
+	}
+}
+
+func TestReadsFilesOnce(t *testing.T) {
+	dir, err := ioutil.TempDir("", "signal-checker-offline")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	writeFiles(t, dir, map[string]string{
+		"BTC-USDT/candlesticks/2021-07.csv": "t,o,c,l,h\n1625408040,1,1,1,1\n1625408100,2,2,2,2\n",
+		"BTC-USDT/trades/2021-07.csv":       "t,BaseAssetPrice,BaseAssetQuantity\n1625408041,1.5,2\n",
+	})
+	o := NewOffline(dir)
+	if _, err := o.BuildCandlestickIterator(context.Background(), "BTC", "USDT", "2021-07-04T14:14:00Z").Next(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := o.BuildTradeIterator(context.Background(), "BTC", "USDT", "2021-07-04T14:14:00Z").Next(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Later iterators are served from memory, even if the files are gone.
+	os.RemoveAll(filepath.Join(dir, "BTC-USDT"))
+	candlestick, err := o.BuildCandlestickIterator(context.Background(), "BTC", "USDT", "2021-07-04T14:15:00Z").Next()
+	if err != nil || candlestick.Timestamp != 1625408100 {
+		t.Fatalf("expected the second candlestick, but got %v (error %v)", candlestick, err)
+	}
+	trade, err := o.BuildTradeIterator(context.Background(), "BTC", "USDT", "2021-07-04T14:14:00Z").Next()
+	if err != nil || trade.Timestamp != 1625408041 {
+		t.Fatalf("expected the trade, but got %v (error %v)", trade, err)
+	}
+}
@@ -0,0 +1,269 @@
+// The portfolio package simulates following many signals with a single account, i.e. with a shared, limited capital.
+//
+// Each signal's ProfitRatio assumes that there is always capital to enter it. Instead, this simulator goes through the
+// signals in chronological order, sizing each position from the account's balance, and skipping signals when the
+// capital is busy on other positions.
+//
+// Use it like this: output, err := portfolio.Run(input)
+package portfolio
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/marianogappa/signal-checker/common"
+	"github.com/marianogappa/signal-checker/signalchecker"
+)
+
+const (
+	POSITION_SIZING_FIXED_FRACTION = "fixed_fraction"
+	POSITION_SIZING_FIXED_USD      = "fixed_usd"
+
+	POSITION_STATUS_TAKEN       = "taken"
+	POSITION_STATUS_SKIPPED     = "skipped"
+	POSITION_STATUS_NOT_ENTERED = "not_entered"
+	POSITION_STATUS_ERROR       = "error"
+
+	SKIP_REASON_MAX_CONCURRENT_POSITIONS = "max_concurrent_positions"
+	SKIP_REASON_INSUFFICIENT_CAPITAL     = "insufficient_capital"
+)
+
+const maxInt = int(^uint(0) >> 1)
+
+var (
+	ErrInitialBalanceMustBePositive  = errors.New("initialBalance must be greater than 0")
+	ErrInvalidPositionSizing         = errors.New("positionSizing must be one of 'fixed_fraction' or 'fixed_usd'")
+	ErrPositionSizeInvalid           = errors.New("positionSize must be greater than 0, and at most 1 for 'fixed_fraction'")
+	ErrMaxConcurrentPositionsInvalid = errors.New("maxConcurrentPositions must not be negative")
+)
+
+// Input is the input to the portfolio simulation.
+type Input struct {
+	// Signals are the signals followed by the account, in any order.
+	Signals []common.SignalCheckInput `json:"signals"`
+
+	// InitialBalance is the account's starting balance, in quote asset (e.g. USDT).
+	InitialBalance common.JsonFloat64 `json:"initialBalance"`
+
+	// PositionSizing decides how much of the balance goes into each signal. One of:
+	//
+	// - fixed_fraction: (default) PositionSize is a ratio of the balance (e.g. 0.1 for 10%) when entering.
+	// - fixed_usd: PositionSize is the amount of quote asset (e.g. 100 USDT).
+	PositionSizing string `json:"positionSizing"`
+
+	// PositionSize is the amount of each position, as decided by PositionSizing.
+	PositionSize common.JsonFloat64 `json:"positionSize"`
+
+	// MaxConcurrentPositions is the maximum number of positions open at the same time; 0 means no limit. Signals that
+	// enter while at the limit are skipped.
+	MaxConcurrentPositions int `json:"maxConcurrentPositions"`
+}
+
+// Output is the result of the portfolio simulation.
+type Output struct {
+	// FinalBalance is the account's balance after all positions closed.
+	FinalBalance common.JsonFloat64 `json:"finalBalance"`
+
+	// ProfitRatio is the account's profit (or loss, if negative) as a ratio of the InitialBalance.
+	ProfitRatio common.JsonFloat64 `json:"profitRatio"`
+
+	// RealizedMaxDrawdownRatio is the largest drop of the balance from a previous peak, as a ratio of that peak. It's
+	// the realized drawdown: the balance only changes when positions close, so drops of open positions that recover
+	// before closing are not included.
+	RealizedMaxDrawdownRatio common.JsonFloat64 `json:"realizedMaxDrawdownRatio"`
+
+	// EquityCurve is the balance over time: the initial balance, and the balance after each position closed.
+	EquityCurve []EquityPoint `json:"equityCurve"`
+
+	// Positions are the results of the signals, in the same order as Input.Signals.
+	Positions []Position `json:"positions"`
+}
+
+// EquityPoint is the account's balance at a point in time.
+type EquityPoint struct {
+	At      common.ISO8601     `json:"at"`
+	Balance common.JsonFloat64 `json:"balance"`
+}
+
+// Position is the result of following a signal with the account.
+type Position struct {
+	// Status is one of taken, skipped (see SkipReason), not_entered or error (see ErrorMessage).
+	Status string `json:"status"`
+
+	// SkipReason is, in the case of 'skipped', one of max_concurrent_positions or insufficient_capital.
+	SkipReason string `json:"skipReason,omitempty"`
+
+	// OpenedAt is when the signal first entered.
+	OpenedAt common.ISO8601 `json:"openedAt,omitempty"`
+
+	// ClosedAt is when the signal's last event happened.
+	ClosedAt common.ISO8601 `json:"closedAt,omitempty"`
+
+	// Size is the amount of quote asset invested in the signal.
+	Size common.JsonFloat64 `json:"size,omitempty"`
+
+	// ProfitRatio is the signal's ProfitRatio.
+	ProfitRatio common.JsonFloat64 `json:"profitRatio"`
+
+	// Profit is the amount of quote asset made (or lost, if negative) by the position.
+	Profit common.JsonFloat64 `json:"profit"`
+
+	// ErrorMessage is, in the case of 'error', why the signal couldn't be checked.
+	ErrorMessage string `json:"errorMessage,omitempty"`
+}
+
+// Run checks all the input's signals, and simulates following them. Options are passed to every signal check.
+func Run(input Input, opts ...signalchecker.Option) (Output, error) {
+	input, err := validateInput(input)
+	if err != nil {
+		return Output{}, err
+	}
+	outputs := []common.SignalCheckOutput{}
+	for _, signal := range input.Signals {
+		output, _ := signalchecker.NewSignalChecker(signal, opts...).Check()
+		outputs = append(outputs, output)
+	}
+	return Simulate(input, outputs)
+}
+
+func validateInput(input Input) (Input, error) {
+	if input.InitialBalance <= 0 {
+		return input, ErrInitialBalanceMustBePositive
+	}
+	if input.PositionSizing == "" {
+		input.PositionSizing = POSITION_SIZING_FIXED_FRACTION
+	}
+	if input.PositionSizing != POSITION_SIZING_FIXED_FRACTION && input.PositionSizing != POSITION_SIZING_FIXED_USD {
+		return input, ErrInvalidPositionSizing
+	}
+	if input.PositionSize <= 0 || (input.PositionSizing == POSITION_SIZING_FIXED_FRACTION && input.PositionSize > 1) {
+		return input, ErrPositionSizeInvalid
+	}
+	if input.MaxConcurrentPositions < 0 {
+		return input, ErrMaxConcurrentPositionsInvalid
+	}
+	return input, nil
+}
+
+// position is a signal that entered, and so may be taken by the account.
+type position struct {
+	index              int
+	openedAt, closedAt int
+	output             common.SignalCheckOutput
+}
+
+// Simulate is like Run, for when the signals were already checked. outputs must be in the same order as
+// input.Signals.
+//
+// Each position is opened (i.e. the capital is allocated) when its signal first enters, and closed when its last
+// event happens, at the signal's ProfitRatio. Note that this means that signals with many entries are considered to
+// use all of their capital from the first entry.
+func Simulate(input Input, outputs []common.SignalCheckOutput) (Output, error) {
+	input, err := validateInput(input)
+	if err != nil {
+		return Output{}, err
+	}
+	result := Output{Positions: make([]Position, len(outputs)), EquityCurve: []EquityPoint{}}
+
+	candidates := []position{}
+	for i, output := range outputs {
+		p, status, errorMessage := toPosition(i, output)
+		result.Positions[i] = Position{Status: status, ErrorMessage: errorMessage}
+		if status == POSITION_STATUS_TAKEN {
+			candidates = append(candidates, p)
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].openedAt < candidates[j].openedAt
+	})
+
+	balance := float64(input.InitialBalance)
+	allocated := 0.0
+	open := []position{}
+	if len(candidates) > 0 {
+		result.EquityCurve = append(result.EquityCurve, EquityPoint{At: toISO8601(candidates[0].openedAt), Balance: input.InitialBalance})
+	}
+	closeUntil := func(until int) {
+		sort.SliceStable(open, func(i, j int) bool {
+			return open[i].closedAt < open[j].closedAt
+		})
+		for len(open) > 0 && open[0].closedAt <= until {
+			p := open[0]
+			open = open[1:]
+			r := &result.Positions[p.index]
+			r.Profit = r.Size * p.output.ProfitRatio
+			allocated -= float64(r.Size)
+			balance += float64(r.Profit)
+			result.EquityCurve = append(result.EquityCurve, EquityPoint{At: r.ClosedAt, Balance: common.JsonFloat64(balance)})
+		}
+	}
+	for _, p := range candidates {
+		closeUntil(p.openedAt)
+		r := &result.Positions[p.index]
+		if input.MaxConcurrentPositions > 0 && len(open) >= input.MaxConcurrentPositions {
+			r.Status, r.SkipReason = POSITION_STATUS_SKIPPED, SKIP_REASON_MAX_CONCURRENT_POSITIONS
+			continue
+		}
+		size := float64(input.PositionSize)
+		if input.PositionSizing == POSITION_SIZING_FIXED_FRACTION {
+			size *= balance
+		}
+		if size <= 0 || size > balance-allocated {
+			r.Status, r.SkipReason = POSITION_STATUS_SKIPPED, SKIP_REASON_INSUFFICIENT_CAPITAL
+			continue
+		}
+		allocated += size
+		r.OpenedAt = toISO8601(p.openedAt)
+		r.ClosedAt = toISO8601(p.closedAt)
+		r.Size = common.JsonFloat64(size)
+		r.ProfitRatio = p.output.ProfitRatio
+		open = append(open, p)
+	}
+	// Close the remaining positions.
+	closeUntil(maxInt)
+
+	result.FinalBalance = common.JsonFloat64(balance)
+	result.ProfitRatio = common.JsonFloat64(balance/float64(input.InitialBalance) - 1)
+	result.RealizedMaxDrawdownRatio = common.JsonFloat64(realizedMaxDrawdownRatio(result.EquityCurve))
+	return result, nil
+}
+
+// toPosition returns the position of a signal that entered, or otherwise the status of the signal.
+func toPosition(index int, output common.SignalCheckOutput) (position, string, string) {
+	if output.IsError {
+		return position{}, POSITION_STATUS_ERROR, output.ErrorMessage
+	}
+	if !output.Entered || len(output.Events) == 0 {
+		return position{}, POSITION_STATUS_NOT_ENTERED, ""
+	}
+	p := position{index: index, openedAt: -1, output: output}
+	for _, event := range output.Events {
+		if event.EventType == common.ENTERED && p.openedAt == -1 {
+			p.openedAt, _ = event.At.Seconds()
+		}
+	}
+	p.closedAt, _ = output.Events[len(output.Events)-1].At.Seconds()
+	if p.openedAt == -1 {
+		return position{}, POSITION_STATUS_NOT_ENTERED, ""
+	}
+	return p, POSITION_STATUS_TAKEN, ""
+}
+
+func realizedMaxDrawdownRatio(curve []EquityPoint) float64 {
+	peak, maxDrawdown := 0.0, 0.0
+	for _, point := range curve {
+		balance := float64(point.Balance)
+		if balance > peak {
+			peak = balance
+		}
+		if peak > 0 && (peak-balance)/peak > maxDrawdown {
+			maxDrawdown = (peak - balance) / peak
+		}
+	}
+	return maxDrawdown
+}
+
+func toISO8601(seconds int) common.ISO8601 {
+	return common.ISO8601(time.Unix(int64(seconds), 0).UTC().Format(time.RFC3339))
+}
@@ -0,0 +1,174 @@
+package portfolio
+
+import (
+	"encoding/json"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/marianogappa/signal-checker/common"
+)
+
+func entered(openedAt, closedAt common.ISO8601, profitRatio float64) common.SignalCheckOutput {
+	return common.SignalCheckOutput{
+		Entered:     true,
+		ProfitRatio: common.JsonFloat64(profitRatio),
+		Events: []common.SignalCheckOutputEvent{
+			{EventType: common.ENTERED, Target: 1, At: openedAt},
+			{EventType: common.ENTERED, Target: 2, At: openedAt},
+			{EventType: common.TOOK_PROFIT, Target: 1, At: closedAt},
+		},
+	}
+}
+
+func TestSimulate(t *testing.T) {
+	type test struct {
+		name                string
+		input               Input
+		outputs             []common.SignalCheckOutput
+		expectedPositions   []Position
+		expectedCurve       []EquityPoint
+		expectedFinal       float64
+		expectedMaxDrawdown float64
+	}
+	tss := []test{
+		{
+			name:  "Fixed fraction compounds the balance",
+			input: Input{InitialBalance: 1000, PositionSize: 0.5},
+			outputs: []common.SignalCheckOutput{
+				// Out of order, to check that positions are taken in chronological order.
+				entered("2021-07-03T00:00:00Z", "2021-07-04T00:00:00Z", -0.2),
+				entered("2021-07-01T00:00:00Z", "2021-07-02T00:00:00Z", 0.1),
+			},
+			expectedPositions: []Position{
+				{Status: POSITION_STATUS_TAKEN, OpenedAt: "2021-07-03T00:00:00Z", ClosedAt: "2021-07-04T00:00:00Z", Size: 525, ProfitRatio: -0.2, Profit: -105},
+				{Status: POSITION_STATUS_TAKEN, OpenedAt: "2021-07-01T00:00:00Z", ClosedAt: "2021-07-02T00:00:00Z", Size: 500, ProfitRatio: 0.1, Profit: 50},
+			},
+			expectedCurve: []EquityPoint{
+				{At: "2021-07-01T00:00:00Z", Balance: 1000},
+				{At: "2021-07-02T00:00:00Z", Balance: 1050},
+				{At: "2021-07-04T00:00:00Z", Balance: 945},
+			},
+			expectedFinal:       945,
+			expectedMaxDrawdown: 0.1,
+		},
+		{
+			name:  "Max concurrent positions",
+			input: Input{InitialBalance: 1000, PositionSizing: POSITION_SIZING_FIXED_USD, PositionSize: 100, MaxConcurrentPositions: 1},
+			outputs: []common.SignalCheckOutput{
+				entered("2021-07-01T00:00:00Z", "2021-07-05T00:00:00Z", 0.1),
+				entered("2021-07-02T00:00:00Z", "2021-07-03T00:00:00Z", 0.5),
+				// Opens as the first one closes, so there's room for it.
+				entered("2021-07-05T00:00:00Z", "2021-07-06T00:00:00Z", 0.2),
+			},
+			expectedPositions: []Position{
+				{Status: POSITION_STATUS_TAKEN, OpenedAt: "2021-07-01T00:00:00Z", ClosedAt: "2021-07-05T00:00:00Z", Size: 100, ProfitRatio: 0.1, Profit: 10},
+				{Status: POSITION_STATUS_SKIPPED, SkipReason: SKIP_REASON_MAX_CONCURRENT_POSITIONS},
+				{Status: POSITION_STATUS_TAKEN, OpenedAt: "2021-07-05T00:00:00Z", ClosedAt: "2021-07-06T00:00:00Z", Size: 100, ProfitRatio: 0.2, Profit: 20},
+			},
+			expectedCurve: []EquityPoint{
+				{At: "2021-07-01T00:00:00Z", Balance: 1000},
+				{At: "2021-07-05T00:00:00Z", Balance: 1010},
+				{At: "2021-07-06T00:00:00Z", Balance: 1030},
+			},
+			expectedFinal: 1030,
+		},
+		{
+			name:  "Insufficient capital",
+			input: Input{InitialBalance: 100, PositionSizing: POSITION_SIZING_FIXED_USD, PositionSize: 60},
+			outputs: []common.SignalCheckOutput{
+				entered("2021-07-01T00:00:00Z", "2021-07-03T00:00:00Z", -0.5),
+				entered("2021-07-02T00:00:00Z", "2021-07-04T00:00:00Z", 0.5),
+				// After the first loss, the balance is 70.
+				entered("2021-07-05T00:00:00Z", "2021-07-06T00:00:00Z", 0.5),
+				entered("2021-07-05T00:00:00Z", "2021-07-06T00:00:00Z", 0.5),
+			},
+			expectedPositions: []Position{
+				{Status: POSITION_STATUS_TAKEN, OpenedAt: "2021-07-01T00:00:00Z", ClosedAt: "2021-07-03T00:00:00Z", Size: 60, ProfitRatio: -0.5, Profit: -30},
+				{Status: POSITION_STATUS_SKIPPED, SkipReason: SKIP_REASON_INSUFFICIENT_CAPITAL},
+				{Status: POSITION_STATUS_TAKEN, OpenedAt: "2021-07-05T00:00:00Z", ClosedAt: "2021-07-06T00:00:00Z", Size: 60, ProfitRatio: 0.5, Profit: 30},
+				{Status: POSITION_STATUS_SKIPPED, SkipReason: SKIP_REASON_INSUFFICIENT_CAPITAL},
+			},
+			expectedCurve: []EquityPoint{
+				{At: "2021-07-01T00:00:00Z", Balance: 100},
+				{At: "2021-07-03T00:00:00Z", Balance: 70},
+				{At: "2021-07-06T00:00:00Z", Balance: 100},
+			},
+			expectedFinal:       100,
+			expectedMaxDrawdown: 0.3,
+		},
+		{
+			name:  "Not entered and errors",
+			input: Input{InitialBalance: 100, PositionSize: 1},
+			outputs: []common.SignalCheckOutput{
+				{Events: []common.SignalCheckOutputEvent{{EventType: common.FINISHED_DATASET, At: "2021-07-01T00:00:00Z"}}},
+				{IsError: true, ErrorMessage: "base asset is required (e.g. BTC)"},
+			},
+			expectedPositions: []Position{
+				{Status: POSITION_STATUS_NOT_ENTERED},
+				{Status: POSITION_STATUS_ERROR, ErrorMessage: "base asset is required (e.g. BTC)"},
+			},
+			expectedCurve: []EquityPoint{},
+			expectedFinal: 100,
+		},
+	}
+	for _, ts := range tss {
+		t.Run(ts.name, func(t *testing.T) {
+			actual, err := Simulate(ts.input, ts.outputs)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(ts.expectedPositions, actual.Positions) {
+				t.Fatalf("expected positions %+v but got %+v", ts.expectedPositions, actual.Positions)
+			}
+			if !reflect.DeepEqual(ts.expectedCurve, actual.EquityCurve) {
+				t.Fatalf("expected equity curve %+v but got %+v", ts.expectedCurve, actual.EquityCurve)
+			}
+			if math.Abs(float64(actual.FinalBalance)-ts.expectedFinal) > 1e-9 {
+				t.Fatalf("expected final balance %v but got %v", ts.expectedFinal, actual.FinalBalance)
+			}
+			if math.Abs(float64(actual.RealizedMaxDrawdownRatio)-ts.expectedMaxDrawdown) > 1e-9 {
+				t.Fatalf("expected max drawdown %v but got %v", ts.expectedMaxDrawdown, actual.RealizedMaxDrawdownRatio)
+			}
+		})
+	}
+}
+
+func TestSimulateValidatesInput(t *testing.T) {
+	type test struct {
+		name        string
+		input       Input
+		expectedErr error
+	}
+	tss := []test{
+		{name: "No initial balance", input: Input{PositionSize: 0.1}, expectedErr: ErrInitialBalanceMustBePositive},
+		{name: "Invalid position sizing", input: Input{InitialBalance: 100, PositionSizing: "invalid", PositionSize: 0.1}, expectedErr: ErrInvalidPositionSizing},
+		{name: "No position size", input: Input{InitialBalance: 100}, expectedErr: ErrPositionSizeInvalid},
+		{name: "Fraction above 1", input: Input{InitialBalance: 100, PositionSize: 1.5}, expectedErr: ErrPositionSizeInvalid},
+		{name: "Negative max concurrent positions", input: Input{InitialBalance: 100, PositionSize: 0.1, MaxConcurrentPositions: -1}, expectedErr: ErrMaxConcurrentPositionsInvalid},
+	}
+	for _, ts := range tss {
+		t.Run(ts.name, func(t *testing.T) {
+			if _, err := Simulate(ts.input, nil); err != ts.expectedErr {
+				t.Fatalf("expected error %v but got %v", ts.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestMarshalsBreakevenPositions(t *testing.T) {
+	output, err := Simulate(Input{InitialBalance: 1000, PositionSize: 0.5}, []common.SignalCheckOutput{
+		entered("2021-07-01T00:00:00Z", "2021-07-02T00:00:00Z", 0),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	bs, err := json.Marshal(output.Positions[0])
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(string(bs), `"profitRatio":0`) || !strings.Contains(string(bs), `"profit":0`) {
+		t.Fatalf("expected a zero profit ratio and profit but got %v", string(bs))
+	}
+}
@@ -1,8 +1,6 @@
 package profitcalculator
 
 import (
-	"log"
-
 	"github.com/marianogappa/signal-checker/common"
 )
 
@@ -19,6 +17,10 @@ type ProfitCalculator struct {
 	positionSize       float64
 	ratioAwaitingEnter float64
 	ratioOut           float64
+	tradingCost        float64
+	funding            float64
+
+	logger *common.Logger
 }
 
 func calculateCumulativeRatios(requiredLen int, ratios []common.JsonFloat64) []float64 {
@@ -38,21 +40,69 @@ func calculateCumulativeRatios(requiredLen int, ratios []common.JsonFloat64) []f
 func NewProfitCalculator(input common.SignalCheckInput) ProfitCalculator {
 	return ProfitCalculator{
 		input:              input,
-		tpCumRatios:        calculateCumulativeRatios(len(input.TakeProfits), input.TakeProfitRatios),
-		entryCumRatios:     calculateCumulativeRatios(max(1, len(input.Entries)), input.EntryRatios),
+		tpCumRatios:        calculateCumulativeRatios(input.TakeProfitCount(), input.TakeProfitRatios),
+		entryCumRatios:     calculateCumulativeRatios(max(1, input.EntryCount()), input.EntryRatios),
 		ratioAwaitingEnter: 1.0,
+		logger:             common.NewLogger(input.Debug, false),
 	}
 }
 
+// SetLogger replaces the logger, e.g. with the check's, to return the profit calculations on its output.
+func (p *ProfitCalculator) SetLogger(logger *common.Logger) {
+	p.logger = logger
+}
+
 func (p *ProfitCalculator) updatePositionSize(event common.SignalCheckOutputEvent) float64 {
 	p.positionSize *= float64(event.Price) / p.lastPrice
 	return p.positionSize
 }
 
-func (p *ProfitCalculator) ApplyEvent(event common.SignalCheckOutputEvent) float64 {
-	if p.input.Debug {
-		log.Printf("ProfitCalculator: applying event '%v' with price %v\n", event.EventType, event.Price)
+// applyTradingCost deducts the fees (and slippage, on market fills) of filling an order, whose notional is expressed
+// as a ratio of the invested capital.
+func (p *ProfitCalculator) applyTradingCost(notional float64, isMarketFill bool, event common.SignalCheckOutputEvent, volume common.JsonFloat64) {
+	if p.input.DontApplyFees || notional <= 0 {
+		return
 	}
+	costRatio := feeRatio(p.input.MakerFeeRatio)
+	if isMarketFill {
+		costRatio = feeRatio(p.input.TakerFeeRatio) + p.calculateSlippageRatio(notional, float64(event.Price), volume)
+	}
+	p.tradingCost += notional * costRatio
+	p.logger.Printf("ProfitCalculator: filling notional %v at a cost ratio of %v (market fill = %v). Total trading cost = %v\n", notional, costRatio, isMarketFill, p.tradingCost)
+}
+
+// feeRatio is 0 for fee ratios that aren't set, i.e. on inputs that weren't validated.
+func feeRatio(ratio *common.JsonFloat64) float64 {
+	if ratio == nil {
+		return 0
+	}
+	return float64(*ratio)
+}
+
+func (p ProfitCalculator) calculateSlippageRatio(notional, price float64, volume common.JsonFloat64) float64 {
+	slippageRatio := float64(p.input.SlippageBps) / 10000
+	switch p.input.SlippageModel {
+	case common.SLIPPAGE_MODEL_FIXED:
+		return slippageRatio
+	case common.SLIPPAGE_MODEL_VOLUME:
+		quantity := notional * p.leverage() * float64(p.input.Capital) / price
+		if volume <= 0 || quantity >= float64(volume) {
+			return slippageRatio
+		}
+		return slippageRatio * quantity / float64(volume)
+	}
+	return 0
+}
+
+// ApplyEvent is like ApplyEventWithVolume, for when the volume of the candlestick of the event is unknown.
+func (p *ProfitCalculator) ApplyEvent(event common.SignalCheckOutputEvent) float64 {
+	return p.ApplyEventWithVolume(event, 0)
+}
+
+// ApplyEventWithVolume applies an event to the calculation, and returns the profit ratio up to this event. The volume
+// of the candlestick the event happened on is only used by the 'volume' slippage model.
+func (p *ProfitCalculator) ApplyEventWithVolume(event common.SignalCheckOutputEvent, volume common.JsonFloat64) float64 {
+	p.logger.Printf("ProfitCalculator: applying event '%v' with price %v\n", event.EventType, event.Price)
 	p.appliedEventCount++
 
 	switch event.EventType {
@@ -77,6 +127,9 @@ func (p *ProfitCalculator) ApplyEvent(event common.SignalCheckOutputEvent) float
 		// And calculating the difference between them:
 		enterWith := cumCurrentEntry - cumLastEntry
 
+		// Entering immediately (i.e. no entries) means buying at market.
+		p.applyTradingCost(enterWith, p.input.EntryCount() == 0, event, volume)
+
 		oldPositionSize := p.positionSize
 		newPositionSize := enterWith / float64(event.Price)
 		if p.positionSize == 0 {
@@ -89,30 +142,43 @@ func (p *ProfitCalculator) ApplyEvent(event common.SignalCheckOutputEvent) float
 		p.highestEntered = event.Target
 		p.ratioAwaitingEnter -= enterWith
 		p.positionSize = oldPositionSize + newPositionSize
-	case common.STOPPED_LOSS, common.INVALIDATED, common.FINISHED_DATASET:
+	case common.STOPPED_LOSS, common.TRAILING_STOPPED_LOSS, common.INVALIDATED, common.FINISHED_DATASET:
 		// Empty ratio awaiting enter, so that isFinished returns true
 		p.ratioOut += p.ratioAwaitingEnter
 		p.ratioAwaitingEnter = 0
 		p.updatePositionSize(event)
 		result := p.positionSize * p.entryPrice
+		p.applyTradingCost(result, true, event, volume)
 		if p.input.IsShort {
 			result *= -1
 		}
 		p.ratioOut += result
 		p.positionSize = 0
 
-		if p.positionSize == 0 && event.EventType == common.STOPPED_LOSS {
-			if p.input.Debug {
-				log.Println("ProfitCalculator: stopped loss without entering. This is likely a bug!")
-			}
+		if p.positionSize == 0 && (event.EventType == common.STOPPED_LOSS || event.EventType == common.TRAILING_STOPPED_LOSS) {
+			p.logger.Printf("ProfitCalculator: stopped loss without entering. This is likely a bug!")
 			break
 		}
 		if p.appliedEventCount == 1 {
-			if p.input.Debug {
-				log.Println("ProfitCalculator: invalidating at first event. Likely signal out-of-sync with data.")
-			}
+			p.logger.Printf("ProfitCalculator: invalidating at first event. Likely signal out-of-sync with data.")
+			break
+		}
+	case common.LIQUIDATED:
+		// Liquidation loses all the margin backing the position, so it closes at the bankruptcy price (i.e. the
+		// liquidation price without maintenance margin) rather than at the liquidation price.
+		bankruptcyPrice, ok := p.calculateLiquidationPrice(0)
+		if !ok {
+			p.logger.Printf("ProfitCalculator: liquidated without a leveraged position. This is likely a bug!")
 			break
 		}
+		p.ratioOut += p.ratioAwaitingEnter
+		p.ratioAwaitingEnter = 0
+		result := p.positionSize * p.entryPrice * bankruptcyPrice / p.lastPrice
+		if p.input.IsShort {
+			result *= -1
+		}
+		p.ratioOut += result
+		p.positionSize = 0
 	case common.TOOK_PROFIT:
 		// Once having taken profit, all balance awaiting enter should never enter again, so empty it
 		p.ratioOut += p.ratioAwaitingEnter
@@ -120,20 +186,17 @@ func (p *ProfitCalculator) ApplyEvent(event common.SignalCheckOutputEvent) float
 		p.updatePositionSize(event)
 
 		if p.positionSize == 0 {
-			if p.input.Debug {
-				log.Println("ProfitCalculator: took profit without entering. This is likely a bug!")
-			}
+			p.logger.Printf("ProfitCalculator: took profit without entering. This is likely a bug!")
 			break
 		}
 		if len(p.tpCumRatios)-1 < event.Target-1 {
-			if p.input.Debug {
-				log.Println("ProfitCalculator: took profit above existing take profit targets. This is likely a bug!")
-			}
+			p.logger.Printf("ProfitCalculator: took profit above existing take profit targets. This is likely a bug!")
 			break
 		}
 
 		ratioToTakeOut := p.positionSize * p.tpCumRatios[event.Target-1]
 		result := ratioToTakeOut * p.entryPrice
+		p.applyTradingCost(result, false, event, volume)
 		if p.input.IsShort {
 			result *= -1
 		}
@@ -141,40 +204,124 @@ func (p *ProfitCalculator) ApplyEvent(event common.SignalCheckOutputEvent) float
 		p.positionSize -= ratioToTakeOut
 		p.ratioOut += result
 	default:
-		if p.input.Debug {
-			log.Println("ProfitCalculator: found invalid event type. This is likely a bug!")
-		}
+		p.logger.Printf("ProfitCalculator: found invalid event type. This is likely a bug!")
 	}
 	p.lastEventType = event.EventType
 	p.lastPrice = float64(event.Price)
 	return p.CalculateTakeProfitRatio()
 }
 
+// EntryPrice is the average price at which the signal entered so far (0 if it did not enter).
+func (p ProfitCalculator) EntryPrice() float64 {
+	return p.entryPrice
+}
+
 func (p ProfitCalculator) IsFinished() bool {
 	return p.ratioAwaitingEnter+p.positionSize == 0.0
 }
 
 func (p ProfitCalculator) CalculateTakeProfitRatio() float64 {
+	if p.entryPrice == 0 {
+		return 0
+	}
+	tpr, resultIn := p.calculateTakeProfitRatio()
+	p.logger.Printf("ProfitCalculator: awaiting enter = %v, taken out = %v, position size = %v, entry price = %v (PS*EP = %v), trading cost = %v, funding = %v. Take profit ratio =  %v\n",
+		p.ratioAwaitingEnter, p.ratioOut, p.positionSize, p.entryPrice, resultIn, p.tradingCost, p.funding, tpr,
+	)
+	return tpr
+}
+
+func (p ProfitCalculator) calculateTakeProfitRatio() (float64, float64) {
 	base := float64(1)
 	if p.input.IsShort {
 		base *= -1
 	}
-	if p.entryPrice == 0 {
-		return 0
-	}
 	resultIn := p.positionSize * p.entryPrice
 	if p.input.IsShort {
 		resultIn *= -1
 	}
-	tpr := resultIn + p.ratioOut - base + p.ratioAwaitingEnter
-	if p.input.Debug {
-		log.Printf("ProfitCalculator: awaiting enter = %v, taken out = %v, position size = %v, entry price = %v (PS*EP = %v). Take profit ratio =  %v\n",
-			p.ratioAwaitingEnter, p.ratioOut, p.positionSize, p.entryPrice, resultIn, tpr,
-		)
+	return p.leverage() * (resultIn + p.ratioOut - base + p.ratioAwaitingEnter - p.tradingCost + p.funding), resultIn
+}
+
+// ProfitRatioAt is the profit ratio so far if the open position was valued at the given price, without the trading
+// costs of closing it.
+func (p ProfitCalculator) ProfitRatioAt(price float64) float64 {
+	if p.entryPrice == 0 {
+		return 0
+	}
+	if p.lastPrice != 0 {
+		p.positionSize *= price / p.lastPrice
 	}
+	tpr, _ := p.calculateTakeProfitRatio()
 	return tpr
 }
 
+// TradingCostRatio is the total spent on fees and slippage so far, as a ratio of the invested capital.
+func (p ProfitCalculator) TradingCostRatio() float64 {
+	return p.leverage() * p.tradingCost
+}
+
+// ApplyFundingRate applies a perpetual futures funding rate, settled at the given price, to the open position. Longs
+// pay shorts when the rate is positive, and shorts pay longs when it's negative.
+func (p *ProfitCalculator) ApplyFundingRate(rate, price float64) {
+	if p.positionSize == 0 || p.lastPrice == 0 {
+		return
+	}
+	payment := rate * p.positionSize * p.entryPrice * price / p.lastPrice
+	if !p.input.IsShort {
+		payment *= -1
+	}
+	p.funding += payment
+	p.logger.Printf("ProfitCalculator: applying funding rate %v at price %v. Total funding = %v\n", rate, price, p.funding)
+}
+
+// FundingRatio is the total funding received (or paid, if negative) so far, as a ratio of the invested capital.
+func (p ProfitCalculator) FundingRatio() float64 {
+	return p.leverage() * p.funding
+}
+
+// LiquidationPrice is the price at which the currently entered position would be liquidated. It returns false if
+// there is no leveraged position to liquidate.
+func (p ProfitCalculator) LiquidationPrice() (float64, bool) {
+	return p.calculateLiquidationPrice(float64(p.input.MaintenanceMarginRatio))
+}
+
+// calculateLiquidationPrice returns the price at which the margin backing the position equals the maintenance
+// margin. With a maintenanceMarginRatio of 0, this is the bankruptcy price.
+func (p ProfitCalculator) calculateLiquidationPrice(maintenanceMarginRatio float64) (float64, bool) {
+	leverage := p.leverage()
+	if leverage <= 1 || p.positionSize <= 0 || p.entryPrice == 0 || p.lastPrice == 0 {
+		return 0, false
+	}
+	side := 1.0
+	if p.input.IsShort {
+		side = -1.0
+	}
+	if p.input.MarginMode != common.MARGIN_MODE_CROSS {
+		return p.entryPrice * (1 - side*(1/leverage-maintenanceMarginRatio)), true
+	}
+	// On cross margin, the equity is all of the capital plus the leveraged profit ratio, which is linear on the price:
+	//
+	// equity(price) = 1 + leverage * (rest + side * value * price / lastPrice)
+	//
+	// where value is the position's value at the last price, and rest is the profit ratio not coming from the position.
+	// The liquidation price is where equity(price) = maintenanceMarginRatio * leverage * value * price / lastPrice.
+	value := p.positionSize * p.entryPrice
+	rest := p.ratioOut - side + p.ratioAwaitingEnter - p.tradingCost + p.funding
+	price := (1 + leverage*rest) * p.lastPrice / (leverage * value * (maintenanceMarginRatio - side))
+	if price < 0 {
+		price = 0
+	}
+	return price, true
+}
+
+func (p ProfitCalculator) leverage() float64 {
+	if p.input.Leverage < 1 {
+		return 1
+	}
+	return float64(p.input.Leverage)
+}
+
 func max(a, b int) int {
 	if a > b {
 		return a
@@ -1185,3 +1185,239 @@ func TestProfitCalculator(t *testing.T) {
 		})
 	}
 }
+
+func TestProfitCalculatorTradingCosts(t *testing.T) {
+	type test struct {
+		name                     string
+		input                    common.SignalCheckInput
+		events                   []common.SignalCheckOutputEvent
+		volumes                  []common.JsonFloat64
+		expectedProfitRatio      float64
+		expectedTradingCostRatio float64
+	}
+
+	tss := []test{
+		{
+			name: "maker fees on entering and taking profit",
+			input: common.SignalCheckInput{
+				Entries:          []common.JsonFloat64{10.0, 5.0},
+				EntryRatios:      []common.JsonFloat64{1.0},
+				TakeProfits:      []common.JsonFloat64{20.0},
+				TakeProfitRatios: []common.JsonFloat64{1.0},
+				MakerFeeRatio:    common.NewJsonFloat64(0.001),
+				TakerFeeRatio:    common.NewJsonFloat64(0.002),
+			},
+			events: []common.SignalCheckOutputEvent{
+				{EventType: common.ENTERED, Target: 1, Price: 10, At: "2020-01-02T03:04:05+00:00"},
+				{EventType: common.TOOK_PROFIT, Target: 1, Price: 20, At: "2020-01-02T04:04:05+00:00"},
+			},
+			volumes:                  []common.JsonFloat64{0, 0},
+			expectedProfitRatio:      0.997,
+			expectedTradingCostRatio: 0.003,
+		},
+		{
+			name: "taker fees on stopping loss",
+			input: common.SignalCheckInput{
+				Entries:       []common.JsonFloat64{10.0, 5.0},
+				EntryRatios:   []common.JsonFloat64{1.0},
+				StopLoss:      4,
+				MakerFeeRatio: common.NewJsonFloat64(0.001),
+				TakerFeeRatio: common.NewJsonFloat64(0.002),
+			},
+			events: []common.SignalCheckOutputEvent{
+				{EventType: common.ENTERED, Target: 1, Price: 10, At: "2020-01-02T03:04:05+00:00"},
+				{EventType: common.STOPPED_LOSS, Price: 5, At: "2020-01-02T04:04:05+00:00"},
+			},
+			volumes:                  []common.JsonFloat64{0, 0},
+			expectedProfitRatio:      -0.502,
+			expectedTradingCostRatio: 0.002,
+		},
+		{
+			name: "taker fees and fixed slippage on entering immediately and finishing dataset",
+			input: common.SignalCheckInput{
+				EntryRatios:   []common.JsonFloat64{1.0},
+				MakerFeeRatio: common.NewJsonFloat64(0.001),
+				TakerFeeRatio: common.NewJsonFloat64(0.002),
+				SlippageModel: common.SLIPPAGE_MODEL_FIXED,
+				SlippageBps:   10,
+			},
+			events: []common.SignalCheckOutputEvent{
+				{EventType: common.ENTERED, Target: 1, Price: 10, At: "2020-01-02T03:04:05+00:00"},
+				{EventType: common.FINISHED_DATASET, Price: 10, At: "2020-01-02T04:04:05+00:00"},
+			},
+			volumes:                  []common.JsonFloat64{0, 0},
+			expectedProfitRatio:      -0.006,
+			expectedTradingCostRatio: 0.006,
+		},
+		{
+			name: "volume slippage is relative to the candlestick's volume",
+			input: common.SignalCheckInput{
+				Entries:       []common.JsonFloat64{10.0, 5.0},
+				EntryRatios:   []common.JsonFloat64{1.0},
+				StopLoss:      4,
+				SlippageModel: common.SLIPPAGE_MODEL_VOLUME,
+				SlippageBps:   100,
+				Capital:       1000,
+			},
+			events: []common.SignalCheckOutputEvent{
+				{EventType: common.ENTERED, Target: 1, Price: 10, At: "2020-01-02T03:04:05+00:00"},
+				{EventType: common.STOPPED_LOSS, Price: 5, At: "2020-01-02T04:04:05+00:00"},
+			},
+			volumes:                  []common.JsonFloat64{1000, 1000},
+			expectedProfitRatio:      -0.5005,
+			expectedTradingCostRatio: 0.0005,
+		},
+		{
+			name: "volume slippage is capped when the fill exceeds the candlestick's volume",
+			input: common.SignalCheckInput{
+				Entries:       []common.JsonFloat64{10.0, 5.0},
+				EntryRatios:   []common.JsonFloat64{1.0},
+				StopLoss:      4,
+				SlippageModel: common.SLIPPAGE_MODEL_VOLUME,
+				SlippageBps:   100,
+				Capital:       1000,
+			},
+			events: []common.SignalCheckOutputEvent{
+				{EventType: common.ENTERED, Target: 1, Price: 10, At: "2020-01-02T03:04:05+00:00"},
+				{EventType: common.STOPPED_LOSS, Price: 5, At: "2020-01-02T04:04:05+00:00"},
+			},
+			volumes:                  []common.JsonFloat64{1, 1},
+			expectedProfitRatio:      -0.505,
+			expectedTradingCostRatio: 0.005,
+		},
+		{
+			name: "no trading costs when fees are not applied",
+			input: common.SignalCheckInput{
+				Entries:       []common.JsonFloat64{10.0, 5.0},
+				EntryRatios:   []common.JsonFloat64{1.0},
+				StopLoss:      4,
+				MakerFeeRatio: common.NewJsonFloat64(0.001),
+				TakerFeeRatio: common.NewJsonFloat64(0.002),
+				SlippageModel: common.SLIPPAGE_MODEL_FIXED,
+				SlippageBps:   10,
+				DontApplyFees: true,
+			},
+			events: []common.SignalCheckOutputEvent{
+				{EventType: common.ENTERED, Target: 1, Price: 10, At: "2020-01-02T03:04:05+00:00"},
+				{EventType: common.STOPPED_LOSS, Price: 5, At: "2020-01-02T04:04:05+00:00"},
+			},
+			volumes:                  []common.JsonFloat64{0, 0},
+			expectedProfitRatio:      -0.5,
+			expectedTradingCostRatio: 0,
+		},
+	}
+	for _, ts := range tss {
+		t.Run(ts.name, func(t *testing.T) {
+			profitCalculator := NewProfitCalculator(ts.input)
+			for i, ev := range ts.events {
+				profitCalculator.ApplyEventWithVolume(ev, ts.volumes[i])
+			}
+			actual := profitCalculator.CalculateTakeProfitRatio()
+			if math.Abs(actual-ts.expectedProfitRatio) > 1e-12 {
+				t.Fatalf("expected profit ratio %v to equal %v", actual, ts.expectedProfitRatio)
+			}
+			actualTradingCostRatio := profitCalculator.TradingCostRatio()
+			if math.Abs(actualTradingCostRatio-ts.expectedTradingCostRatio) > 1e-12 {
+				t.Fatalf("expected trading cost ratio %v to equal %v", actualTradingCostRatio, ts.expectedTradingCostRatio)
+			}
+		})
+	}
+}
+
+func TestLiquidationPrice(t *testing.T) {
+	type test struct {
+		name                     string
+		input                    common.SignalCheckInput
+		events                   []common.SignalCheckOutputEvent
+		expectedLiquidationPrice float64
+		expectedOk               bool
+	}
+
+	tss := []test{
+		{
+			name: "no liquidation without leverage",
+			input: common.SignalCheckInput{
+				Entries:     []common.JsonFloat64{10.0, 5.0},
+				EntryRatios: []common.JsonFloat64{1.0},
+			},
+			events: []common.SignalCheckOutputEvent{
+				{EventType: common.ENTERED, Target: 1, Price: 10, At: "2020-01-02T03:04:05+00:00"},
+			},
+			expectedOk: false,
+		},
+		{
+			name: "isolated long",
+			input: common.SignalCheckInput{
+				Entries:     []common.JsonFloat64{10.0, 5.0},
+				EntryRatios: []common.JsonFloat64{1.0},
+				Leverage:    10,
+				MarginMode:  common.MARGIN_MODE_ISOLATED,
+			},
+			events: []common.SignalCheckOutputEvent{
+				{EventType: common.ENTERED, Target: 1, Price: 10, At: "2020-01-02T03:04:05+00:00"},
+			},
+			expectedLiquidationPrice: 9,
+			expectedOk:               true,
+		},
+		{
+			name: "isolated short with maintenance margin",
+			input: common.SignalCheckInput{
+				IsShort:                true,
+				Entries:                []common.JsonFloat64{10.0, 20.0},
+				EntryRatios:            []common.JsonFloat64{1.0},
+				Leverage:               10,
+				MarginMode:             common.MARGIN_MODE_ISOLATED,
+				MaintenanceMarginRatio: 0.004,
+			},
+			events: []common.SignalCheckOutputEvent{
+				{EventType: common.ENTERED, Target: 1, Price: 10, At: "2020-01-02T03:04:05+00:00"},
+			},
+			expectedLiquidationPrice: 10.96,
+			expectedOk:               true,
+		},
+		{
+			name: "cross short fully entered is like isolated",
+			input: common.SignalCheckInput{
+				IsShort:     true,
+				Entries:     []common.JsonFloat64{10.0, 20.0},
+				EntryRatios: []common.JsonFloat64{1.0},
+				Leverage:    2,
+				MarginMode:  common.MARGIN_MODE_CROSS,
+			},
+			events: []common.SignalCheckOutputEvent{
+				{EventType: common.ENTERED, Target: 1, Price: 10, At: "2020-01-02T03:04:05+00:00"},
+			},
+			expectedLiquidationPrice: 15,
+			expectedOk:               true,
+		},
+		{
+			name: "cross long backed by the capital awaiting entry",
+			input: common.SignalCheckInput{
+				Entries:     []common.JsonFloat64{10.0, 5.0},
+				EntryRatios: []common.JsonFloat64{0.5, 0.5},
+				Leverage:    4,
+				MarginMode:  common.MARGIN_MODE_CROSS,
+			},
+			events: []common.SignalCheckOutputEvent{
+				{EventType: common.ENTERED, Target: 1, Price: 10, At: "2020-01-02T03:04:05+00:00"},
+			},
+			expectedLiquidationPrice: 5,
+			expectedOk:               true,
+		},
+	}
+	for _, ts := range tss {
+		t.Run(ts.name, func(t *testing.T) {
+			profitCalculator := NewProfitCalculator(ts.input)
+			for _, ev := range ts.events {
+				profitCalculator.ApplyEvent(ev)
+			}
+			actual, ok := profitCalculator.LiquidationPrice()
+			if ok != ts.expectedOk {
+				t.Fatalf("expected ok %v to equal %v", ok, ts.expectedOk)
+			}
+			if math.Abs(actual-ts.expectedLiquidationPrice) > 1e-12 {
+				t.Fatalf("expected liquidation price %v to equal %v", actual, ts.expectedLiquidationPrice)
+			}
+		})
+	}
+}
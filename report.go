@@ -0,0 +1,38 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/marianogappa/signal-checker/common"
+)
+
+// readOutputs reads one JSON output per line, e.g. the output of a batch. Empty lines and batch summaries are skipped.
+func readOutputs(r io.Reader) ([]common.SignalCheckOutput, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxBatchLineBytes)
+	outputs := []common.SignalCheckOutput{}
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var summary struct {
+			Summary *batchSummary `json:"summary"`
+		}
+		if err := json.Unmarshal(line, &summary); err == nil && summary.Summary != nil {
+			continue
+		}
+		output := common.SignalCheckOutput{}
+		if err := json.Unmarshal(line, &output); err != nil {
+			return nil, fmt.Errorf("line %v: invalid output: %v", lineNumber, err)
+		}
+		outputs = append(outputs, output)
+	}
+	return outputs, scanner.Err()
+}
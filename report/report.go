@@ -0,0 +1,215 @@
+// The report package aggregates the outputs of many signal checks into performance reports, to fact-check the
+// providers that published the signals.
+//
+// Use it like this: reports := report.Build(outputs)
+package report
+
+import (
+	"math"
+	"sort"
+
+	"github.com/marianogappa/signal-checker/common"
+)
+
+// Reports is the result of aggregating signal check outputs.
+type Reports struct {
+	// Overall aggregates all outputs.
+	Overall Report `json:"overall"`
+
+	// ByProvider aggregates outputs by their input's provider, sorted by provider. Outputs without a provider are
+	// grouped under an empty provider. It's empty if no output has a provider.
+	ByProvider []Report `json:"byProvider,omitempty"`
+}
+
+// Report is the performance of a group of signals.
+//
+// Rates are ratios between 0 and 1. Unless otherwise noted, rates and profit ratios only consider the signals that
+// entered, as the ones that didn't never made or lost anything.
+type Report struct {
+	// Provider is the input's provider that this report is about, if grouped by provider.
+	Provider string `json:"provider,omitempty"`
+
+	// Signals is the number of checked signals, excluding errors.
+	Signals int `json:"signals"`
+
+	// Errors is the number of signals that couldn't be checked. They are excluded from all other values.
+	Errors int `json:"errors"`
+
+	// Entered is the number of signals that entered.
+	Entered int `json:"entered"`
+
+	// WinRate is the ratio of entered signals with a positive profit ratio.
+	WinRate common.JsonFloat64 `json:"winRate"`
+
+	// TakeProfitHitRates is, for each take profit level (e.g. TP1 first), the ratio of entered signals that reached
+	// it, among the entered signals that had that many take profits.
+	TakeProfitHitRates []common.JsonFloat64 `json:"takeProfitHitRates"`
+
+	// StopLossRate is the ratio of entered signals that reached their stop loss (including trailing ones).
+	StopLossRate common.JsonFloat64 `json:"stopLossRate"`
+
+	// NeverEnteredRate is the ratio of all checked signals that never entered.
+	NeverEnteredRate common.JsonFloat64 `json:"neverEnteredRate"`
+
+	// AverageProfitRatio is the average profit ratio of entered signals.
+	AverageProfitRatio common.JsonFloat64 `json:"averageProfitRatio"`
+
+	// MedianProfitRatio is the median profit ratio of entered signals.
+	MedianProfitRatio common.JsonFloat64 `json:"medianProfitRatio"`
+
+	// TotalProfitRatio is the sum of the profit ratios of entered signals, i.e. the profit of investing the same
+	// amount on each of them.
+	TotalProfitRatio common.JsonFloat64 `json:"totalProfitRatio"`
+
+	// Expectancy is the expected profit ratio of following an entered signal, i.e. the win rate times the average
+	// win, minus the loss rate times the average loss.
+	Expectancy common.JsonFloat64 `json:"expectancy"`
+
+	// ProfitFactor is the sum of the profits of winning signals divided by the sum of the losses of losing signals.
+	// It's 0 when there are no losing signals, as it's undefined.
+	ProfitFactor common.JsonFloat64 `json:"profitFactor"`
+
+	// MaxConsecutiveLosses is the longest run of losing entered signals, sorted by their initial time.
+	MaxConsecutiveLosses int `json:"maxConsecutiveLosses"`
+}
+
+// Build aggregates the outputs into an overall report, and into a report per provider.
+func Build(outputs []common.SignalCheckOutput) Reports {
+	reports := Reports{Overall: BuildReport(outputs)}
+
+	byProvider := map[string][]common.SignalCheckOutput{}
+	hasProvider := false
+	for _, output := range outputs {
+		byProvider[output.Input.Provider] = append(byProvider[output.Input.Provider], output)
+		if output.Input.Provider != "" {
+			hasProvider = true
+		}
+	}
+	if !hasProvider {
+		return reports
+	}
+	providers := []string{}
+	for provider := range byProvider {
+		providers = append(providers, provider)
+	}
+	sort.Strings(providers)
+	for _, provider := range providers {
+		report := BuildReport(byProvider[provider])
+		report.Provider = provider
+		reports.ByProvider = append(reports.ByProvider, report)
+	}
+	return reports
+}
+
+// BuildReport aggregates the outputs into a single report, regardless of their provider.
+func BuildReport(outputs []common.SignalCheckOutput) Report {
+	report := Report{TakeProfitHitRates: []common.JsonFloat64{}}
+	entered := []common.SignalCheckOutput{}
+	for _, output := range outputs {
+		if output.IsError {
+			report.Errors++
+			continue
+		}
+		report.Signals++
+		if output.Entered {
+			entered = append(entered, output)
+		}
+	}
+	report.Entered = len(entered)
+	if report.Signals > 0 {
+		report.NeverEnteredRate = common.JsonFloat64(float64(report.Signals-report.Entered) / float64(report.Signals))
+	}
+	if len(entered) == 0 {
+		return report
+	}
+
+	var (
+		wins, losses             int
+		totalWins, totalLosses   float64
+		stoppedLoss              int
+		profitRatios             = []float64{}
+		takeProfitHits, tpTotals = []int{}, []int{}
+	)
+	for _, output := range entered {
+		profitRatio := float64(output.ProfitRatio)
+		profitRatios = append(profitRatios, profitRatio)
+		report.TotalProfitRatio += output.ProfitRatio
+		if profitRatio > 0 {
+			wins++
+			totalWins += profitRatio
+		}
+		if profitRatio < 0 {
+			losses++
+			totalLosses -= profitRatio
+		}
+		if output.ReachedStopLoss {
+			stoppedLoss++
+		}
+		for len(tpTotals) < len(output.Input.TakeProfits) {
+			takeProfitHits, tpTotals = append(takeProfitHits, 0), append(tpTotals, 0)
+		}
+		for i := range output.Input.TakeProfits {
+			tpTotals[i]++
+			if output.HighestTakeProfit > i {
+				takeProfitHits[i]++
+			}
+		}
+	}
+
+	count := float64(len(entered))
+	report.WinRate = common.JsonFloat64(float64(wins) / count)
+	report.StopLossRate = common.JsonFloat64(float64(stoppedLoss) / count)
+	for i := range tpTotals {
+		report.TakeProfitHitRates = append(report.TakeProfitHitRates, common.JsonFloat64(float64(takeProfitHits[i])/float64(tpTotals[i])))
+	}
+	report.AverageProfitRatio = common.JsonFloat64(float64(report.TotalProfitRatio) / count)
+	report.MedianProfitRatio = common.JsonFloat64(median(profitRatios))
+	averageWin, averageLoss := 0.0, 0.0
+	if wins > 0 {
+		averageWin = totalWins / float64(wins)
+	}
+	if losses > 0 {
+		averageLoss = totalLosses / float64(losses)
+		report.ProfitFactor = common.JsonFloat64(totalWins / totalLosses)
+	}
+	report.Expectancy = common.JsonFloat64(float64(wins)/count*averageWin - float64(losses)/count*averageLoss)
+	report.MaxConsecutiveLosses = maxConsecutiveLosses(entered)
+	return report
+}
+
+func median(fs []float64) float64 {
+	sorted := append([]float64{}, fs...)
+	sort.Float64s(sorted)
+	middle := len(sorted) / 2
+	if len(sorted)%2 == 1 {
+		return sorted[middle]
+	}
+	return (sorted[middle-1] + sorted[middle]) / 2
+}
+
+func maxConsecutiveLosses(outputs []common.SignalCheckOutput) int {
+	sorted := append([]common.SignalCheckOutput{}, outputs...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return initialSeconds(sorted[i]) < initialSeconds(sorted[j])
+	})
+	max, current := 0, 0
+	for _, output := range sorted {
+		if output.ProfitRatio >= 0 {
+			current = 0
+			continue
+		}
+		current++
+		if current > max {
+			max = current
+		}
+	}
+	return max
+}
+
+func initialSeconds(output common.SignalCheckOutput) int {
+	seconds, err := output.Input.InitialISO8601.Seconds()
+	if err != nil {
+		return math.MinInt32
+	}
+	return seconds
+}
@@ -0,0 +1,144 @@
+package report
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/marianogappa/signal-checker/common"
+)
+
+func output(provider string, initial common.ISO8601, takeProfits int, entered bool, highestTakeProfit int, reachedStopLoss bool, profitRatio float64) common.SignalCheckOutput {
+	input := common.SignalCheckInput{Provider: provider, InitialISO8601: initial}
+	for i := 0; i < takeProfits; i++ {
+		input.TakeProfits = append(input.TakeProfits, common.JsonFloat64(i+1))
+	}
+	return common.SignalCheckOutput{
+		Input:             input,
+		Entered:           entered,
+		HighestTakeProfit: highestTakeProfit,
+		ReachedStopLoss:   reachedStopLoss,
+		ProfitRatio:       common.JsonFloat64(profitRatio),
+	}
+}
+
+func TestBuildReport(t *testing.T) {
+	type test struct {
+		name     string
+		outputs  []common.SignalCheckOutput
+		expected Report
+	}
+	tss := []test{
+		{
+			name:     "No outputs",
+			outputs:  []common.SignalCheckOutput{},
+			expected: Report{TakeProfitHitRates: []common.JsonFloat64{}},
+		},
+		{
+			name: "Never entered and errors",
+			outputs: []common.SignalCheckOutput{
+				output("", "2021-07-04T14:14:18Z", 2, false, 0, false, 0),
+				{IsError: true},
+			},
+			expected: Report{Signals: 1, Errors: 1, NeverEnteredRate: 1, TakeProfitHitRates: []common.JsonFloat64{}},
+		},
+		{
+			name: "Mixed results",
+			outputs: []common.SignalCheckOutput{
+				output("", "2021-07-01T00:00:00Z", 2, true, 2, false, 0.5),
+				// Out of order, to check that consecutive losses are sorted by initial time.
+				output("", "2021-07-04T00:00:00Z", 2, true, 0, true, -0.1),
+				output("", "2021-07-02T00:00:00Z", 3, true, 1, true, -0.2),
+				output("", "2021-07-03T00:00:00Z", 1, true, 0, false, -0.3),
+				output("", "2021-07-05T00:00:00Z", 2, true, 1, false, 0.1),
+				output("", "2021-07-06T00:00:00Z", 2, false, 0, false, 0),
+			},
+			expected: Report{
+				Signals:              6,
+				Entered:              5,
+				WinRate:              0.4,
+				TakeProfitHitRates:   []common.JsonFloat64{0.6, 0.25, 0},
+				StopLossRate:         0.4,
+				NeverEnteredRate:     common.JsonFloat64(1.0 / 6),
+				AverageProfitRatio:   0,
+				MedianProfitRatio:    -0.1,
+				TotalProfitRatio:     0,
+				Expectancy:           0,
+				ProfitFactor:         1,
+				MaxConsecutiveLosses: 3,
+			},
+		},
+		{
+			name: "Only wins",
+			outputs: []common.SignalCheckOutput{
+				output("", "2021-07-01T00:00:00Z", 1, true, 1, false, 0.1),
+				output("", "2021-07-02T00:00:00Z", 1, true, 1, false, 0.3),
+			},
+			expected: Report{
+				Signals:            2,
+				Entered:            2,
+				WinRate:            1,
+				TakeProfitHitRates: []common.JsonFloat64{1},
+				AverageProfitRatio: 0.2,
+				MedianProfitRatio:  0.2,
+				TotalProfitRatio:   0.4,
+				Expectancy:         0.2,
+			},
+		},
+	}
+	for _, ts := range tss {
+		t.Run(ts.name, func(t *testing.T) {
+			actual := BuildReport(ts.outputs)
+			roundReport(&actual)
+			roundReport(&ts.expected)
+			if !reflect.DeepEqual(ts.expected, actual) {
+				t.Fatalf("expected %+v but got %+v", ts.expected, actual)
+			}
+		})
+	}
+}
+
+func TestBuildGroupsByProvider(t *testing.T) {
+	outputs := []common.SignalCheckOutput{
+		output("b", "2021-07-01T00:00:00Z", 1, true, 1, false, 0.1),
+		output("a", "2021-07-02T00:00:00Z", 1, true, 0, true, -0.1),
+		output("", "2021-07-03T00:00:00Z", 1, false, 0, false, 0),
+		output("b", "2021-07-04T00:00:00Z", 1, true, 1, false, 0.3),
+	}
+	reports := Build(outputs)
+	if reports.Overall.Signals != 4 {
+		t.Fatalf("expected 4 signals overall but got %v", reports.Overall.Signals)
+	}
+	providers := []string{}
+	signals := []int{}
+	for _, report := range reports.ByProvider {
+		providers = append(providers, report.Provider)
+		signals = append(signals, report.Signals)
+	}
+	if !reflect.DeepEqual(providers, []string{"", "a", "b"}) || !reflect.DeepEqual(signals, []int{1, 1, 2}) {
+		t.Fatalf("expected providers ['', 'a', 'b'] with 1, 1 and 2 signals, but got %v with %v", providers, signals)
+	}
+
+	if reports := Build(outputs[2:3]); reports.ByProvider != nil {
+		t.Fatalf("expected no reports by provider when no output has one, but got %+v", reports.ByProvider)
+	}
+}
+
+// roundReport rounds the floats in the report, so that they can be compared.
+func roundReport(r *Report) {
+	round := func(f *common.JsonFloat64) {
+		*f = common.JsonFloat64(float64(int64(float64(*f)*1e9+0.5*sign(float64(*f)))) / 1e9)
+	}
+	for i := range r.TakeProfitHitRates {
+		round(&r.TakeProfitHitRates[i])
+	}
+	for _, f := range []*common.JsonFloat64{&r.WinRate, &r.StopLossRate, &r.NeverEnteredRate, &r.AverageProfitRatio, &r.MedianProfitRatio, &r.TotalProfitRatio, &r.Expectancy, &r.ProfitFactor} {
+		round(f)
+	}
+}
+
+func sign(f float64) float64 {
+	if f < 0 {
+		return -1
+	}
+	return 1
+}
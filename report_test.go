@@ -0,0 +1,25 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReadOutputs(t *testing.T) {
+	batchOutput := `{"input":{"provider":"a"},"entered":true,"profitRatio":0.5}
+
+{"isError":true,"httpStatus":400,"errorMessage":"line 2: base asset is required (e.g. BTC)"}
+{"summary":{"total":2,"errors":1,"entered":1,"tookProfit":0,"stoppedLoss":0,"liquidated":0}}
+`
+	outputs, err := readOutputs(strings.NewReader(batchOutput))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(outputs) != 2 || outputs[0].Input.Provider != "a" || !outputs[1].IsError {
+		t.Fatalf("expected the two outputs without the summary, but got %+v", outputs)
+	}
+
+	if _, err := readOutputs(strings.NewReader("{\n")); err == nil {
+		t.Fatal("expected an error on an invalid output")
+	}
+}
@@ -1,8 +1,8 @@
 package signalchecker
 
 import (
+	"context"
 	"errors"
-	"log"
 
 	"github.com/marianogappa/signal-checker/common"
 )
@@ -16,24 +16,22 @@ func getEnteredEvent(events []common.SignalCheckOutputEvent) (common.SignalCheck
 	return common.SignalCheckOutputEvent{}, false
 }
 
-func calculateMaxEnterUSD(exchange common.Exchange, input common.SignalCheckInput, events []common.SignalCheckOutputEvent) (common.JsonFloat64, error) {
+func calculateMaxEnterUSD(ctx context.Context, exchange common.Exchange, input common.SignalCheckInput, events []common.SignalCheckOutputEvent) (common.JsonFloat64, error) {
 	enteredEvent, ok := getEnteredEvent(events)
 	if !ok {
 		return common.JsonFloat64(0.0), errors.New("this signal did not enter so cannot calculate maxEnterUSD")
 	}
-	usdPricePerBaseAsset, err := common.GetUSDPricePerBaseAssetUnitAtEvent(exchange, input, enteredEvent)
+	usdPricePerBaseAsset, err := common.GetUSDPricePerBaseAssetUnitAtEvent(ctx, exchange, input, enteredEvent)
 	if err != nil {
 		return common.JsonFloat64(0.0), err
 	}
-	tradeIterator := exchange.BuildTradeIterator(input.BaseAsset, input.QuoteAsset, enteredEvent.At)
+	tradeIterator := exchange.BuildTradeIterator(ctx, input.BaseAsset, input.QuoteAsset, enteredEvent.At)
 	maxTrade, err := tradeIterator.GetMaxBaseAssetEnter(5 /* minuteCount */, 10 /* bucketCount */, 10000 /* maxTradeCount */)
 	if err != nil {
 		return common.JsonFloat64(0.0), err
 	}
 	maxEnterUSD := usdPricePerBaseAsset * maxTrade.BaseAssetQuantity
-	if input.Debug {
-		log.Printf("calculateMaxEnterUSD: best-ish quantity trade was %v units of %v/%v at a price of %.6f (but entered price was %.6f!!), which is a USD price of ~$%.6f per unit, totalling ~$%.6f\n",
-			maxTrade.BaseAssetQuantity, input.BaseAsset, input.QuoteAsset, maxTrade.BaseAssetPrice, enteredEvent.Price, usdPricePerBaseAsset, maxEnterUSD)
-	}
+	common.LoggerFrom(ctx, input.Debug).Printf("calculateMaxEnterUSD: best-ish quantity trade was %v units of %v/%v at a price of %.6f (but entered price was %.6f!!), which is a USD price of ~$%.6f per unit, totalling ~$%.6f\n",
+		maxTrade.BaseAssetQuantity, input.BaseAsset, input.QuoteAsset, maxTrade.BaseAssetPrice, enteredEvent.Price, usdPricePerBaseAsset, maxEnterUSD)
 	return maxEnterUSD, nil
 }
@@ -0,0 +1,80 @@
+package signalchecker
+
+import (
+	"time"
+
+	"github.com/marianogappa/signal-checker/common"
+)
+
+// excursion tracks how the signal's profit ratio moved while entered, between its events.
+type excursion struct {
+	entered           bool
+	firstEntryAt      int
+	maxFavourable     float64
+	maxAdverse        float64
+	peakEquity        float64
+	maxDrawdown       float64
+	equityCurve       []common.EquityCurvePoint
+	lastSampleAt      int
+	trackedEventCount int
+}
+
+// trackExcursion values the position at the tick's price, after the tick was applied.
+func (s *checkSignalState) trackExcursion(tick common.Tick) {
+	if s.highestEntry == 0 || tick.Timestamp < int(s.initialTime.Unix()) {
+		return
+	}
+	e := &s.excursion
+	newEvents := s.events[e.trackedEventCount:]
+	e.trackedEventCount = len(s.events)
+
+	profitRatio := s.profitCalculator.ProfitRatioAt(float64(tick.Price))
+	if !e.entered {
+		e.entered = true
+		e.firstEntryAt = tick.Timestamp
+		e.peakEquity = 1
+		e.lastSampleAt = tick.Timestamp
+	}
+	if profitRatio > e.maxFavourable {
+		e.maxFavourable = profitRatio
+	}
+	if profitRatio < e.maxAdverse {
+		e.maxAdverse = profitRatio
+	}
+	equity := 1 + profitRatio
+	if equity > e.peakEquity {
+		e.peakEquity = equity
+	}
+	if e.peakEquity > 0 && (e.peakEquity-equity)/e.peakEquity > e.maxDrawdown {
+		e.maxDrawdown = (e.peakEquity - equity) / e.peakEquity
+	}
+
+	if !s.input.ReturnEquityCurve {
+		return
+	}
+	for _, event := range newEvents {
+		e.equityCurve = append(e.equityCurve, common.EquityCurvePoint{At: event.At, ProfitRatio: event.ProfitRatio})
+	}
+	if len(newEvents) == 0 && tick.Timestamp >= e.lastSampleAt+s.input.EquityCurveSampleSeconds {
+		at := common.ISO8601(time.Unix(int64(tick.Timestamp), 0).UTC().Format(time.RFC3339))
+		e.equityCurve = append(e.equityCurve, common.EquityCurvePoint{At: at, ProfitRatio: common.JsonFloat64(profitRatio)})
+		e.lastSampleAt = tick.Timestamp
+	}
+}
+
+// setExcursionOutput sets the excursion metrics on the output, if the signal entered.
+func (s *checkSignalState) setExcursionOutput(output *common.SignalCheckOutput) {
+	e := s.excursion
+	if !e.entered {
+		return
+	}
+	output.MaxFavourableExcursionRatio = common.JsonFloat64(e.maxFavourable)
+	output.MaxAdverseExcursionRatio = common.JsonFloat64(e.maxAdverse)
+	output.MaxDrawdownRatio = common.JsonFloat64(e.maxDrawdown)
+	output.TimeToFirstEntrySeconds = e.firstEntryAt - int(s.initialTime.Unix())
+	if len(s.events) > 0 {
+		lastEventAt, _ := s.events[len(s.events)-1].At.Seconds()
+		output.TimeInPositionSeconds = lastEventAt - e.firstEntryAt
+	}
+	output.EquityCurve = e.equityCurve
+}
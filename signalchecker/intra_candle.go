@@ -0,0 +1,124 @@
+package signalchecker
+
+import (
+	"time"
+
+	"github.com/marianogappa/signal-checker/common"
+)
+
+// candlestickDurationSeconds is the duration of the candlesticks requested to the exchanges, i.e. one minute.
+const candlestickDurationSeconds = 60
+
+// candlestickToTicks converts a candlestick to the ticks the checker applies, in the order that the
+// IntraCandleResolution decides. It's only called when the previous candlestick's ticks are applied, so the state is
+// up to date.
+func (s *checkSignalState) candlestickToTicks(candlestick common.Candlestick) []common.Tick {
+	ticks := candlestick.ToTicks()
+	ambiguous := s.isAmbiguous(candlestick)
+	if ambiguous && s.input.IntraCandleResolution == common.INTRA_CANDLE_RESOLUTION_EXACT {
+		tradeTicks, ok := s.tradesToTicks(candlestick)
+		if ok {
+			for i := range tradeTicks {
+				tradeTicks[i].Ambiguous = true
+			}
+			return tradeTicks
+		}
+	}
+
+	// ToTicks puts the lowest price first, which is pessimistic for LONG and optimistic for SHORT. It's kept as is for
+	// low_first.
+	switch s.input.IntraCandleResolution {
+	case common.INTRA_CANDLE_RESOLUTION_PESSIMISTIC, common.INTRA_CANDLE_RESOLUTION_EXACT:
+		if s.input.IsShort {
+			ticks[0], ticks[1] = ticks[1], ticks[0]
+		}
+	case common.INTRA_CANDLE_RESOLUTION_OPTIMISTIC:
+		if !s.input.IsShort {
+			ticks[0], ticks[1] = ticks[1], ticks[0]
+		}
+	}
+	for i := range ticks {
+		ticks[i].Ambiguous = ambiguous
+	}
+	return ticks
+}
+
+// isAmbiguous answers if the candlestick touched more than one of the levels that trigger events (e.g. the next
+// entry and the stop loss, or the stop loss and the next take profit), so that it's unknown which happened first.
+// These mirror applyTick's conditions.
+func (s *checkSignalState) isAmbiguous(candlestick common.Candlestick) bool {
+	var (
+		low, high = candlestick.LowestPrice, candlestick.HighestPrice
+		entries   = s.input.Entries
+		tps       = s.input.TakeProfits
+		isShort   = s.input.IsShort
+		h         = s.highestEntry
+		touched   = 0
+	)
+	reachesTakeProfit := func(takeProfit common.JsonFloat64) bool {
+		return (!isShort && high >= takeProfit) || (isShort && low <= takeProfit)
+	}
+
+	entering := len(entries) >= h+2 && ((!isShort && high >= entries[h+1] && low < entries[h]) || (isShort && high > entries[h] && low <= entries[h+1]))
+	if entering {
+		touched++
+	}
+	invalidating := s.input.InvalidateIfTPBeforeEntering && h == 0 && len(entries) > 0 && len(tps) > 0 && reachesTakeProfit(tps[0])
+	if invalidating {
+		touched++
+	}
+	// The stop loss, liquidation and take profits only matter once entered, which may happen on this candlestick.
+	if h == 0 && !entering {
+		return touched > 1
+	}
+	stopping := (!isShort && low <= s.stopLoss) || (isShort && s.stopLoss != -1 && high >= s.stopLoss)
+	if liquidationPrice, ok := s.profitCalculator.LiquidationPrice(); ok && h > 0 &&
+		(s.isLiquidatedAt(low, common.JsonFloat64(liquidationPrice)) || s.isLiquidatedAt(high, common.JsonFloat64(liquidationPrice))) {
+		stopping = true
+	}
+	if stopping {
+		touched++
+	}
+	if !invalidating && s.highestTakeProfit < len(tps) && reachesTakeProfit(tps[s.highestTakeProfit]) {
+		touched++
+	}
+	return touched > 1
+}
+
+// tradesToTicks converts the exchange's trades during the candlestick into ticks. It returns false if the trades
+// can't be fetched.
+func (s *checkSignalState) tradesToTicks(candlestick common.Candlestick) ([]common.Tick, bool) {
+	if s.exchange == nil || !common.CapabilitiesOf(s.exchange).Trades {
+		return nil, false
+	}
+	initialISO8601 := common.ISO8601(time.Unix(int64(candlestick.Timestamp), 0).UTC().Format(time.RFC3339))
+	tradeIterator := s.exchange.BuildTradeIterator(s.ctx, s.input.BaseAsset, s.input.QuoteAsset, initialISO8601)
+	ticks := []common.Tick{}
+	for {
+		trade, err := tradeIterator.Next()
+		if err == common.ErrOutOfTrades {
+			break
+		}
+		if err != nil {
+			s.logger.Printf("Checker: couldn't fetch trades to resolve ambiguous candlestick at %v: %v\n", initialISO8601, err)
+			return nil, false
+		}
+		if trade.Timestamp < candlestick.Timestamp {
+			continue
+		}
+		if trade.Timestamp >= candlestick.Timestamp+candlestickDurationSeconds {
+			break
+		}
+		ticks = append(ticks, common.Tick{
+			Timestamp:      trade.Timestamp,
+			Price:          trade.BaseAssetPrice,
+			Volume:         candlestick.Volume,
+			NumberOfTrades: candlestick.NumberOfTrades,
+		})
+	}
+	if len(ticks) == 0 {
+		s.logger.Printf("Checker: no trades to resolve ambiguous candlestick at %v\n", initialISO8601)
+		return nil, false
+	}
+	return ticks, true
+}
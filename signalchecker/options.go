@@ -0,0 +1,49 @@
+package signalchecker
+
+import "github.com/marianogappa/signal-checker/common"
+
+// Option configures a SignalChecker. Pass options to NewSignalChecker.
+type Option func(*SignalChecker)
+
+// WithCandlestickCache stores the candlesticks fetched from exchanges under dir, so that checking the same exchange,
+// pair and time range again is served from disk, and only missing gaps are fetched.
+func WithCandlestickCache(dir string) Option {
+	return func(c *SignalChecker) {
+		c.cacheDir = dir
+	}
+}
+
+// WithHTTPRecording stores every HTTP response of the supported exchanges under dir, so that the same checks can be
+// replayed later without network access using WithHTTPReplay, e.g. to turn a disputed check into a regression test.
+func WithHTTPRecording(dir string) Option {
+	recording := newRecording(dir, false)
+	return func(c *SignalChecker) {
+		c.recording = recording
+	}
+}
+
+// WithHTTPReplay serves the HTTP responses of the supported exchanges from the ones stored under dir by
+// WithHTTPRecording, without network access. Requests that were not recorded fail with an error wrapping
+// httpclient.ErrFixtureNotFound.
+func WithHTTPReplay(dir string) Option {
+	recording := newRecording(dir, true)
+	return func(c *SignalChecker) {
+		c.recording = recording
+	}
+}
+
+// WithProgress calls onProgress after each candlestick the check processes, e.g. to report the progress of long
+// checks. It's called from the goroutine doing the check, so it should return quickly.
+func WithProgress(onProgress func(Progress)) Option {
+	return func(c *SignalChecker) {
+		c.onProgress = onProgress
+	}
+}
+
+// WithEvents calls onEvent with each event of the check as soon as it happens, e.g. to stream them to a client. It's
+// called from the goroutine doing the check, so it should return quickly.
+func WithEvents(onEvent func(common.SignalCheckOutputEvent)) Option {
+	return func(c *SignalChecker) {
+		c.onEvent = onEvent
+	}
+}
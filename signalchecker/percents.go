@@ -0,0 +1,45 @@
+package signalchecker
+
+import (
+	"github.com/marianogappa/signal-checker/common"
+)
+
+// resolvePercent is the price that is percent away from the reference price.
+func resolvePercent(reference, percent common.JsonFloat64) common.JsonFloat64 {
+	return reference * (1 + percent/100)
+}
+
+func resolvePercents(reference common.JsonFloat64, percents []common.JsonFloat64) []common.JsonFloat64 {
+	if len(percents) == 0 {
+		return nil
+	}
+	prices := make([]common.JsonFloat64, 0, len(percents))
+	for _, percent := range percents {
+		prices = append(prices, resolvePercent(reference, percent))
+	}
+	return prices
+}
+
+// resolvePercents replaces the input's percents that are relative to the reference (i.e. 'first_candle' or 'entry')
+// with their prices, now that the reference's price is known. Entry percents are always relative to the first
+// candlestick. Relative to the entry, it's called on every entry, as each one moves the average entry price; the stop
+// loss is only resolved again if it wasn't moved (e.g. by a trailing stop loss or a stop loss movement).
+func (s *checkSignalState) resolvePercents(relativeTo string, price common.JsonFloat64) {
+	resolved := false
+	if relativeTo == common.PERCENTS_RELATIVE_TO_FIRST_CANDLE && len(s.input.EntryPercents) > 0 {
+		s.input.Entries = resolvePercents(price, s.input.EntryPercents)
+		resolved = true
+	}
+	if relativeTo == s.input.PercentsRelativeTo && len(s.input.TakeProfitPercents) > 0 {
+		s.input.TakeProfits = resolvePercents(price, s.input.TakeProfitPercents)
+		resolved = true
+	}
+	if relativeTo == s.input.PercentsRelativeTo && s.input.StopLossPercent != 0 && (s.input.StopLoss == 0 || s.stopLoss == s.input.StopLoss) {
+		s.input.StopLoss = resolvePercent(price, s.input.StopLossPercent)
+		s.stopLoss = s.input.StopLoss
+		resolved = true
+	}
+	if resolved {
+		s.logger.Printf("Checker: resolved the percents relative to the %v price (%v): entries %v, take profits %v and stop loss %v.\n", relativeTo, price, s.input.Entries, s.input.TakeProfits, s.input.StopLoss)
+	}
+}
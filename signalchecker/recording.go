@@ -0,0 +1,48 @@
+package signalchecker
+
+import (
+	"sync"
+
+	"github.com/marianogappa/signal-checker/common"
+	"github.com/marianogappa/signal-checker/httpclient"
+)
+
+// recording builds exchanges that record their HTTP responses under dir, or replay them from it. Checks configured
+// with the same option share its exchanges, so that they share their rate limits while recording.
+type recording struct {
+	dir    string
+	replay bool
+
+	mutex     sync.Mutex
+	exchanges map[string]common.Exchange
+}
+
+func newRecording(dir string, replay bool) *recording {
+	return &recording{dir: dir, replay: replay, exchanges: map[string]common.Exchange{}}
+}
+
+// exchange returns the recording or replaying version of the named exchange, or false if it doesn't fetch over HTTP
+// (e.g. offline), or if it was registered with RegisterExchange, which takes precedence over the supported exchanges.
+func (r *recording) exchange(name string) (common.Exchange, bool) {
+	if isOverridden(name) {
+		return nil, false
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if exchange, ok := r.exchanges[name]; ok {
+		return exchange, true
+	}
+	constructor, ok := httpExchangeConstructors[name]
+	if !ok {
+		return nil, false
+	}
+	var exchange common.Exchange
+	if r.replay {
+		// Replayed responses don't need to wait for the exchange's rate limit.
+		exchange = constructor(httpclient.WithTransport(httpclient.NewReplayer(r.dir)), httpclient.WithLimit(httpclient.Limit{}))
+	} else {
+		exchange = constructor(httpclient.WithTransport(httpclient.NewRecorder(r.dir, nil)))
+	}
+	r.exchanges[name] = exchange
+	return exchange, true
+}
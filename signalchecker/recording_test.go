@@ -0,0 +1,118 @@
+package signalchecker
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/marianogappa/signal-checker/binance"
+	"github.com/marianogappa/signal-checker/common"
+	"github.com/marianogappa/signal-checker/fake"
+	"github.com/marianogappa/signal-checker/httpclient"
+)
+
+// roundTripperFunc is an http.RoundTripper that calls itself.
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	ts := common.ISO8601("2021-07-04T14:14:00Z")
+	sec, _ := ts.Seconds()
+	input := common.SignalCheckInput{
+		Exchange:                 common.BINANCE,
+		BaseAsset:                "BTC",
+		QuoteAsset:               "USDT",
+		Entries:                  []common.JsonFloat64{f(11), f(9)},
+		TakeProfits:              []common.JsonFloat64{f(20)},
+		StopLoss:                 f(5),
+		InitialISO8601:           ts,
+		DontCalculateMaxEnterUSD: true,
+	}
+
+	// Instead of Binance, the recorder sends requests to a transport that serves two candlesticks.
+	requests := 0
+	binanceTransport := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		requests++
+		body := "[]"
+		if req.URL.Query().Get("startTime") == fmt.Sprintf("%v", sec*1000) {
+			body = fmt.Sprintf(`[[%v,"10","11","9","10","1",0,"0",1,"0","0","0"],[%v,"10","21","10","20","1",0,"0",1,"0","0","0"]]`, sec*1000, (sec+60)*1000)
+		}
+		return &http.Response{StatusCode: 200, Header: http.Header{}, Body: ioutil.NopCloser(bytes.NewReader([]byte(body)))}, nil
+	})
+	dir := t.TempDir()
+	recording := newRecording(dir, false)
+	recording.exchanges[common.BINANCE] = binance.NewBinance(httpclient.WithTransport(httpclient.NewRecorder(dir, binanceTransport)))
+	sChecker := NewSignalChecker(input)
+	sChecker.recording = recording
+	recordedOutput, err := sChecker.Check()
+	if err != nil {
+		t.Fatalf("unexpected error recording: %v", err)
+	}
+	if len(recordedOutput.Events) != 2 || recordedOutput.Events[1].EventType != common.TOOK_PROFIT {
+		t.Fatalf("expected to enter and take profit, but events were %+v", recordedOutput.Events)
+	}
+	recordedRequests := requests
+
+	replayedOutput, err := NewSignalChecker(input, WithHTTPReplay(dir)).Check()
+	if err != nil {
+		t.Fatalf("unexpected error replaying: %v", err)
+	}
+	if requests != recordedRequests {
+		t.Fatalf("replaying should not have sent requests, but it sent %v", requests-recordedRequests)
+	}
+	if !reflect.DeepEqual(recordedOutput, replayedOutput) {
+		t.Fatalf("expected the replayed output %+v to equal the recorded output %+v", replayedOutput, recordedOutput)
+	}
+
+	// Replaying a different check fails, as its requests were not recorded.
+	input.InitialISO8601 = "2021-07-05T14:14:00Z"
+	_, err = NewSignalChecker(input, WithHTTPReplay(dir)).Check()
+	if !errors.Is(err, httpclient.ErrFixtureNotFound) {
+		t.Fatalf("expected httpclient.ErrFixtureNotFound but got %v", err)
+	}
+}
+
+func TestReplayUsesRegisteredExchanges(t *testing.T) {
+	ts := common.ISO8601("2021-07-04T14:14:00Z")
+	sec, _ := ts.Seconds()
+	input := common.SignalCheckInput{
+		Exchange:                 common.BINANCE,
+		BaseAsset:                "BTC",
+		QuoteAsset:               "USDT",
+		Entries:                  []common.JsonFloat64{f(11), f(9)},
+		TakeProfits:              []common.JsonFloat64{f(20)},
+		StopLoss:                 f(5),
+		InitialISO8601:           ts,
+		DontCalculateMaxEnterUSD: true,
+	}
+
+	registryMutex.RLock()
+	supported := registry[common.BINANCE]
+	registryMutex.RUnlock()
+	RegisterExchange(common.BINANCE, fake.NewFake([]common.Candlestick{
+		{Timestamp: sec, OpenPrice: f(10), LowestPrice: f(9), HighestPrice: f(11), ClosePrice: f(10)},
+		{Timestamp: sec + 60, OpenPrice: f(10), LowestPrice: f(10), HighestPrice: f(21), ClosePrice: f(20)},
+	}, nil, nil, nil))
+	defer func() {
+		registryMutex.Lock()
+		registry[common.BINANCE] = supported
+		delete(overridden, common.BINANCE)
+		registryMutex.Unlock()
+	}()
+
+	// Nothing was recorded, so replaying the supported exchange would fail.
+	output, err := NewSignalChecker(input, WithHTTPReplay(t.TempDir())).Check()
+	if err != nil && !errors.Is(err, common.ErrOutOfCandlesticks) {
+		t.Fatalf("the registered exchange should have been used, but the check failed with %v", err)
+	}
+	if output.HighestTakeProfit != 1 {
+		t.Fatalf("the signal should have taken profit, but the output was %+v", output)
+	}
+}
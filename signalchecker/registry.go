@@ -0,0 +1,111 @@
+package signalchecker
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/marianogappa/signal-checker/binance"
+	"github.com/marianogappa/signal-checker/binanceusdmfutures"
+	"github.com/marianogappa/signal-checker/coinbase"
+	"github.com/marianogappa/signal-checker/common"
+	"github.com/marianogappa/signal-checker/ftx"
+	"github.com/marianogappa/signal-checker/httpclient"
+	"github.com/marianogappa/signal-checker/kraken"
+	"github.com/marianogappa/signal-checker/kucoin"
+	"github.com/marianogappa/signal-checker/offline"
+)
+
+// exchangeConstructor returns the exchange for a check's input.
+type exchangeConstructor func(input common.SignalCheckInput) common.Exchange
+
+// shared is the constructor of an exchange that is shared by all checks.
+func shared(exchange common.Exchange) exchangeConstructor {
+	return func(common.SignalCheckInput) common.Exchange { return exchange }
+}
+
+var (
+	registryMutex sync.RWMutex
+	registry      = map[string]exchangeConstructor{
+		common.BINANCE:              shared(binance.NewBinance()),
+		common.FTX:                  shared(ftx.NewFTX()),
+		common.COINBASE:             shared(coinbase.NewCoinbase()),
+		common.KRAKEN:               shared(kraken.NewKraken()),
+		common.KUCOIN:               shared(kucoin.NewKucoin()),
+		common.BINANCE_USDM_FUTURES: shared(binanceusdmfutures.NewBinanceUSDMFutures()),
+		// Each check reads from its own input's DataDir.
+		common.OFFLINE: func(input common.SignalCheckInput) common.Exchange { return offline.NewOffline(input.DataDir) },
+	}
+	// overridden are the names registered with RegisterExchange, which replace any supported exchange's constructor.
+	overridden = map[string]bool{}
+)
+
+// httpExchangeConstructors build the supported exchanges that fetch over HTTP with the given client options, e.g. to
+// record or replay their responses.
+var httpExchangeConstructors = map[string]func(opts ...httpclient.Option) common.Exchange{
+	common.BINANCE:  func(opts ...httpclient.Option) common.Exchange { return binance.NewBinance(opts...) },
+	common.FTX:      func(opts ...httpclient.Option) common.Exchange { return ftx.NewFTX(opts...) },
+	common.COINBASE: func(opts ...httpclient.Option) common.Exchange { return coinbase.NewCoinbase(opts...) },
+	common.KRAKEN:   func(opts ...httpclient.Option) common.Exchange { return kraken.NewKraken(opts...) },
+	common.KUCOIN:   func(opts ...httpclient.Option) common.Exchange { return kucoin.NewKucoin(opts...) },
+	common.BINANCE_USDM_FUTURES: func(opts ...httpclient.Option) common.Exchange {
+		return binanceusdmfutures.NewBinanceUSDMFutures(opts...)
+	},
+}
+
+// RegisterExchange makes an exchange available to checks whose input's exchange is name (case insensitive), e.g. to
+// check signals against a data source other than the supported exchanges. It replaces any exchange registered with
+// the same name. Exchanges registered with a supported exchange's name are used as-is by WithHTTPRecording and
+// WithHTTPReplay, i.e. their HTTP responses are not recorded nor replayed.
+//
+// The exchange is shared by all checks, so it must be safe for concurrent use. Implement common.CapableExchange to
+// declare what it supports (e.g. trades) and its default fees, and common.FundingRateExchange if it has funding rates.
+func RegisterExchange(name string, exchange common.Exchange) {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+	registry[strings.ToLower(name)] = shared(exchange)
+	overridden[strings.ToLower(name)] = true
+}
+
+// RegisteredExchanges returns the names of the registered exchanges, sorted.
+func RegisteredExchanges() []string {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+	names := []string{}
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// getExchange returns the registered exchange for the input's exchange, or false if there's none.
+func getExchange(input common.SignalCheckInput) (common.Exchange, bool) {
+	registryMutex.RLock()
+	constructor, ok := registry[input.Exchange]
+	registryMutex.RUnlock()
+	if !ok {
+		return nil, false
+	}
+	return constructor(input), true
+}
+
+func isOverridden(name string) bool {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+	return overridden[name]
+}
+
+// invalidExchangeError wraps common.ErrInvalidExchange with the registered exchanges.
+func invalidExchangeError() error {
+	names := RegisteredExchanges()
+	for i := range names {
+		names[i] = fmt.Sprintf("'%v'", names[i])
+	}
+	validNames := names[0]
+	if len(names) > 1 {
+		validNames = fmt.Sprintf("%v and %v", strings.Join(names[:len(names)-1], ", "), names[len(names)-1])
+	}
+	return fmt.Errorf("%w: the only valid exchanges are %v", common.ErrInvalidExchange, validNames)
+}
@@ -0,0 +1,111 @@
+package signalchecker
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/marianogappa/signal-checker/common"
+	"github.com/marianogappa/signal-checker/fake"
+)
+
+// otcExchange is a third-party exchange without trades, and with its own fees.
+type otcExchange struct {
+	*fake.Fake
+}
+
+func (e otcExchange) Capabilities() common.ExchangeCapabilities {
+	return common.ExchangeCapabilities{Fees: common.Fees{Maker: 0.01, Taker: 0.02}}
+}
+
+func TestRegisterExchange(t *testing.T) {
+	ts := common.ISO8601("2021-07-04T14:14:18Z")
+	tsSec, _ := ts.Seconds()
+	input := common.SignalCheckInput{
+		Exchange:       "OTC",
+		BaseAsset:      "BTC",
+		QuoteAsset:     "USDT",
+		Entries:        []common.JsonFloat64{f(2), f(1)},
+		TakeProfits:    []common.JsonFloat64{f(3)},
+		StopLoss:       f(0.5),
+		InitialISO8601: ts,
+	}
+
+	_, err := NewSignalChecker(input).Check()
+	if !errors.Is(err, common.ErrInvalidExchange) {
+		t.Fatalf("check should have failed with an invalid exchange before registering it, but returned %v", err)
+	}
+	if strings.Contains(err.Error(), "otc") || !strings.Contains(err.Error(), "'binance'") {
+		t.Fatalf("the error should list the registered exchanges, but was: %v", err)
+	}
+
+	RegisterExchange("OTC", otcExchange{fake.NewFake([]common.Candlestick{
+		{Timestamp: tsSec, OpenPrice: f(2), LowestPrice: f(1.5), HighestPrice: f(2.5), ClosePrice: f(2)},
+		{Timestamp: tsSec + 60, OpenPrice: f(2), LowestPrice: f(2), HighestPrice: f(3), ClosePrice: f(3)},
+	}, nil, nil, nil)})
+	defer func() {
+		registryMutex.Lock()
+		delete(registry, "otc")
+		delete(overridden, "otc")
+		registryMutex.Unlock()
+	}()
+
+	output, err := NewSignalChecker(input).Check()
+	if err != nil && err != common.ErrOutOfCandlesticks {
+		t.Fatalf("check should have succeeded, but failed with %v", err)
+	}
+	if output.HighestTakeProfit != 1 {
+		t.Fatalf("the signal should have taken profit, but the output was %+v", output)
+	}
+	if *output.Input.MakerFeeRatio != 0.01 || *output.Input.TakerFeeRatio != 0.02 {
+		t.Fatalf("the exchange's fees should have been used, but were %v and %v", *output.Input.MakerFeeRatio, *output.Input.TakerFeeRatio)
+	}
+	// The exchange doesn't support trades, so maxEnterUSD can't be calculated.
+	if output.MaxEnterUSD != 0 {
+		t.Fatalf("maxEnterUSD should not have been calculated, but was %v", output.MaxEnterUSD)
+	}
+	found := false
+	for _, name := range RegisteredExchanges() {
+		found = found || name == "otc"
+	}
+	if !found {
+		t.Fatalf("otc should be a registered exchange, but they were %v", RegisteredExchanges())
+	}
+}
+
+func TestRegisterExchangeOverridesOffline(t *testing.T) {
+	ts := common.ISO8601("2021-07-04T14:14:18Z")
+	tsSec, _ := ts.Seconds()
+	input := common.SignalCheckInput{
+		Exchange:       common.OFFLINE,
+		BaseAsset:      "BTC",
+		QuoteAsset:     "USDT",
+		Entries:        []common.JsonFloat64{f(2), f(1)},
+		TakeProfits:    []common.JsonFloat64{f(3)},
+		StopLoss:       f(0.5),
+		InitialISO8601: ts,
+	}
+
+	registryMutex.RLock()
+	supported := registry[common.OFFLINE]
+	registryMutex.RUnlock()
+	RegisterExchange(common.OFFLINE, otcExchange{fake.NewFake([]common.Candlestick{
+		{Timestamp: tsSec, OpenPrice: f(2), LowestPrice: f(1.5), HighestPrice: f(2.5), ClosePrice: f(2)},
+		{Timestamp: tsSec + 60, OpenPrice: f(2), LowestPrice: f(2), HighestPrice: f(3), ClosePrice: f(3)},
+	}, nil, nil, nil)})
+	defer func() {
+		registryMutex.Lock()
+		registry[common.OFFLINE] = supported
+		delete(overridden, common.OFFLINE)
+		registryMutex.Unlock()
+	}()
+
+	// The registered exchange doesn't read local files, so there's no need for a DataDir.
+	output, err := NewSignalChecker(input).Check()
+	if err != nil && err != common.ErrOutOfCandlesticks {
+		t.Fatalf("check should have succeeded, but failed with %v", err)
+	}
+	if output.HighestTakeProfit != 1 {
+		t.Fatalf("the signal should have taken profit, but the output was %+v", output)
+	}
+}
@@ -6,6 +6,8 @@
 //
 // output, err := signalchecker.NewSignalChecker(input).Check()
 //
+// Or, to set a deadline or be able to cancel the check: signalchecker.NewSignalChecker(input).CheckContext(ctx)
+//
 // Note that the output contains richer information about the error than err itself, but you can still use err if it
 // reads better in your code.
 //
@@ -15,71 +17,94 @@
 package signalchecker
 
 import (
-	"log"
+	"context"
+	"errors"
+	"fmt"
 	"time"
 
-	"github.com/marianogappa/signal-checker/binance"
-	"github.com/marianogappa/signal-checker/binanceusdmfutures"
-	"github.com/marianogappa/signal-checker/coinbase"
+	"github.com/marianogappa/signal-checker/cache"
 	"github.com/marianogappa/signal-checker/common"
 	"github.com/marianogappa/signal-checker/fake"
-	"github.com/marianogappa/signal-checker/ftx"
-	"github.com/marianogappa/signal-checker/kraken"
-	"github.com/marianogappa/signal-checker/kucoin"
+	"github.com/marianogappa/signal-checker/offline"
 	"github.com/marianogappa/signal-checker/profitcalculator"
 )
 
-var (
-	exchanges = map[string]common.Exchange{
-		common.BINANCE:              binance.NewBinance(),
-		common.FTX:                  ftx.NewFTX(),
-		common.COINBASE:             coinbase.NewCoinbase(),
-		common.KRAKEN:               kraken.NewKraken(),
-		common.KUCOIN:               kucoin.NewKucoin(),
-		common.BINANCE_USDM_FUTURES: binanceusdmfutures.NewBinanceUSDMFutures(),
-	}
-)
-
 // SignalChecker is the main struct does that the signal checking.
 // Use it like this: output, err := signalchecker.NewSignalChecker(input).Check()
 // Please review the docs on the common.SignalCheckInput and common.SignalCheckOutput.
 type SignalChecker struct {
-	input    common.SignalCheckInput
-	exchange common.Exchange
+	input      common.SignalCheckInput
+	exchange   common.Exchange
+	cacheDir   string
+	recording  *recording
+	onProgress func(Progress)
+	onEvent    func(common.SignalCheckOutputEvent)
 
 	// For testing
 	mockCandlesticks []common.Candlestick
 	mockTrades       []common.Trade
+	mockFundingRates []common.FundingRate
 	mockReturnErr    error
 }
 
 // NewSignalChecker is the constructor for SignalChecker.
 // Use it like this: output, err := signalchecker.NewSignalChecker(input).Check()
 // Please review the docs on the common.SignalCheckInput and common.SignalCheckOutput.
-func NewSignalChecker(input common.SignalCheckInput) *SignalChecker {
-	return &SignalChecker{input: input}
+func NewSignalChecker(input common.SignalCheckInput, opts ...Option) *SignalChecker {
+	c := &SignalChecker{input: input}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
 }
 
 // Check is the main method on SignalChecker that does the actual checking.
 // Use it like this: output, err := signalchecker.NewSignalChecker(input).Check()
 // Please review the docs on the common.SignalCheckInput and common.SignalCheckOutput.
 func (c SignalChecker) Check() (common.SignalCheckOutput, error) {
+	return c.CheckContext(context.Background())
+}
+
+// CheckContext is like Check, but stops checking (including any exchange requests in flight) when the context is
+// done, e.g. on a deadline or when the HTTP client who asked for the check disconnects. In that case, the output is an
+// error with the context's error.
+func (c SignalChecker) CheckContext(ctx context.Context) (common.SignalCheckOutput, error) {
 	validationResult, err := validateInput(c.input)
 	if err != nil {
 		return validationResult, err
 	}
 	c.input = validationResult.Input
-	if c.input.Debug {
-		log.Printf("Input validation ok. Input: %+v\n", c.input)
-	}
-	c.exchange = exchanges[c.input.Exchange]
+	// Everything the check calls logs to its own logger, so that concurrent checks' logs can be told apart.
+	logger := common.NewInputLogger(c.input)
+	ctx = common.WithLogger(ctx, logger)
+	logger.Printf("Input validation ok. Input: %+v\n", c.input)
+	c.exchange, _ = getExchange(c.input)
 
-	if c.mockCandlesticks != nil || c.mockTrades != nil {
-		c.exchange = fake.NewFake(c.mockCandlesticks, c.mockTrades, c.mockReturnErr)
+	if c.mockCandlesticks != nil || c.mockTrades != nil || c.mockFundingRates != nil {
+		c.exchange = fake.NewFake(c.mockCandlesticks, c.mockTrades, c.mockFundingRates, c.mockReturnErr)
+	} else {
+		if c.recording != nil {
+			if exchange, ok := c.recording.exchange(c.input.Exchange); ok {
+				c.exchange = exchange
+			}
+		}
+		// N.B. local files aren't cached, as they're already read once per pair, and the cache doesn't know their dir.
+		if _, isOffline := c.exchange.(*offline.Offline); c.cacheDir != "" && !isOffline {
+			c.exchange = cache.NewCache(c.exchange, c.input.Exchange, c.cacheDir)
+		}
 	}
 	c.exchange.SetDebug(c.input.Debug)
 
-	return c.doCheck()
+	return c.doCheck(ctx)
+}
+
+// Progress is how far a check went, as reported to WithProgress.
+type Progress struct {
+	// Candlesticks is the number of candlesticks processed so far.
+	Candlesticks int `json:"candlesticks"`
+
+	// CurrentISO8601 is the time of the last processed candlestick.
+	CurrentISO8601 common.ISO8601 `json:"currentISO8601"`
 }
 
 func resolveInvalidAt(input common.SignalCheckInput) (time.Time, bool) {
@@ -111,6 +136,7 @@ type checkSignalState struct {
 	profitCalculator     profitcalculator.ProfitCalculator
 	first                bool
 	reachedStopLoss      bool
+	liquidated           bool
 	highestTakeProfit    int
 	highestEntry         int
 	firstCandleOpenPrice common.JsonFloat64
@@ -119,41 +145,151 @@ type checkSignalState struct {
 	hasInvalidAt         bool
 	events               []common.SignalCheckOutputEvent
 	stopLoss             common.JsonFloat64
+	isTrailingStopLoss   bool
+	bestPrice            common.JsonFloat64
 	initialTime          time.Time
-	priceCheckpoint      float64
 	isEnded              bool
+
+	ctx                   context.Context
+	exchange              common.Exchange
+	logger                *common.Logger
+	fundingRates          *common.FundingRateIterator
+	pendingFundingRate    common.FundingRate
+	hasPendingFundingRate bool
+
+	excursion excursion
 }
 
 func newChecker(input common.SignalCheckInput) *checkSignalState {
 	invalidAt, hasInvalidAt := resolveInvalidAt(input)
 	initialTime, _ := input.InitialISO8601.Time()
+	// A stop loss of 0 (e.g. a stopLossPercent of 0) means no stop loss, which SHORT signals would otherwise reach on
+	// every price.
+	stopLoss := input.StopLoss
+	if stopLoss == 0 {
+		stopLoss = -1
+	}
 	return &checkSignalState{
 		input:            input,
 		profitCalculator: profitcalculator.NewProfitCalculator(input),
 		first:            true,
 		invalidAt:        invalidAt,
 		hasInvalidAt:     hasInvalidAt,
-		stopLoss:         input.StopLoss,
+		stopLoss:         stopLoss,
 		initialTime:      initialTime,
-		priceCheckpoint:  0.0,
 	}
 }
 
-// N.B. appyEvent returns "isEnded" boolean, to decide whether to continue.
-func (s *checkSignalState) applyEvent(eventType string, target int, tick common.Tick) bool {
+// N.B. appyEvent returns "isEnded" boolean, to decide whether to continue. why explains the event on the logs.
+func (s *checkSignalState) applyEvent(eventType string, target int, tick common.Tick, why string) bool {
+	if tick.Ambiguous {
+		why += " (on an ambiguous candlestick)"
+	}
+	s.logger.Printf("Checker: event '%v' (target %v) at %v with price %v, as %v.\n", eventType, target, time.Unix(int64(tick.Timestamp), 0).UTC().Format(time.RFC3339), tick.Price, why)
 	event := common.SignalCheckOutputEvent{EventType: eventType}
 	event.Target = target
 	event.At = common.ISO8601(time.Unix(int64(tick.Timestamp), 0).UTC().Format(time.RFC3339))
 	event.Price = tick.Price
-	event.ProfitRatio = common.JsonFloat64(s.profitCalculator.ApplyEvent(event))
+	event.Ambiguous = tick.Ambiguous
+	event.ProfitRatio = common.JsonFloat64(s.profitCalculator.ApplyEventWithVolume(event, tick.Volume))
 	s.events = append(s.events, event)
-	s.isEnded = eventType == common.FINISHED_DATASET || eventType == common.STOPPED_LOSS || s.profitCalculator.IsFinished()
+	s.isEnded = eventType == common.FINISHED_DATASET || eventType == common.STOPPED_LOSS ||
+		eventType == common.TRAILING_STOPPED_LOSS || eventType == common.LIQUIDATED || s.profitCalculator.IsFinished()
 	return s.isEnded
 }
 
+// invalidate applies an 'invalidated' event, stating the reason for it.
+func (s *checkSignalState) invalidate(reason string, tick common.Tick, why string) bool {
+	isEnded := s.applyEvent(common.INVALIDATED, 0, tick, why)
+	s.events[len(s.events)-1].Reason = reason
+	return isEnded
+}
+
+// isLiquidatedAt answers if the price crossed the liquidation price, and the stop loss (if any) isn't between the
+// liquidation price and the price at which the position was entered, so it couldn't have been reached first.
+func (s *checkSignalState) isLiquidatedAt(price, liquidationPrice common.JsonFloat64) bool {
+	if !s.input.IsShort {
+		return price <= liquidationPrice && s.stopLoss <= liquidationPrice
+	}
+	return price >= liquidationPrice && (s.stopLoss == -1 || s.stopLoss >= liquidationPrice)
+}
+
+// ratchetTrailingStopLoss moves the stop loss towards the best price seen since entering, if the input asks for a
+// trailing stop loss. It never moves the stop loss against the signal.
+func (s *checkSignalState) ratchetTrailingStopLoss(price common.JsonFloat64) {
+	if s.input.TrailingStopLossDistance == 0 && s.input.TrailingStopLossRatio == 0 {
+		return
+	}
+	if s.bestPrice == 0 || (!s.input.IsShort && price > s.bestPrice) || (s.input.IsShort && price < s.bestPrice) {
+		s.bestPrice = price
+	}
+	// N.B. only one of distance & ratio can be set, so the other one adds nothing.
+	trailBy := s.input.TrailingStopLossDistance + s.bestPrice*s.input.TrailingStopLossRatio
+	if !s.input.IsShort && s.bestPrice-trailBy > s.stopLoss {
+		s.stopLoss = s.bestPrice - trailBy
+		s.isTrailingStopLoss = true
+	}
+	if s.input.IsShort && (s.stopLoss == -1 || s.bestPrice+trailBy < s.stopLoss) {
+		s.stopLoss = s.bestPrice + trailBy
+		s.isTrailingStopLoss = true
+	}
+}
+
+// moveStopLoss applies the stop loss movements triggered by the take profits after fromTakeProfit and up to
+// toTakeProfit. Movements are sorted by take profit upon validation, so the highest reached one wins.
+func (s *checkSignalState) moveStopLoss(fromTakeProfit, toTakeProfit int) {
+	for _, movement := range s.input.StopLossMovements {
+		if movement.WhenTakeProfit <= fromTakeProfit || movement.WhenTakeProfit > toTakeProfit {
+			continue
+		}
+		entryPrice := common.JsonFloat64(s.profitCalculator.EntryPrice())
+		switch movement.MoveTo {
+		case common.MOVE_STOP_LOSS_TO_ENTRY:
+			s.stopLoss = entryPrice
+		case common.MOVE_STOP_LOSS_TO_TAKE_PROFIT:
+			s.stopLoss = s.input.TakeProfits[movement.TakeProfit-1]
+		case common.MOVE_STOP_LOSS_TO_PRICE:
+			s.stopLoss = movement.Price
+		case common.MOVE_STOP_LOSS_TO_RATIO:
+			if !s.input.IsShort {
+				s.stopLoss = entryPrice * (1 + movement.Ratio)
+			} else {
+				s.stopLoss = entryPrice * (1 - movement.Ratio)
+			}
+		}
+		s.logger.Printf("Checker: moved the stop loss to %v, as take profit %v was reached.\n", s.stopLoss, movement.WhenTakeProfit)
+		s.isTrailingStopLoss = false
+	}
+}
+
+// applyFundingRates applies the funding rates settled up to the tick's time to the open position, if any.
+func (s *checkSignalState) applyFundingRates(tick common.Tick) error {
+	for s.fundingRates != nil {
+		if !s.hasPendingFundingRate {
+			fundingRate, err := s.fundingRates.Next()
+			if err == common.ErrOutOfFundingRates {
+				s.fundingRates = nil
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			s.pendingFundingRate, s.hasPendingFundingRate = fundingRate, true
+		}
+		if s.pendingFundingRate.Timestamp > tick.Timestamp {
+			return nil
+		}
+		if s.highestEntry > 0 {
+			s.profitCalculator.ApplyFundingRate(float64(s.pendingFundingRate.Rate), float64(tick.Price))
+		}
+		s.hasPendingFundingRate = false
+	}
+	return nil
+}
+
 func (s *checkSignalState) applyTick(tick common.Tick, err error) (bool, error) {
 	if err == common.ErrOutOfCandlesticks {
-		return s.applyEvent(common.FINISHED_DATASET, 0, tick), err
+		return s.applyEvent(common.FINISHED_DATASET, 0, tick, "there are no more candlesticks"), err
 	}
 	if err != nil {
 		return true, err
@@ -165,17 +301,28 @@ func (s *checkSignalState) applyTick(tick common.Tick, err error) (bool, error)
 		return false, nil
 	}
 
+	if err := s.applyFundingRates(tick); err != nil {
+		return true, err
+	}
+
 	// Save the first read candlestick WITHIN the signal's initial time (the first tick is the open price of the first
 	// candlestick).
 	if s.first {
 		s.first = false
 		s.firstCandleOpenPrice = tick.Price
 		s.firstCandleAt = common.ISO8601(tickTime.UTC().Format(time.RFC3339))
+		s.resolvePercents(common.PERCENTS_RELATIVE_TO_FIRST_CANDLE, s.firstCandleOpenPrice)
 	}
 
 	// If the tick's time is >= the invalidation time, finish here.
 	if s.hasInvalidAt && (tickTime.After(s.invalidAt) || tickTime.Equal(s.invalidAt)) {
-		return s.applyEvent(common.INVALIDATED, 0, tick), nil
+		return s.invalidate(common.INVALIDATION_REASON_EXPIRED, tick, fmt.Sprintf("the signal expired at %v", s.invalidAt.UTC().Format(time.RFC3339))), nil
+	}
+
+	// If we haven't entered yet and price >= TP1 (for LONG) or <= TP1 (for SHORT), the signal might be void.
+	if s.input.InvalidateIfTPBeforeEntering && s.highestEntry == 0 && len(s.input.Entries) > 0 && len(s.input.TakeProfits) > 0 &&
+		((!s.input.IsShort && tick.Price >= s.input.TakeProfits[0]) || (s.input.IsShort && tick.Price <= s.input.TakeProfits[0])) {
+		return s.invalidate(common.INVALIDATION_REASON_TOOK_PROFIT_BEFORE_ENTERING, tick, fmt.Sprintf("price reached the first take profit (%v) before entering", s.input.TakeProfits[0])), nil
 	}
 
 	// If we haven't entered yet, or there are multiple entries and we're able to enter further, calculate so
@@ -193,22 +340,47 @@ func (s *checkSignalState) applyTick(tick common.Tick, err error) (bool, error)
 		}
 
 		// If there are no entries at all, this must be the first and only entry
+		why := "there are no entries, so the signal enters at the first price"
 		if len(s.input.Entries) == 0 {
 			s.highestEntry = 1
+		} else {
+			why = fmt.Sprintf("price is within entry range %v (%v to %v)", s.highestEntry, s.input.Entries[s.highestEntry-1], s.input.Entries[s.highestEntry])
+		}
+		isEnded := s.applyEvent(common.ENTERED, s.highestEntry, tick, why)
+		// Each entry moves the average entry price, so the levels relative to it are resolved again.
+		s.resolvePercents(common.PERCENTS_RELATIVE_TO_ENTRY, common.JsonFloat64(s.profitCalculator.EntryPrice()))
+		s.ratchetTrailingStopLoss(tick.Price)
+		return isEnded, nil
+	}
+
+	if s.highestEntry > 0 {
+		s.ratchetTrailingStopLoss(tick.Price)
+	}
+
+	// If we entered with leverage, and price crossed the liquidation price before the stop loss, we got liquidated.
+	if s.highestEntry > 0 {
+		if liquidationPrice, ok := s.profitCalculator.LiquidationPrice(); ok && s.isLiquidatedAt(tick.Price, common.JsonFloat64(liquidationPrice)) {
+			s.liquidated = true
+			tick.Price = common.JsonFloat64(liquidationPrice)
+			return s.applyEvent(common.LIQUIDATED, 0, tick, "price crossed the liquidation price before the stop loss"), nil
 		}
-		return s.applyEvent(common.ENTERED, s.highestEntry, tick), nil
 	}
 
 	// If we entered, and price <= stopLoss (for LONG) or >= stopLoss (for SHORT), then we reached stop loss.
-	if s.highestEntry > 0 && ((!s.input.IsShort && tick.Price <= s.stopLoss) || (s.input.IsShort && tick.Price >= s.stopLoss)) {
+	if s.highestEntry > 0 && ((!s.input.IsShort && tick.Price <= s.stopLoss) || (s.input.IsShort && s.stopLoss != -1 && tick.Price >= s.stopLoss)) {
 		s.reachedStopLoss = true
-		return s.applyEvent(common.STOPPED_LOSS, 0, tick), nil
+		if s.isTrailingStopLoss {
+			return s.applyEvent(common.TRAILING_STOPPED_LOSS, 0, tick, fmt.Sprintf("price crossed the trailing stop loss (%v)", s.stopLoss)), nil
+		}
+		return s.applyEvent(common.STOPPED_LOSS, 0, tick, fmt.Sprintf("price crossed the stop loss (%v)", s.stopLoss)), nil
 	}
 
 	// If we have entered and there are TPs and we're able to take profit further, calculate so
 	if s.highestEntry > 0 && s.highestTakeProfit < len(s.input.TakeProfits) &&
 		((!s.input.IsShort && tick.Price >= s.input.TakeProfits[s.highestTakeProfit]) || (s.input.IsShort && tick.Price <= s.input.TakeProfits[s.highestTakeProfit])) {
 
+		previousTakeProfit := s.highestTakeProfit
+
 		// Go backwards from furthest possible TP, and take profit on the first range that the price is in
 		for i := len(s.input.TakeProfits) - 1; i >= s.highestTakeProfit; i-- {
 			if (!s.input.IsShort && tick.Price < s.input.TakeProfits[i]) || (s.input.IsShort && tick.Price > s.input.TakeProfits[i]) {
@@ -217,42 +389,95 @@ func (s *checkSignalState) applyTick(tick common.Tick, err error) (bool, error)
 			s.highestTakeProfit = i + 1
 			break
 		}
-		s.applyEvent(common.TOOK_PROFIT, s.highestTakeProfit, tick)
+		s.applyEvent(common.TOOK_PROFIT, s.highestTakeProfit, tick, fmt.Sprintf("price reached take profit %v (%v)", s.highestTakeProfit, s.input.TakeProfits[s.highestTakeProfit-1]))
 		if s.isEnded || s.highestTakeProfit == len(s.input.TakeProfits) {
 			return true, nil
 		}
-		if (s.highestTakeProfit == 1 && s.input.IfTP1StopAtEntry) ||
-			(s.highestTakeProfit == 2 && s.input.IfTP2StopAtTP1) ||
-			(s.highestTakeProfit == 3 && s.input.IfTP3StopAtTP2) ||
-			(s.highestTakeProfit == 4 && s.input.IfTP4StopAtTP3) {
-			s.stopLoss = common.JsonFloat64(s.priceCheckpoint)
-		}
-		s.priceCheckpoint = float64(tick.Price)
+		s.moveStopLoss(previousTakeProfit, s.highestTakeProfit)
 	}
 	return false, nil
 }
 
-func (c SignalChecker) doCheck() (common.SignalCheckOutput, error) {
+// reportingProgress wraps next, so that it reports each candlestick to onProgress, if it's set.
+func (c SignalChecker) reportingProgress(next func() (common.Candlestick, error)) func() (common.Candlestick, error) {
+	if c.onProgress == nil {
+		return next
+	}
+	progress := Progress{}
+	return func() (common.Candlestick, error) {
+		candlestick, err := next()
+		if err == nil {
+			progress.Candlesticks++
+			progress.CurrentISO8601 = common.ISO8601(time.Unix(int64(candlestick.Timestamp), 0).UTC().Format(time.RFC3339))
+			c.onProgress(progress)
+		}
+		return candlestick, err
+	}
+}
+
+func (c SignalChecker) doCheck(ctx context.Context) (common.SignalCheckOutput, error) {
 	var (
-		candlestickIterator = c.exchange.BuildCandlestickIterator(c.input.BaseAsset, c.input.QuoteAsset, c.input.InitialISO8601)
+		candlestickIterator *common.CandlestickIterator
 		checker             = newChecker(c.input)
 		err                 error
 		isEnded             bool
 		maxEnterUSD         common.JsonFloat64
-		nextTick            = buildTickIterator(candlestickIterator.Next)
+		reportedEvents      int
+		capabilities        = common.CapabilitiesOf(c.exchange)
+		nextCandlestick     func() (common.Candlestick, error)
+		closeCandlesticks   func()
 	)
-	if c.input.ReturnCandlesticks {
-		candlestickIterator.SaveCandlesticks()
+	logger := common.LoggerFrom(ctx, c.input.Debug)
+	if c.canZoom(capabilities) {
+		logger.Printf("Checker: scanning with %v second candlesticks, and zooming into the one minute ones where needed.\n", zoomResolutionSeconds)
+		zoomingIterator := newZoomingIterator(ctx, c.exchange, c.input, zoomResolutionSeconds, checker.canSkip)
+		nextCandlestick, closeCandlesticks = zoomingIterator.next, zoomingIterator.close
+	} else {
+		// N.B. returning candlesticks turns zooming off, so they are only saved here.
+		candlestickIterator = c.exchange.BuildCandlestickIterator(ctx, c.input.BaseAsset, c.input.QuoteAsset, c.input.InitialISO8601)
+		if c.input.ReturnCandlesticks {
+			candlestickIterator.SaveCandlesticks()
+		}
+		nextCandlestick, closeCandlesticks = candlestickIterator.Next, candlestickIterator.Close
+	}
+	defer closeCandlesticks()
+	nextTick := buildTickIterator(c.reportingProgress(nextCandlestick), checker.candlestickToTicks)
+	checker.ctx = ctx
+	checker.exchange = c.exchange
+	checker.logger = logger
+	checker.profitCalculator.SetLogger(logger)
+	if fundingRateExchange, ok := c.exchange.(common.FundingRateExchange); ok && capabilities.Futures && !c.input.DontApplyFundingRates {
+		checker.fundingRates = fundingRateExchange.BuildFundingRateIterator(ctx, c.input.BaseAsset, c.input.QuoteAsset, c.input.InitialISO8601)
 	}
 	for {
-		if isEnded, err = checker.applyTick(nextTick()); isEnded || err != nil {
+		// Exchange requests already stop when the context is done, but the fake and offline exchanges don't make any.
+		if err = ctx.Err(); err != nil {
+			break
+		}
+		tick, tickErr := nextTick()
+		isEnded, err = checker.applyTick(tick, tickErr)
+		if tickErr == nil || tickErr == common.ErrOutOfCandlesticks {
+			checker.trackExcursion(tick)
+		}
+		// Events are reported once the tick is applied, as some (e.g. invalidations) are completed after being added.
+		for ; c.onEvent != nil && reportedEvents < len(checker.events); reportedEvents++ {
+			c.onEvent(checker.events[reportedEvents])
+		}
+		if isEnded || err != nil {
 			break
 		}
 	}
-	if isEnded && (err == nil || err == common.ErrOutOfCandlesticks) && !c.input.DontCalculateMaxEnterUSD {
-		maxEnterUSD, err = calculateMaxEnterUSD(c.exchange, c.input, checker.events)
+	// If the context is done, a failed exchange request's error is only a consequence of it.
+	if ctxErr := ctx.Err(); err != nil && err != common.ErrOutOfCandlesticks && ctxErr != nil {
+		err = ctxErr
+	}
+	if isEnded && (err == nil || err == common.ErrOutOfCandlesticks) && !c.input.DontCalculateMaxEnterUSD && !capabilities.Trades {
+		logger.Printf("Checker: not calculating maxEnterUSD, as exchange %v doesn't support trades.\n", c.input.Exchange)
+	}
+	if isEnded && (err == nil || err == common.ErrOutOfCandlesticks) && !c.input.DontCalculateMaxEnterUSD && capabilities.Trades {
+		maxEnterUSD, err = calculateMaxEnterUSD(ctx, c.exchange, c.input, checker.events)
 		if err != nil {
-			log.Println(err)
+			logger.Printf("Checker: couldn't calculate maxEnterUSD: %v\n", err)
 		}
 	}
 	output := common.SignalCheckOutput{
@@ -263,17 +488,27 @@ func (c SignalChecker) doCheck() (common.SignalCheckOutput, error) {
 		output.IsError = true
 		output.HttpStatus = 500
 		output.ErrorMessage = err.Error()
+		if errors.Is(err, common.ErrRateLimit) {
+			output.HttpStatus = 429
+		}
 	}
 	output.Events = checker.events
-	output.Input = c.input
+	output.Input = checker.input
 	output.Entered = checker.highestEntry > 0
 	output.HighestEntry = checker.highestEntry
 	output.FirstCandleOpenPrice = checker.firstCandleOpenPrice
 	output.FirstCandleAt = checker.firstCandleAt
 	output.HighestTakeProfit = checker.highestTakeProfit
 	output.ReachedStopLoss = checker.reachedStopLoss
+	output.Liquidated = checker.liquidated
 	output.ProfitRatio = common.JsonFloat64(checker.profitCalculator.CalculateTakeProfitRatio())
+	output.TradingCostRatio = common.JsonFloat64(checker.profitCalculator.TradingCostRatio())
+	output.FundingRatio = common.JsonFloat64(checker.profitCalculator.FundingRatio())
 	output.MaxEnterUSD = maxEnterUSD
-	output.Candlesticks = candlestickIterator.SavedCandlesticks
+	if candlestickIterator != nil {
+		output.Candlesticks = candlestickIterator.SavedCandlesticks
+	}
+	checker.setExcursionOutput(&output)
+	output.Logs = logger.Logs()
 	return output, err
 }
@@ -1,9 +1,14 @@
 package signalchecker
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"math"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/marianogappa/signal-checker/common"
 )
@@ -13,6 +18,8 @@ func TestSignalChecker(t *testing.T) {
 		name         string
 		input        common.SignalCheckInput
 		candlesticks []common.Candlestick
+		trades       []common.Trade
+		fundingRates []common.FundingRate
 		expected     common.SignalCheckOutput
 	}
 
@@ -317,7 +324,7 @@ func TestSignalChecker(t *testing.T) {
 			},
 			expected: common.SignalCheckOutput{
 				Events: []common.SignalCheckOutputEvent{
-					{EventType: common.INVALIDATED, At: ts[0], Price: f(1.0), ProfitRatio: f(0)},
+					{EventType: common.INVALIDATED, At: ts[0], Price: f(1.0), ProfitRatio: f(0), Reason: common.INVALIDATION_REASON_EXPIRED},
 				},
 				Entered:              false,
 				FirstCandleOpenPrice: f(1.0),
@@ -347,7 +354,7 @@ func TestSignalChecker(t *testing.T) {
 			},
 			expected: common.SignalCheckOutput{
 				Events: []common.SignalCheckOutputEvent{
-					{EventType: common.INVALIDATED, At: ts[1], Price: f(1.0), ProfitRatio: f(0)},
+					{EventType: common.INVALIDATED, At: ts[1], Price: f(1.0), ProfitRatio: f(0), Reason: common.INVALIDATION_REASON_EXPIRED},
 				},
 				Entered:              false,
 				FirstCandleOpenPrice: f(1.0),
@@ -483,7 +490,7 @@ func TestSignalChecker(t *testing.T) {
 					{EventType: common.ENTERED, Target: 1, At: ts[0], Price: f(2.0), ProfitRatio: f(0)},
 					{EventType: common.ENTERED, Target: 2, At: ts[1], Price: f(1.0), ProfitRatio: f(-0.25)},
 					{EventType: common.TOOK_PROFIT, Target: 1, At: ts[2], Price: f(5), ProfitRatio: f(2.75)},
-					{EventType: common.INVALIDATED, At: ts[3], Price: f(5), ProfitRatio: f(2.75)},
+					{EventType: common.INVALIDATED, At: ts[3], Price: f(5), ProfitRatio: f(2.75), Reason: common.INVALIDATION_REASON_EXPIRED},
 				},
 				Entered:              true,
 				FirstCandleOpenPrice: f(2.0),
@@ -620,7 +627,7 @@ func TestSignalChecker(t *testing.T) {
 				Events: []common.SignalCheckOutputEvent{
 					{EventType: common.ENTERED, Target: 1, At: ts[0], Price: f(2.0), ProfitRatio: f(0)},
 					{EventType: common.ENTERED, Target: 2, At: ts[1], Price: f(1.0), ProfitRatio: f(-0.25)},
-					{EventType: common.INVALIDATED, At: ts[2], Price: f(1), ProfitRatio: f(-0.25)},
+					{EventType: common.INVALIDATED, At: ts[2], Price: f(1), ProfitRatio: f(-0.25), Reason: common.INVALIDATION_REASON_EXPIRED},
 				},
 				Entered:              true,
 				FirstCandleOpenPrice: f(2.0),
@@ -630,11 +637,796 @@ func TestSignalChecker(t *testing.T) {
 				IsError:              false,
 			},
 		},
+		{
+			name: "trailing stop loss (ratio) follows the highest price and stops",
+			input: common.SignalCheckInput{
+				Exchange:                 "fake",
+				BaseAsset:                "BTC",
+				QuoteAsset:               "USDT",
+				Entries:                  []common.JsonFloat64{f(2.0), f(1.0)},
+				StopLoss:                 f(0.1),
+				TrailingStopLossRatio:    f(0.5),
+				InitialISO8601:           ts[0],
+				TakeProfits:              []common.JsonFloat64{5.0, 6.0, 7.0},
+				TakeProfitRatios:         []common.JsonFloat64{0.5, 0.25, 0.25},
+				DontCalculateMaxEnterUSD: true,
+			},
+			candlesticks: []common.Candlestick{
+				{Timestamp: tsSec[0], LowestPrice: f(1.0), HighestPrice: f(1.0), Volume: f(1.0)},
+				{Timestamp: tsSec[1], LowestPrice: f(3.0), HighestPrice: f(3.0), Volume: f(1.0)},
+				{Timestamp: tsSec[2], LowestPrice: f(1.5), HighestPrice: f(1.5), Volume: f(1.0)},
+			},
+			expected: common.SignalCheckOutput{
+				Events: []common.SignalCheckOutputEvent{
+					{EventType: common.ENTERED, Target: 1, At: ts[0], Price: f(1.0), ProfitRatio: f(0)},
+					{EventType: common.TRAILING_STOPPED_LOSS, At: ts[2], Price: f(1.5), ProfitRatio: f(0.5)},
+				},
+				Entered:              true,
+				FirstCandleOpenPrice: f(1.0),
+				FirstCandleAt:        ts[0],
+				HighestTakeProfit:    0,
+				ReachedStopLoss:      true,
+				IsError:              false,
+			},
+		},
+		{
+			name: "trailing stop loss (distance) never moves against the signal",
+			input: common.SignalCheckInput{
+				Exchange:                 "fake",
+				BaseAsset:                "BTC",
+				QuoteAsset:               "USDT",
+				Entries:                  []common.JsonFloat64{f(2.0), f(1.0)},
+				StopLoss:                 f(0.1),
+				TrailingStopLossDistance: f(1.0),
+				InitialISO8601:           ts[0],
+				TakeProfits:              []common.JsonFloat64{5.0, 6.0, 7.0},
+				TakeProfitRatios:         []common.JsonFloat64{0.5, 0.25, 0.25},
+				DontCalculateMaxEnterUSD: true,
+			},
+			candlesticks: []common.Candlestick{
+				{Timestamp: tsSec[0], LowestPrice: f(1.0), HighestPrice: f(1.0), Volume: f(1.0)},
+				{Timestamp: tsSec[1], LowestPrice: f(3.0), HighestPrice: f(3.0), Volume: f(1.0)},
+				{Timestamp: tsSec[2], LowestPrice: f(2.5), HighestPrice: f(2.5), Volume: f(1.0)},
+				{Timestamp: tsSec[3], LowestPrice: f(2.0), HighestPrice: f(2.0), Volume: f(1.0)},
+			},
+			expected: common.SignalCheckOutput{
+				Events: []common.SignalCheckOutputEvent{
+					{EventType: common.ENTERED, Target: 1, At: ts[0], Price: f(1.0), ProfitRatio: f(0)},
+					{EventType: common.TRAILING_STOPPED_LOSS, At: ts[3], Price: f(2.0), ProfitRatio: f(1.0)},
+				},
+				Entered:              true,
+				FirstCandleOpenPrice: f(1.0),
+				FirstCandleAt:        ts[0],
+				HighestTakeProfit:    0,
+				ReachedStopLoss:      true,
+				IsError:              false,
+			},
+		},
+		{
+			name: "trailing stop loss below the stop loss does not replace it",
+			input: common.SignalCheckInput{
+				Exchange:                 "fake",
+				BaseAsset:                "BTC",
+				QuoteAsset:               "USDT",
+				Entries:                  []common.JsonFloat64{f(2.0), f(1.0)},
+				StopLoss:                 f(0.5),
+				TrailingStopLossRatio:    f(0.9),
+				InitialISO8601:           ts[0],
+				TakeProfits:              []common.JsonFloat64{5.0, 6.0, 7.0},
+				TakeProfitRatios:         []common.JsonFloat64{0.5, 0.25, 0.25},
+				DontCalculateMaxEnterUSD: true,
+			},
+			candlesticks: []common.Candlestick{
+				{Timestamp: tsSec[0], LowestPrice: f(1.0), HighestPrice: f(1.0), Volume: f(1.0)},
+				{Timestamp: tsSec[1], LowestPrice: f(0.5), HighestPrice: f(0.5), Volume: f(1.0)},
+			},
+			expected: common.SignalCheckOutput{
+				Events: []common.SignalCheckOutputEvent{
+					{EventType: common.ENTERED, Target: 1, At: ts[0], Price: f(1.0), ProfitRatio: f(0)},
+					{EventType: common.STOPPED_LOSS, At: ts[1], Price: f(0.5), ProfitRatio: f(-0.5)},
+				},
+				Entered:              true,
+				FirstCandleOpenPrice: f(1.0),
+				FirstCandleAt:        ts[0],
+				HighestTakeProfit:    0,
+				ReachedStopLoss:      true,
+				IsError:              false,
+			},
+		},
+		{
+			name: "moves stop loss to entry on TP1 (legacy flag)",
+			input: common.SignalCheckInput{
+				Exchange:                 "fake",
+				BaseAsset:                "BTC",
+				QuoteAsset:               "USDT",
+				Entries:                  []common.JsonFloat64{f(2.0), f(1.0)},
+				StopLoss:                 f(0.1),
+				InitialISO8601:           ts[0],
+				TakeProfits:              []common.JsonFloat64{5.0, 6.0, 7.0},
+				TakeProfitRatios:         []common.JsonFloat64{0.5, 0.25, 0.25},
+				IfTP1StopAtEntry:         true,
+				DontCalculateMaxEnterUSD: true,
+			},
+			candlesticks: []common.Candlestick{
+				{Timestamp: tsSec[0], LowestPrice: f(1.0), HighestPrice: f(1.0), Volume: f(1.0)},
+				{Timestamp: tsSec[1], LowestPrice: f(5.0), HighestPrice: f(5.0), Volume: f(1.0)},
+				{Timestamp: tsSec[2], LowestPrice: f(1.0), HighestPrice: f(1.0), Volume: f(1.0)},
+			},
+			expected: common.SignalCheckOutput{
+				Events: []common.SignalCheckOutputEvent{
+					{EventType: common.ENTERED, Target: 1, At: ts[0], Price: f(1.0), ProfitRatio: f(0)},
+					{EventType: common.TOOK_PROFIT, Target: 1, At: ts[1], Price: f(5.0), ProfitRatio: f(4)},
+					{EventType: common.STOPPED_LOSS, At: ts[2], Price: f(1.0), ProfitRatio: f(2)},
+				},
+				Entered:              true,
+				FirstCandleOpenPrice: f(1.0),
+				FirstCandleAt:        ts[0],
+				HighestTakeProfit:    1,
+				ReachedStopLoss:      true,
+				IsError:              false,
+			},
+		},
+		{
+			name: "moves stop loss to a take profit past TP4",
+			input: common.SignalCheckInput{
+				Exchange:         "fake",
+				BaseAsset:        "BTC",
+				QuoteAsset:       "USDT",
+				Entries:          []common.JsonFloat64{f(2.0), f(1.0)},
+				StopLoss:         f(0.1),
+				InitialISO8601:   ts[0],
+				TakeProfits:      []common.JsonFloat64{5.0, 6.0, 7.0, 8.0, 9.0, 10.0},
+				TakeProfitRatios: []common.JsonFloat64{0.5, 0, 0, 0, 0.25, 0.25},
+				StopLossMovements: []common.StopLossMovement{
+					{WhenTakeProfit: 5, MoveTo: common.MOVE_STOP_LOSS_TO_TAKE_PROFIT, TakeProfit: 4},
+					{WhenTakeProfit: 1, MoveTo: common.MOVE_STOP_LOSS_TO_ENTRY},
+				},
+				DontCalculateMaxEnterUSD: true,
+			},
+			candlesticks: []common.Candlestick{
+				{Timestamp: tsSec[0], LowestPrice: f(1.0), HighestPrice: f(1.0), Volume: f(1.0)},
+				{Timestamp: tsSec[1], LowestPrice: f(9.0), HighestPrice: f(9.0), Volume: f(1.0)},
+				{Timestamp: tsSec[2], LowestPrice: f(8.0), HighestPrice: f(8.0), Volume: f(1.0)},
+			},
+			expected: common.SignalCheckOutput{
+				Events: []common.SignalCheckOutputEvent{
+					{EventType: common.ENTERED, Target: 1, At: ts[0], Price: f(1.0), ProfitRatio: f(0)},
+					{EventType: common.TOOK_PROFIT, Target: 5, At: ts[1], Price: f(9.0), ProfitRatio: f(8)},
+					{EventType: common.STOPPED_LOSS, At: ts[2], Price: f(8.0), ProfitRatio: f(7.75)},
+				},
+				Entered:              true,
+				FirstCandleOpenPrice: f(1.0),
+				FirstCandleAt:        ts[0],
+				HighestTakeProfit:    5,
+				ReachedStopLoss:      true,
+				IsError:              false,
+			},
+		},
+		{
+			name: "moves stop loss to a ratio in profit from entry",
+			input: common.SignalCheckInput{
+				Exchange:         "fake",
+				BaseAsset:        "BTC",
+				QuoteAsset:       "USDT",
+				Entries:          []common.JsonFloat64{f(2.0), f(1.0)},
+				StopLoss:         f(0.1),
+				InitialISO8601:   ts[0],
+				TakeProfits:      []common.JsonFloat64{5.0, 6.0, 7.0},
+				TakeProfitRatios: []common.JsonFloat64{0.5, 0.25, 0.25},
+				StopLossMovements: []common.StopLossMovement{
+					{WhenTakeProfit: 1, MoveTo: common.MOVE_STOP_LOSS_TO_RATIO, Ratio: 0.5},
+				},
+				DontCalculateMaxEnterUSD: true,
+			},
+			candlesticks: []common.Candlestick{
+				{Timestamp: tsSec[0], LowestPrice: f(1.0), HighestPrice: f(1.0), Volume: f(1.0)},
+				{Timestamp: tsSec[1], LowestPrice: f(5.0), HighestPrice: f(5.0), Volume: f(1.0)},
+				{Timestamp: tsSec[2], LowestPrice: f(1.5), HighestPrice: f(1.5), Volume: f(1.0)},
+			},
+			expected: common.SignalCheckOutput{
+				Events: []common.SignalCheckOutputEvent{
+					{EventType: common.ENTERED, Target: 1, At: ts[0], Price: f(1.0), ProfitRatio: f(0)},
+					{EventType: common.TOOK_PROFIT, Target: 1, At: ts[1], Price: f(5.0), ProfitRatio: f(4)},
+					{EventType: common.STOPPED_LOSS, At: ts[2], Price: f(1.5), ProfitRatio: f(2.25)},
+				},
+				Entered:              true,
+				FirstCandleOpenPrice: f(1.0),
+				FirstCandleAt:        ts[0],
+				HighestTakeProfit:    1,
+				ReachedStopLoss:      true,
+				IsError:              false,
+			},
+		},
+		{
+			name: "liquidates an isolated leveraged position before the stop loss",
+			input: common.SignalCheckInput{
+				Exchange:                 "fake",
+				BaseAsset:                "BTC",
+				QuoteAsset:               "USDT",
+				Entries:                  []common.JsonFloat64{f(2.0), f(1.0)},
+				EntryRatios:              []common.JsonFloat64{1.0},
+				StopLoss:                 f(0.1),
+				InitialISO8601:           ts[0],
+				TakeProfits:              []common.JsonFloat64{5.0, 6.0, 7.0},
+				TakeProfitRatios:         []common.JsonFloat64{0.5, 0.25, 0.25},
+				Leverage:                 f(4),
+				MarginMode:               common.MARGIN_MODE_ISOLATED,
+				MaintenanceMarginRatio:   f(0.125),
+				DontCalculateMaxEnterUSD: true,
+			},
+			candlesticks: []common.Candlestick{
+				{Timestamp: tsSec[0], LowestPrice: f(1.0), HighestPrice: f(1.0), Volume: f(1.0)},
+				{Timestamp: tsSec[1], LowestPrice: f(0.5), HighestPrice: f(0.5), Volume: f(1.0)},
+			},
+			expected: common.SignalCheckOutput{
+				Events: []common.SignalCheckOutputEvent{
+					{EventType: common.ENTERED, Target: 1, At: ts[0], Price: f(1.0), ProfitRatio: f(0)},
+					{EventType: common.LIQUIDATED, At: ts[1], Price: f(0.875), ProfitRatio: f(-1)},
+				},
+				Entered:              true,
+				FirstCandleOpenPrice: f(1.0),
+				FirstCandleAt:        ts[0],
+				HighestTakeProfit:    0,
+				ReachedStopLoss:      false,
+				Liquidated:           true,
+				IsError:              false,
+			},
+		},
+		{
+			name: "stop loss above the liquidation price is reached instead",
+			input: common.SignalCheckInput{
+				Exchange:                 "fake",
+				BaseAsset:                "BTC",
+				QuoteAsset:               "USDT",
+				Entries:                  []common.JsonFloat64{f(2.0), f(1.0)},
+				EntryRatios:              []common.JsonFloat64{1.0},
+				StopLoss:                 f(0.875),
+				InitialISO8601:           ts[0],
+				TakeProfits:              []common.JsonFloat64{5.0, 6.0, 7.0},
+				TakeProfitRatios:         []common.JsonFloat64{0.5, 0.25, 0.25},
+				Leverage:                 f(2),
+				MarginMode:               common.MARGIN_MODE_ISOLATED,
+				MaintenanceMarginRatio:   f(0.125),
+				DontCalculateMaxEnterUSD: true,
+			},
+			candlesticks: []common.Candlestick{
+				{Timestamp: tsSec[0], LowestPrice: f(1.0), HighestPrice: f(1.0), Volume: f(1.0)},
+				{Timestamp: tsSec[1], LowestPrice: f(0.875), HighestPrice: f(0.875), Volume: f(1.0)},
+			},
+			expected: common.SignalCheckOutput{
+				Events: []common.SignalCheckOutputEvent{
+					{EventType: common.ENTERED, Target: 1, At: ts[0], Price: f(1.0), ProfitRatio: f(0)},
+					{EventType: common.STOPPED_LOSS, At: ts[1], Price: f(0.875), ProfitRatio: f(-0.25)},
+				},
+				Entered:              true,
+				FirstCandleOpenPrice: f(1.0),
+				FirstCandleAt:        ts[0],
+				HighestTakeProfit:    0,
+				ReachedStopLoss:      true,
+				Liquidated:           false,
+				IsError:              false,
+			},
+		},
+		{
+			name: "isolated margin only loses the entered margin on liquidation",
+			input: common.SignalCheckInput{
+				Exchange:                 "fake",
+				BaseAsset:                "BTC",
+				QuoteAsset:               "USDT",
+				Entries:                  []common.JsonFloat64{f(2.0), f(1.0)},
+				EntryRatios:              []common.JsonFloat64{0.5, 0.5},
+				StopLoss:                 f(0.1),
+				InitialISO8601:           ts[0],
+				TakeProfits:              []common.JsonFloat64{5.0, 6.0, 7.0},
+				TakeProfitRatios:         []common.JsonFloat64{0.5, 0.25, 0.25},
+				Leverage:                 f(2),
+				MarginMode:               common.MARGIN_MODE_ISOLATED,
+				MaintenanceMarginRatio:   f(0.125),
+				DontCalculateMaxEnterUSD: true,
+			},
+			candlesticks: []common.Candlestick{
+				{Timestamp: tsSec[0], LowestPrice: f(1.0), HighestPrice: f(1.0), Volume: f(1.0)},
+				{Timestamp: tsSec[1], LowestPrice: f(0.5), HighestPrice: f(0.5), Volume: f(1.0)},
+			},
+			expected: common.SignalCheckOutput{
+				Events: []common.SignalCheckOutputEvent{
+					{EventType: common.ENTERED, Target: 1, At: ts[0], Price: f(1.0), ProfitRatio: f(0)},
+					{EventType: common.LIQUIDATED, At: ts[1], Price: f(0.625), ProfitRatio: f(-0.5)},
+				},
+				Entered:              true,
+				FirstCandleOpenPrice: f(1.0),
+				FirstCandleAt:        ts[0],
+				HighestTakeProfit:    0,
+				ReachedStopLoss:      false,
+				Liquidated:           true,
+				IsError:              false,
+			},
+		},
+		{
+			name: "cross margin is backed by the capital awaiting entry",
+			input: common.SignalCheckInput{
+				Exchange:                 "fake",
+				BaseAsset:                "BTC",
+				QuoteAsset:               "USDT",
+				Entries:                  []common.JsonFloat64{f(2.0), f(1.0)},
+				EntryRatios:              []common.JsonFloat64{0.5, 0.5},
+				StopLoss:                 f(0.1),
+				InitialISO8601:           ts[0],
+				TakeProfits:              []common.JsonFloat64{5.0, 6.0, 7.0},
+				TakeProfitRatios:         []common.JsonFloat64{0.5, 0.25, 0.25},
+				Leverage:                 f(2),
+				MarginMode:               common.MARGIN_MODE_CROSS,
+				MaintenanceMarginRatio:   f(0.125),
+				DontCalculateMaxEnterUSD: true,
+			},
+			candlesticks: []common.Candlestick{
+				{Timestamp: tsSec[0], LowestPrice: f(1.0), HighestPrice: f(1.0), Volume: f(1.0)},
+				{Timestamp: tsSec[1], LowestPrice: f(0.5), HighestPrice: f(0.5), Volume: f(1.0)},
+			},
+			expected: common.SignalCheckOutput{
+				Events: []common.SignalCheckOutputEvent{
+					{EventType: common.ENTERED, Target: 1, At: ts[0], Price: f(1.0), ProfitRatio: f(0)},
+					{EventType: common.FINISHED_DATASET, At: ts[1], Price: f(0.5), ProfitRatio: f(-0.5)},
+				},
+				Entered:              true,
+				FirstCandleOpenPrice: f(1.0),
+				FirstCandleAt:        ts[0],
+				HighestTakeProfit:    0,
+				ReachedStopLoss:      false,
+				Liquidated:           false,
+				IsError:              false,
+			},
+		},
+		{
+			name: "long pays positive funding rates only while entered",
+			input: common.SignalCheckInput{
+				Exchange:                 "fake",
+				BaseAsset:                "BTC",
+				QuoteAsset:               "USDT",
+				Entries:                  []common.JsonFloat64{f(2.0), f(1.0)},
+				StopLoss:                 f(0.1),
+				InitialISO8601:           ts[0],
+				TakeProfits:              []common.JsonFloat64{5.0, 6.0, 7.0},
+				TakeProfitRatios:         []common.JsonFloat64{0.5, 0.25, 0.25},
+				DontCalculateMaxEnterUSD: true,
+			},
+			candlesticks: []common.Candlestick{
+				{Timestamp: tsSec[0], LowestPrice: f(3.0), HighestPrice: f(3.0), Volume: f(1.0)},
+				{Timestamp: tsSec[1], LowestPrice: f(1.0), HighestPrice: f(1.0), Volume: f(1.0)},
+				{Timestamp: tsSec[2], LowestPrice: f(2.0), HighestPrice: f(2.0), Volume: f(1.0)},
+			},
+			fundingRates: []common.FundingRate{
+				{Timestamp: tsSec[0], Rate: f(0.5)},
+				{Timestamp: tsSec[2], Rate: f(0.25)},
+			},
+			expected: common.SignalCheckOutput{
+				Events: []common.SignalCheckOutputEvent{
+					{EventType: common.ENTERED, Target: 1, At: ts[1], Price: f(1.0), ProfitRatio: f(0)},
+					{EventType: common.FINISHED_DATASET, At: ts[2], Price: f(2.0), ProfitRatio: f(0.5)},
+				},
+				Entered:              true,
+				FirstCandleOpenPrice: f(3.0),
+				FirstCandleAt:        ts[0],
+				HighestTakeProfit:    0,
+				ReachedStopLoss:      false,
+				FundingRatio:         f(-0.5),
+				IsError:              false,
+			},
+		},
+		{
+			name: "does not apply funding rates if asked not to",
+			input: common.SignalCheckInput{
+				Exchange:                 "fake",
+				BaseAsset:                "BTC",
+				QuoteAsset:               "USDT",
+				IsShort:                  true,
+				Entries:                  []common.JsonFloat64{f(1.0), f(2.0)},
+				StopLoss:                 f(10.0),
+				InitialISO8601:           ts[0],
+				TakeProfits:              []common.JsonFloat64{0.5},
+				DontApplyFundingRates:    true,
+				DontCalculateMaxEnterUSD: true,
+			},
+			candlesticks: []common.Candlestick{
+				{Timestamp: tsSec[0], LowestPrice: f(1.5), HighestPrice: f(1.5), Volume: f(1.0)},
+				{Timestamp: tsSec[1], LowestPrice: f(1.5), HighestPrice: f(1.5), Volume: f(1.0)},
+			},
+			fundingRates: []common.FundingRate{
+				{Timestamp: tsSec[1], Rate: f(0.25)},
+			},
+			expected: common.SignalCheckOutput{
+				Events: []common.SignalCheckOutputEvent{
+					{EventType: common.ENTERED, Target: 1, At: ts[0], Price: f(1.5), ProfitRatio: f(0)},
+					{EventType: common.FINISHED_DATASET, At: ts[1], Price: f(1.5), ProfitRatio: f(0)},
+				},
+				Entered:              true,
+				FirstCandleOpenPrice: f(1.5),
+				FirstCandleAt:        ts[0],
+				HighestTakeProfit:    0,
+				ReachedStopLoss:      false,
+				IsError:              false,
+			},
+		},
+		{
+			name: "invalidates if TP1 is reached before entering",
+			input: common.SignalCheckInput{
+				Exchange:                     "fake",
+				BaseAsset:                    "BTC",
+				QuoteAsset:                   "USDT",
+				Entries:                      []common.JsonFloat64{f(2.0), f(1.0)},
+				StopLoss:                     f(0.5),
+				InitialISO8601:               ts[0],
+				TakeProfits:                  []common.JsonFloat64{5.0, 6.0, 7.0},
+				TakeProfitRatios:             []common.JsonFloat64{0.5, 0.25, 0.25},
+				InvalidateIfTPBeforeEntering: true,
+				DontCalculateMaxEnterUSD:     true,
+			},
+			candlesticks: []common.Candlestick{
+				{Timestamp: tsSec[0], LowestPrice: f(3.0), HighestPrice: f(3.0), Volume: f(1.0)},
+				{Timestamp: tsSec[1], LowestPrice: f(5.0), HighestPrice: f(5.0), Volume: f(1.0)},
+				{Timestamp: tsSec[2], LowestPrice: f(1.0), HighestPrice: f(1.0), Volume: f(1.0)},
+			},
+			expected: common.SignalCheckOutput{
+				Events: []common.SignalCheckOutputEvent{
+					{EventType: common.INVALIDATED, At: ts[1], Price: f(5.0), ProfitRatio: f(0), Reason: common.INVALIDATION_REASON_TOOK_PROFIT_BEFORE_ENTERING},
+				},
+				Entered:              false,
+				FirstCandleOpenPrice: f(3.0),
+				FirstCandleAt:        ts[0],
+				HighestTakeProfit:    0,
+				ReachedStopLoss:      false,
+				IsError:              false,
+			},
+		},
+		{
+			name: "short invalidates if TP1 is reached before entering",
+			input: common.SignalCheckInput{
+				Exchange:                     "fake",
+				BaseAsset:                    "BTC",
+				QuoteAsset:                   "USDT",
+				IsShort:                      true,
+				Entries:                      []common.JsonFloat64{f(5.0), f(6.0)},
+				StopLoss:                     f(7.0),
+				InitialISO8601:               ts[0],
+				TakeProfits:                  []common.JsonFloat64{3.0, 2.0},
+				InvalidateIfTPBeforeEntering: true,
+				DontCalculateMaxEnterUSD:     true,
+			},
+			candlesticks: []common.Candlestick{
+				{Timestamp: tsSec[0], LowestPrice: f(4.0), HighestPrice: f(4.0), Volume: f(1.0)},
+				{Timestamp: tsSec[1], LowestPrice: f(3.0), HighestPrice: f(3.0), Volume: f(1.0)},
+			},
+			expected: common.SignalCheckOutput{
+				Events: []common.SignalCheckOutputEvent{
+					{EventType: common.INVALIDATED, At: ts[1], Price: f(3.0), ProfitRatio: f(0), Reason: common.INVALIDATION_REASON_TOOK_PROFIT_BEFORE_ENTERING},
+				},
+				Entered:              false,
+				FirstCandleOpenPrice: f(4.0),
+				FirstCandleAt:        ts[0],
+				HighestTakeProfit:    0,
+				ReachedStopLoss:      false,
+				IsError:              false,
+			},
+		},
+		{
+			name: "does not invalidate if TP1 is reached before entering, unless asked to",
+			input: common.SignalCheckInput{
+				Exchange:                 "fake",
+				BaseAsset:                "BTC",
+				QuoteAsset:               "USDT",
+				Entries:                  []common.JsonFloat64{f(2.0), f(1.0)},
+				StopLoss:                 f(0.5),
+				InitialISO8601:           ts[0],
+				TakeProfits:              []common.JsonFloat64{5.0, 6.0, 7.0},
+				TakeProfitRatios:         []common.JsonFloat64{0.5, 0.25, 0.25},
+				DontCalculateMaxEnterUSD: true,
+			},
+			candlesticks: []common.Candlestick{
+				{Timestamp: tsSec[0], LowestPrice: f(3.0), HighestPrice: f(3.0), Volume: f(1.0)},
+				{Timestamp: tsSec[1], LowestPrice: f(5.0), HighestPrice: f(5.0), Volume: f(1.0)},
+				{Timestamp: tsSec[2], LowestPrice: f(1.0), HighestPrice: f(1.0), Volume: f(1.0)},
+			},
+			expected: common.SignalCheckOutput{
+				Events: []common.SignalCheckOutputEvent{
+					{EventType: common.ENTERED, Target: 1, At: ts[2], Price: f(1.0), ProfitRatio: f(0)},
+					{EventType: common.FINISHED_DATASET, At: ts[2], Price: f(1.0), ProfitRatio: f(0)},
+				},
+				Entered:              true,
+				FirstCandleOpenPrice: f(3.0),
+				FirstCandleAt:        ts[0],
+				HighestTakeProfit:    0,
+				ReachedStopLoss:      false,
+				IsError:              false,
+			},
+		},
+		{
+			name: "pessimistic resolution reaches the stop loss first on ambiguous candlesticks",
+			input: common.SignalCheckInput{
+				Exchange:                 "fake",
+				BaseAsset:                "BTC",
+				QuoteAsset:               "USDT",
+				Entries:                  []common.JsonFloat64{f(2.0), f(1.0)},
+				StopLoss:                 f(0.5),
+				InitialISO8601:           ts[0],
+				TakeProfits:              []common.JsonFloat64{5.0, 6.0, 7.0},
+				TakeProfitRatios:         []common.JsonFloat64{0.5, 0.25, 0.25},
+				IntraCandleResolution:    common.INTRA_CANDLE_RESOLUTION_PESSIMISTIC,
+				DontCalculateMaxEnterUSD: true,
+			},
+			candlesticks: []common.Candlestick{
+				{Timestamp: tsSec[0], LowestPrice: f(1.0), HighestPrice: f(1.0), Volume: f(1.0)},
+				{Timestamp: tsSec[1], LowestPrice: f(0.5), HighestPrice: f(5.0), Volume: f(1.0)},
+			},
+			expected: common.SignalCheckOutput{
+				Events: []common.SignalCheckOutputEvent{
+					{EventType: common.ENTERED, Target: 1, At: ts[0], Price: f(1.0), ProfitRatio: f(0)},
+					{EventType: common.STOPPED_LOSS, At: ts[1], Price: f(0.5), ProfitRatio: f(-0.5), Ambiguous: true},
+				},
+				Entered:              true,
+				FirstCandleOpenPrice: f(1.0),
+				FirstCandleAt:        ts[0],
+				HighestTakeProfit:    0,
+				ReachedStopLoss:      true,
+				IsError:              false,
+			},
+		},
+		{
+			name: "optimistic resolution reaches the take profit first on ambiguous candlesticks",
+			input: common.SignalCheckInput{
+				Exchange:                 "fake",
+				BaseAsset:                "BTC",
+				QuoteAsset:               "USDT",
+				Entries:                  []common.JsonFloat64{f(2.0), f(1.0)},
+				StopLoss:                 f(0.5),
+				InitialISO8601:           ts[0],
+				TakeProfits:              []common.JsonFloat64{5.0, 6.0, 7.0},
+				TakeProfitRatios:         []common.JsonFloat64{0.5, 0.25, 0.25},
+				IntraCandleResolution:    common.INTRA_CANDLE_RESOLUTION_OPTIMISTIC,
+				DontCalculateMaxEnterUSD: true,
+			},
+			candlesticks: []common.Candlestick{
+				{Timestamp: tsSec[0], LowestPrice: f(1.0), HighestPrice: f(1.0), Volume: f(1.0)},
+				{Timestamp: tsSec[1], LowestPrice: f(0.5), HighestPrice: f(5.0), Volume: f(1.0)},
+			},
+			expected: common.SignalCheckOutput{
+				Events: []common.SignalCheckOutputEvent{
+					{EventType: common.ENTERED, Target: 1, At: ts[0], Price: f(1.0), ProfitRatio: f(0)},
+					{EventType: common.TOOK_PROFIT, Target: 1, At: ts[1], Price: f(5.0), ProfitRatio: f(4), Ambiguous: true},
+					{EventType: common.STOPPED_LOSS, At: ts[1], Price: f(0.5), ProfitRatio: f(1.75), Ambiguous: true},
+				},
+				Entered:              true,
+				FirstCandleOpenPrice: f(1.0),
+				FirstCandleAt:        ts[0],
+				HighestTakeProfit:    1,
+				ReachedStopLoss:      true,
+				IsError:              false,
+			},
+		},
+		{
+			name: "exact resolution uses trades on ambiguous candlesticks",
+			input: common.SignalCheckInput{
+				Exchange:                 "fake",
+				BaseAsset:                "BTC",
+				QuoteAsset:               "USDT",
+				Entries:                  []common.JsonFloat64{f(2.0), f(1.0)},
+				StopLoss:                 f(0.5),
+				InitialISO8601:           ts[0],
+				TakeProfits:              []common.JsonFloat64{5.0, 6.0, 7.0},
+				TakeProfitRatios:         []common.JsonFloat64{0.5, 0.25, 0.25},
+				IntraCandleResolution:    common.INTRA_CANDLE_RESOLUTION_EXACT,
+				DontCalculateMaxEnterUSD: true,
+			},
+			candlesticks: []common.Candlestick{
+				{Timestamp: tsSec[0], LowestPrice: f(1.0), HighestPrice: f(1.0), Volume: f(1.0)},
+				{Timestamp: tsSec[1], LowestPrice: f(0.5), HighestPrice: f(5.0), Volume: f(1.0)},
+			},
+			trades: []common.Trade{
+				{Timestamp: tsSec[0], BaseAssetPrice: f(1.0)},
+				{Timestamp: tsSec[1], BaseAssetPrice: f(5.0)},
+				{Timestamp: tsSec[1] + 1, BaseAssetPrice: f(0.5)},
+				{Timestamp: tsSec[2], BaseAssetPrice: f(1.0)},
+			},
+			expected: common.SignalCheckOutput{
+				Events: []common.SignalCheckOutputEvent{
+					{EventType: common.ENTERED, Target: 1, At: ts[0], Price: f(1.0), ProfitRatio: f(0)},
+					{EventType: common.TOOK_PROFIT, Target: 1, At: ts[1], Price: f(5.0), ProfitRatio: f(4), Ambiguous: true},
+					{EventType: common.STOPPED_LOSS, At: common.ISO8601("2021-07-04T14:15:19Z"), Price: f(0.5), ProfitRatio: f(1.75), Ambiguous: true},
+				},
+				Entered:              true,
+				FirstCandleOpenPrice: f(1.0),
+				FirstCandleAt:        ts[0],
+				HighestTakeProfit:    1,
+				ReachedStopLoss:      true,
+				IsError:              false,
+			},
+		},
+		{
+			name: "exact resolution falls back to pessimistic without trades",
+			input: common.SignalCheckInput{
+				Exchange:                 "fake",
+				BaseAsset:                "BTC",
+				QuoteAsset:               "USDT",
+				Entries:                  []common.JsonFloat64{f(2.0), f(1.0)},
+				StopLoss:                 f(0.5),
+				InitialISO8601:           ts[0],
+				TakeProfits:              []common.JsonFloat64{5.0, 6.0, 7.0},
+				TakeProfitRatios:         []common.JsonFloat64{0.5, 0.25, 0.25},
+				IntraCandleResolution:    common.INTRA_CANDLE_RESOLUTION_EXACT,
+				DontCalculateMaxEnterUSD: true,
+			},
+			candlesticks: []common.Candlestick{
+				{Timestamp: tsSec[0], LowestPrice: f(1.0), HighestPrice: f(1.0), Volume: f(1.0)},
+				{Timestamp: tsSec[1], LowestPrice: f(0.5), HighestPrice: f(5.0), Volume: f(1.0)},
+			},
+			expected: common.SignalCheckOutput{
+				Events: []common.SignalCheckOutputEvent{
+					{EventType: common.ENTERED, Target: 1, At: ts[0], Price: f(1.0), ProfitRatio: f(0)},
+					{EventType: common.STOPPED_LOSS, At: ts[1], Price: f(0.5), ProfitRatio: f(-0.5), Ambiguous: true},
+				},
+				Entered:              true,
+				FirstCandleOpenPrice: f(1.0),
+				FirstCandleAt:        ts[0],
+				HighestTakeProfit:    0,
+				ReachedStopLoss:      true,
+				IsError:              false,
+			},
+		},
+		{
+			name: "short pessimistic resolution reaches the stop loss first on ambiguous candlesticks",
+			input: common.SignalCheckInput{
+				Exchange:                 "fake",
+				BaseAsset:                "BTC",
+				QuoteAsset:               "USDT",
+				IsShort:                  true,
+				Entries:                  []common.JsonFloat64{f(1.0), f(2.0)},
+				StopLoss:                 f(3.0),
+				InitialISO8601:           ts[0],
+				TakeProfits:              []common.JsonFloat64{0.5},
+				IntraCandleResolution:    common.INTRA_CANDLE_RESOLUTION_PESSIMISTIC,
+				DontCalculateMaxEnterUSD: true,
+			},
+			candlesticks: []common.Candlestick{
+				{Timestamp: tsSec[0], LowestPrice: f(2.0), HighestPrice: f(2.0), Volume: f(1.0)},
+				{Timestamp: tsSec[1], LowestPrice: f(0.5), HighestPrice: f(3.0), Volume: f(1.0)},
+			},
+			expected: common.SignalCheckOutput{
+				Events: []common.SignalCheckOutputEvent{
+					{EventType: common.ENTERED, Target: 1, At: ts[0], Price: f(2.0), ProfitRatio: f(0)},
+					{EventType: common.STOPPED_LOSS, At: ts[1], Price: f(3.0), ProfitRatio: f(-0.5), Ambiguous: true},
+				},
+				Entered:              true,
+				FirstCandleOpenPrice: f(2.0),
+				FirstCandleAt:        ts[0],
+				HighestTakeProfit:    0,
+				ReachedStopLoss:      true,
+				IsError:              false,
+			},
+		},
+		{
+			name: "short low_first (default) resolution takes profit first on ambiguous candlesticks, as before intra candle resolutions",
+			input: common.SignalCheckInput{
+				Exchange:                 "fake",
+				BaseAsset:                "BTC",
+				QuoteAsset:               "USDT",
+				IsShort:                  true,
+				Entries:                  []common.JsonFloat64{f(1.0), f(2.0)},
+				StopLoss:                 f(3.0),
+				InitialISO8601:           ts[0],
+				TakeProfits:              []common.JsonFloat64{0.5},
+				DontCalculateMaxEnterUSD: true,
+			},
+			candlesticks: []common.Candlestick{
+				{Timestamp: tsSec[0], LowestPrice: f(2.0), HighestPrice: f(2.0), Volume: f(1.0)},
+				{Timestamp: tsSec[1], LowestPrice: f(0.5), HighestPrice: f(3.0), Volume: f(1.0)},
+			},
+			expected: common.SignalCheckOutput{
+				Events: []common.SignalCheckOutputEvent{
+					{EventType: common.ENTERED, Target: 1, At: ts[0], Price: f(2.0), ProfitRatio: f(0)},
+					{EventType: common.TOOK_PROFIT, Target: 1, At: ts[1], Price: f(0.5), ProfitRatio: f(0.75), Ambiguous: true},
+				},
+				Entered:              true,
+				FirstCandleOpenPrice: f(2.0),
+				FirstCandleAt:        ts[0],
+				HighestTakeProfit:    1,
+				ReachedStopLoss:      false,
+				IsError:              false,
+			},
+		},
+		{
+			name: "candlesticks that touch an entry and the stop loss are ambiguous",
+			input: common.SignalCheckInput{
+				Exchange:                 "fake",
+				BaseAsset:                "BTC",
+				QuoteAsset:               "USDT",
+				Entries:                  []common.JsonFloat64{f(2.0), f(1.0)},
+				StopLoss:                 f(0.5),
+				InitialISO8601:           ts[0],
+				TakeProfits:              []common.JsonFloat64{5.0},
+				DontCalculateMaxEnterUSD: true,
+			},
+			candlesticks: []common.Candlestick{
+				{Timestamp: tsSec[0], LowestPrice: f(3.0), HighestPrice: f(3.0), Volume: f(1.0)},
+				{Timestamp: tsSec[1], LowestPrice: f(0.4), HighestPrice: f(1.5), Volume: f(1.0)},
+			},
+			expected: common.SignalCheckOutput{
+				Events: []common.SignalCheckOutputEvent{
+					{EventType: common.ENTERED, Target: 1, At: ts[1], Price: f(1.5), ProfitRatio: f(0), Ambiguous: true},
+					{EventType: common.FINISHED_DATASET, At: ts[1], Price: f(1.5), ProfitRatio: f(0), Ambiguous: true},
+				},
+				Entered:              true,
+				FirstCandleOpenPrice: f(3.0),
+				FirstCandleAt:        ts[0],
+				HighestTakeProfit:    0,
+				ReachedStopLoss:      false,
+				IsError:              false,
+			},
+		},
+		{
+			name: "candlesticks that touch an entry and a take profit are ambiguous",
+			input: common.SignalCheckInput{
+				Exchange:                 "fake",
+				BaseAsset:                "BTC",
+				QuoteAsset:               "USDT",
+				Entries:                  []common.JsonFloat64{f(2.0), f(1.0)},
+				StopLoss:                 f(0.5),
+				InitialISO8601:           ts[0],
+				TakeProfits:              []common.JsonFloat64{5.0},
+				DontCalculateMaxEnterUSD: true,
+			},
+			candlesticks: []common.Candlestick{
+				{Timestamp: tsSec[0], LowestPrice: f(3.0), HighestPrice: f(3.0), Volume: f(1.0)},
+				{Timestamp: tsSec[1], LowestPrice: f(1.5), HighestPrice: f(5.0), Volume: f(1.0)},
+			},
+			expected: common.SignalCheckOutput{
+				Events: []common.SignalCheckOutputEvent{
+					{EventType: common.ENTERED, Target: 1, At: ts[1], Price: f(1.5), ProfitRatio: f(0), Ambiguous: true},
+					{EventType: common.TOOK_PROFIT, Target: 1, At: ts[1], Price: f(5.0), ProfitRatio: f(2.3333333333333335), Ambiguous: true},
+				},
+				Entered:              true,
+				FirstCandleOpenPrice: f(3.0),
+				FirstCandleAt:        ts[0],
+				HighestTakeProfit:    1,
+				ReachedStopLoss:      false,
+				IsError:              false,
+			},
+		},
+		{
+			name: "candlesticks that touch an entry and the invalidating take profit are ambiguous",
+			input: common.SignalCheckInput{
+				Exchange:                     "fake",
+				BaseAsset:                    "BTC",
+				QuoteAsset:                   "USDT",
+				Entries:                      []common.JsonFloat64{f(2.0), f(1.0)},
+				StopLoss:                     f(0.5),
+				InitialISO8601:               ts[0],
+				TakeProfits:                  []common.JsonFloat64{5.0},
+				InvalidateIfTPBeforeEntering: true,
+				IntraCandleResolution:        common.INTRA_CANDLE_RESOLUTION_OPTIMISTIC,
+				DontCalculateMaxEnterUSD:     true,
+			},
+			candlesticks: []common.Candlestick{
+				{Timestamp: tsSec[0], LowestPrice: f(3.0), HighestPrice: f(3.0), Volume: f(1.0)},
+				{Timestamp: tsSec[1], LowestPrice: f(1.5), HighestPrice: f(5.0), Volume: f(1.0)},
+			},
+			expected: common.SignalCheckOutput{
+				Events: []common.SignalCheckOutputEvent{
+					{EventType: common.INVALIDATED, At: ts[1], Price: f(5.0), ProfitRatio: f(0), Ambiguous: true, Reason: common.INVALIDATION_REASON_TOOK_PROFIT_BEFORE_ENTERING},
+				},
+				Entered:              false,
+				FirstCandleOpenPrice: f(3.0),
+				FirstCandleAt:        ts[0],
+				HighestTakeProfit:    0,
+				ReachedStopLoss:      false,
+				IsError:              false,
+			},
+		},
 	}
 	for _, ts := range tss {
 		t.Run(ts.name, func(t *testing.T) {
 			sChecker := NewSignalChecker(ts.input)
 			sChecker.mockCandlesticks = ts.candlesticks
+			sChecker.mockTrades = ts.trades
+			sChecker.mockFundingRates = ts.fundingRates
 			actual, err := sChecker.Check()
 			if actual.IsError && err == nil {
 				t.Fatalf("Output says there is an error but function did not return an error!")
@@ -657,6 +1449,12 @@ func TestSignalChecker(t *testing.T) {
 			if actual.ReachedStopLoss != ts.expected.ReachedStopLoss {
 				t.Errorf("expected ReachedStopLoss = %v but got ReachedStopLoss = %v", ts.expected.ReachedStopLoss, actual.ReachedStopLoss)
 			}
+			if actual.FundingRatio != ts.expected.FundingRatio {
+				t.Errorf("expected FundingRatio = %v but got FundingRatio = %v", ts.expected.FundingRatio, actual.FundingRatio)
+			}
+			if actual.Liquidated != ts.expected.Liquidated {
+				t.Errorf("expected Liquidated = %v but got Liquidated = %v", ts.expected.Liquidated, actual.Liquidated)
+			}
 			if actual.IsError != ts.expected.IsError {
 				t.Errorf("expected IsError = %v but got IsError = %v", ts.expected.IsError, actual.IsError)
 			}
@@ -822,6 +1620,89 @@ func TestSaveCandlesticks(t *testing.T) {
 	}
 }
 
+func TestExcursionMetrics(t *testing.T) {
+	ts := common.ISO8601("2021-07-04T14:14:18Z")
+	tsSec, _ := ts.Seconds()
+	iso := func(sec int) common.ISO8601 {
+		return common.ISO8601(time.Unix(int64(sec), 0).UTC().Format(time.RFC3339))
+	}
+
+	input := common.SignalCheckInput{
+		Exchange:                 "fake",
+		BaseAsset:                "BTC",
+		QuoteAsset:               "USDT",
+		Entries:                  []common.JsonFloat64{f(1.1), f(0.9)},
+		EntryRatios:              []common.JsonFloat64{f(1)},
+		StopLoss:                 f(0.5),
+		TakeProfits:              []common.JsonFloat64{f(3)},
+		InitialISO8601:           ts,
+		DontCalculateMaxEnterUSD: true,
+		DontApplyFees:            true,
+		ReturnEquityCurve:        true,
+	}
+	sChecker := NewSignalChecker(input)
+	sChecker.mockCandlesticks = []common.Candlestick{
+		{Timestamp: tsSec, LowestPrice: f(2), HighestPrice: f(2)},
+		{Timestamp: tsSec + 60, LowestPrice: f(1), HighestPrice: f(1.5)},
+		{Timestamp: tsSec + 120, LowestPrice: f(0.8), HighestPrice: f(1.2)},
+		{Timestamp: tsSec + 3660, LowestPrice: f(0.9), HighestPrice: f(0.9)},
+		{Timestamp: tsSec + 3780, LowestPrice: f(3), HighestPrice: f(3)},
+	}
+	output, err := sChecker.Check()
+	if err != nil {
+		t.Fatalf("check should have succeeded, but failed with %v", err)
+	}
+	if output.MaxFavourableExcursionRatio != f(2) {
+		t.Errorf("expected MaxFavourableExcursionRatio = 2 but got %v", output.MaxFavourableExcursionRatio)
+	}
+	if math.Abs(float64(output.MaxAdverseExcursionRatio)+0.2) > 1e-9 {
+		t.Errorf("expected MaxAdverseExcursionRatio = -0.2 but got %v", output.MaxAdverseExcursionRatio)
+	}
+	// From 1.5 times the capital down to 0.8 times.
+	if math.Abs(float64(output.MaxDrawdownRatio)-0.7/1.5) > 1e-9 {
+		t.Errorf("expected MaxDrawdownRatio = %v but got %v", 0.7/1.5, output.MaxDrawdownRatio)
+	}
+	if output.TimeToFirstEntrySeconds != 60 {
+		t.Errorf("expected TimeToFirstEntrySeconds = 60 but got %v", output.TimeToFirstEntrySeconds)
+	}
+	if output.TimeInPositionSeconds != 3720 {
+		t.Errorf("expected TimeInPositionSeconds = 3720 but got %v", output.TimeInPositionSeconds)
+	}
+	// The entry, an hourly sample and the take profit.
+	expectedAts := []common.ISO8601{iso(tsSec + 60), iso(tsSec + 3660), iso(tsSec + 3780)}
+	actualAts := []common.ISO8601{}
+	for _, point := range output.EquityCurve {
+		actualAts = append(actualAts, point.At)
+	}
+	if !reflect.DeepEqual(expectedAts, actualAts) {
+		t.Errorf("expected equity curve at %v but got %+v", expectedAts, output.EquityCurve)
+	}
+}
+
+func TestNoExcursionMetricsWithoutEntering(t *testing.T) {
+	ts := common.ISO8601("2021-07-04T14:14:18Z")
+	tsSec, _ := ts.Seconds()
+
+	input := common.SignalCheckInput{
+		Exchange:                 "fake",
+		BaseAsset:                "BTC",
+		QuoteAsset:               "USDT",
+		Entries:                  []common.JsonFloat64{f(1.1), f(0.9)},
+		EntryRatios:              []common.JsonFloat64{f(1)},
+		InitialISO8601:           ts,
+		DontCalculateMaxEnterUSD: true,
+		ReturnEquityCurve:        true,
+	}
+	sChecker := NewSignalChecker(input)
+	sChecker.mockCandlesticks = []common.Candlestick{
+		{Timestamp: tsSec, LowestPrice: f(2), HighestPrice: f(2)},
+	}
+	output, _ := sChecker.Check()
+	if output.MaxAdverseExcursionRatio != 0 || output.TimeToFirstEntrySeconds != 0 || output.EquityCurve != nil {
+		t.Errorf("expected no excursion metrics but got %+v", output)
+	}
+}
+
 func TestErrorsGettingCandlesticks(t *testing.T) {
 	ts := common.ISO8601("2021-07-04T14:14:18Z")
 	sec, _ := ts.Seconds()
@@ -857,6 +1738,220 @@ func TestErrorsGettingCandlesticks(t *testing.T) {
 	}
 }
 
+func TestRateLimitedGettingCandlesticks(t *testing.T) {
+	ts := common.ISO8601("2021-07-04T14:14:18Z")
+	sec, _ := ts.Seconds()
+	tsSec := sec
+
+	input := common.SignalCheckInput{
+		Exchange:                 "fake",
+		BaseAsset:                "BTC",
+		QuoteAsset:               "USDT",
+		Entries:                  []common.JsonFloat64{f(2), f(1)},
+		InitialISO8601:           ts,
+		DontCalculateMaxEnterUSD: true,
+	}
+	sChecker := NewSignalChecker(input)
+	sChecker.mockCandlesticks = []common.Candlestick{
+		{Timestamp: tsSec, LowestPrice: f(0.2), HighestPrice: f(0.2), Volume: f(1.0)},
+	}
+	sChecker.mockReturnErr = fmt.Errorf("%w: fake returned 429 status code after 3 retries", common.ErrRateLimit)
+	output, err := sChecker.Check()
+	if !errors.Is(err, common.ErrRateLimit) {
+		t.Fatalf("check should have returned a rate limit error, but it returned %v", err)
+	}
+	if output.HttpStatus != 429 {
+		t.Fatalf("output.HttpStatus should have been 429 but was %v", output.HttpStatus)
+	}
+}
+
+func TestCheckContextCancelled(t *testing.T) {
+	ts := common.ISO8601("2021-07-04T14:14:18Z")
+	sec, _ := ts.Seconds()
+	tsSec := sec
+
+	input := common.SignalCheckInput{
+		Exchange:                 "fake",
+		BaseAsset:                "BTC",
+		QuoteAsset:               "USDT",
+		Entries:                  []common.JsonFloat64{f(2), f(1)},
+		InitialISO8601:           ts,
+		DontCalculateMaxEnterUSD: true,
+	}
+	sChecker := NewSignalChecker(input)
+	sChecker.mockCandlesticks = []common.Candlestick{
+		{Timestamp: tsSec, LowestPrice: f(0.2), HighestPrice: f(0.2), Volume: f(1.0)},
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	output, err := sChecker.CheckContext(ctx)
+	if err != context.Canceled {
+		t.Fatalf("check should have returned context.Canceled, but it returned %v", err)
+	}
+	if !output.IsError {
+		t.Fatal("output.IsError should have been true")
+	}
+	if len(output.Events) != 0 {
+		t.Fatalf("there should be no events, but there were %v", len(output.Events))
+	}
+}
+
 func f(fl float64) common.JsonFloat64 {
 	return common.JsonFloat64(fl)
 }
+
+func TestReturnLogs(t *testing.T) {
+	ts := common.ISO8601("2021-07-04T14:14:00Z")
+	tsSec, _ := ts.Seconds()
+	input := common.SignalCheckInput{
+		Exchange:                 "fake",
+		BaseAsset:                "BTC",
+		QuoteAsset:               "USDT",
+		Entries:                  []common.JsonFloat64{f(2), f(1)},
+		TakeProfits:              []common.JsonFloat64{f(3)},
+		StopLoss:                 f(0.5),
+		InitialISO8601:           ts,
+		DontCalculateMaxEnterUSD: true,
+	}
+	candlesticks := []common.Candlestick{
+		{Timestamp: tsSec, LowestPrice: f(1.5), HighestPrice: f(1.5)},
+		{Timestamp: tsSec + 60, LowestPrice: f(3), HighestPrice: f(3)},
+	}
+
+	sChecker := NewSignalChecker(input)
+	sChecker.mockCandlesticks = candlesticks
+	output, _ := sChecker.Check()
+	if output.Logs != nil {
+		t.Fatalf("expected no logs without returnLogs, but got %v", output.Logs)
+	}
+
+	input.ReturnLogs = true
+	sChecker = NewSignalChecker(input)
+	sChecker.mockCandlesticks = candlesticks
+	output, _ = sChecker.Check()
+	expectedLogs := []string{
+		"Checker: event 'entered' (target 1) at 2021-07-04T14:14:00Z with price 1.5, as price is within entry range 1 (2 to 1).",
+		"ProfitCalculator: applying event 'entered' with price 1.5",
+		"Checker: event 'took_profit' (target 1) at 2021-07-04T14:15:00Z with price 3, as price reached take profit 1 (3).",
+	}
+	logs := strings.Join(output.Logs, "\n")
+	for _, expectedLog := range expectedLogs {
+		if !strings.Contains(logs, expectedLog) {
+			t.Fatalf("expected logs to contain %q, but they were:\n%v", expectedLog, logs)
+		}
+	}
+}
+
+func TestPercents(t *testing.T) {
+	initial := common.ISO8601("2021-07-04T14:14:00Z")
+	tsSec, _ := initial.Seconds()
+
+	type test struct {
+		name                string
+		input               common.SignalCheckInput
+		candlesticks        []common.Candlestick
+		expectedEntries     []common.JsonFloat64
+		expectedTakeProfits []common.JsonFloat64
+		expectedStopLoss    common.JsonFloat64
+		expectedEvents      []string
+	}
+	tss := []test{
+		{
+			name: "(LONG) percents relative to the first candle",
+			input: common.SignalCheckInput{
+				EntryPercents:      []common.JsonFloat64{f(0), f(-2)},
+				TakeProfitPercents: []common.JsonFloat64{f(3)},
+				StopLossPercent:    f(-5),
+			},
+			candlesticks: []common.Candlestick{
+				{Timestamp: tsSec, OpenPrice: f(100), LowestPrice: f(100), HighestPrice: f(100)},
+				{Timestamp: tsSec + 60, OpenPrice: f(100), LowestPrice: f(99), HighestPrice: f(103)},
+			},
+			expectedEntries:     []common.JsonFloat64{f(100), f(98)},
+			expectedTakeProfits: []common.JsonFloat64{f(103)},
+			expectedStopLoss:    f(95),
+			expectedEvents:      []string{common.ENTERED, common.TOOK_PROFIT},
+		},
+		{
+			name: "(SHORT) percents relative to the entry",
+			input: common.SignalCheckInput{
+				IsShort:            true,
+				TakeProfitPercents: []common.JsonFloat64{f(-10)},
+				StopLossPercent:    f(5),
+				PercentsRelativeTo: common.PERCENTS_RELATIVE_TO_ENTRY,
+			},
+			candlesticks: []common.Candlestick{
+				{Timestamp: tsSec, OpenPrice: f(200), LowestPrice: f(200), HighestPrice: f(200)},
+				{Timestamp: tsSec + 60, OpenPrice: f(200), LowestPrice: f(200), HighestPrice: f(205)},
+				{Timestamp: tsSec + 120, OpenPrice: f(205), LowestPrice: f(205), HighestPrice: f(210)},
+			},
+			expectedTakeProfits: []common.JsonFloat64{f(180)},
+			expectedStopLoss:    f(210),
+			expectedEvents:      []string{common.ENTERED, common.STOPPED_LOSS},
+		},
+		{
+			name: "(LONG) percents relative to the average entry price, after entering twice",
+			input: common.SignalCheckInput{
+				Entries:            []common.JsonFloat64{f(101), f(90), f(70)},
+				EntryRatios:        []common.JsonFloat64{f(0.5), f(0.5)},
+				TakeProfitPercents: []common.JsonFloat64{f(10)},
+				StopLossPercent:    f(-20),
+				PercentsRelativeTo: common.PERCENTS_RELATIVE_TO_ENTRY,
+				DontApplyFees:      true,
+			},
+			candlesticks: []common.Candlestick{
+				{Timestamp: tsSec, OpenPrice: f(100), LowestPrice: f(100), HighestPrice: f(100)},
+				{Timestamp: tsSec + 60, OpenPrice: f(100), LowestPrice: f(80), HighestPrice: f(80)},
+				{Timestamp: tsSec + 120, OpenPrice: f(80), LowestPrice: f(100), HighestPrice: f(100)},
+			},
+			expectedEntries: []common.JsonFloat64{f(101), f(90), f(70)},
+			// i.e. 10% over and 20% under the ProfitCalculator's average entry price after both entries (3600/41),
+			// rather than the first entry's 100.
+			expectedTakeProfits: []common.JsonFloat64{f(96.58536585365853)},
+			expectedStopLoss:    f(70.24390243902438),
+			expectedEvents:      []string{common.ENTERED, common.ENTERED, common.TOOK_PROFIT},
+		},
+		{
+			name: "(SHORT) no stop loss percent means no stop loss",
+			input: common.SignalCheckInput{
+				IsShort:            true,
+				TakeProfitPercents: []common.JsonFloat64{f(-10)},
+			},
+			candlesticks: []common.Candlestick{
+				{Timestamp: tsSec, OpenPrice: f(100), LowestPrice: f(100), HighestPrice: f(100)},
+				{Timestamp: tsSec + 60, OpenPrice: f(100), LowestPrice: f(100), HighestPrice: f(150)},
+				{Timestamp: tsSec + 120, OpenPrice: f(150), LowestPrice: f(90), HighestPrice: f(90)},
+			},
+			expectedTakeProfits: []common.JsonFloat64{f(90)},
+			expectedEvents:      []string{common.ENTERED, common.TOOK_PROFIT},
+		},
+	}
+	for _, ts := range tss {
+		t.Run(ts.name, func(t *testing.T) {
+			input := ts.input
+			input.Exchange = "fake"
+			input.BaseAsset = "BTC"
+			input.QuoteAsset = "USDT"
+			input.InitialISO8601 = initial
+			input.DontCalculateMaxEnterUSD = true
+
+			sChecker := NewSignalChecker(input)
+			sChecker.mockCandlesticks = ts.candlesticks
+			output, err := sChecker.Check()
+			if err != nil && err != common.ErrOutOfCandlesticks {
+				t.Fatalf("check should have succeeded, but failed with %v", err)
+			}
+			events := []string{}
+			for _, event := range output.Events {
+				events = append(events, event.EventType)
+			}
+			if !reflect.DeepEqual(events, ts.expectedEvents) {
+				t.Fatalf("expected events %v but got %v", ts.expectedEvents, events)
+			}
+			if !reflect.DeepEqual(output.Input.Entries, ts.expectedEntries) || !reflect.DeepEqual(output.Input.TakeProfits, ts.expectedTakeProfits) || output.Input.StopLoss != ts.expectedStopLoss {
+				t.Fatalf("expected resolved entries %v, take profits %v and stop loss %v, but got %v, %v and %v",
+					ts.expectedEntries, ts.expectedTakeProfits, ts.expectedStopLoss, output.Input.Entries, output.Input.TakeProfits, output.Input.StopLoss)
+			}
+		})
+	}
+}
@@ -4,18 +4,19 @@ import (
 	"github.com/marianogappa/signal-checker/common"
 )
 
-func buildTickIterator(f func() (common.Candlestick, error)) func() (common.Tick, error) {
-	return newTickIterator(f).next
+func buildTickIterator(f func() (common.Candlestick, error), toTicks func(common.Candlestick) []common.Tick) func() (common.Tick, error) {
+	return newTickIterator(f, toTicks).next
 }
 
 type tickIterator struct {
 	f        func() (common.Candlestick, error)
+	toTicks  func(common.Candlestick) []common.Tick
 	ticks    []common.Tick
 	lastTick common.Tick
 }
 
-func newTickIterator(f func() (common.Candlestick, error)) *tickIterator {
-	return &tickIterator{f: f}
+func newTickIterator(f func() (common.Candlestick, error), toTicks func(common.Candlestick) []common.Tick) *tickIterator {
+	return &tickIterator{f: f, toTicks: toTicks}
 }
 
 // N.B. When next() hits an error, it returns the previous (last) tick.
@@ -32,6 +33,6 @@ func (it *tickIterator) next() (common.Tick, error) {
 	if err != nil {
 		return it.lastTick, err
 	}
-	it.ticks = append(it.ticks, candlestick.ToTicks()...)
+	it.ticks = append(it.ticks, it.toTicks(candlestick)...)
 	return it.next()
 }
@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/marianogappa/signal-checker/common"
+	"github.com/marianogappa/signal-checker/offline"
 )
 
 func invalidateWith(err error, input common.SignalCheckInput) (common.SignalCheckOutput, error) {
@@ -35,35 +36,96 @@ func validateInput(input common.SignalCheckInput) (common.SignalCheckOutput, err
 	if sum(input.EntryRatios) != 1.0 {
 		return invalidateWith(common.ErrEntryRatiosMustAddUpToOne, input)
 	}
-	if len(input.Entries) == 1 {
+	if len(input.Entries) == 1 || len(input.EntryPercents) == 1 {
 		return invalidateWith(common.ErrInvalidEntriesLength, input)
 	}
-	if !input.IsShort {
-		sort.Slice(input.TakeProfits, func(i, j int) bool { return input.TakeProfits[i] < input.TakeProfits[j] })
-		sort.Slice(input.Entries, func(i, j int) bool { return input.Entries[i] > input.Entries[j] })
-	} else {
-		sort.Slice(input.TakeProfits, func(i, j int) bool { return input.TakeProfits[i] > input.TakeProfits[j] })
-		sort.Slice(input.Entries, func(i, j int) bool { return input.Entries[i] < input.Entries[j] })
+	if (len(input.Entries) > 0 && len(input.EntryPercents) > 0) || (len(input.TakeProfits) > 0 && len(input.TakeProfitPercents) > 0) ||
+		(input.StopLoss != 0 && input.StopLossPercent != 0) {
+		return invalidateWith(common.ErrPricesAndPercentsBothSet, input)
 	}
-	if !input.IsShort && input.StopLoss != -1 && len(input.Entries) > 0 && input.StopLoss >= input.Entries[len(input.Entries)-1] {
-		return invalidateWith(common.ErrStopLossIsGreaterThanOrEqualToEnterRangeLow, input)
+	sortLevels(input.IsShort, input.Entries, input.TakeProfits)
+	sortLevels(input.IsShort, input.EntryPercents, input.TakeProfitPercents)
+	stopLoss := input.StopLoss
+	if input.StopLossPercent != 0 {
+		stopLoss = -1
 	}
-	if input.IsShort && input.StopLoss != -1 && len(input.Entries) > 0 && input.StopLoss <= input.Entries[len(input.Entries)-1] {
-		return invalidateWith(common.ErrStopLossIsLessThanOrEqualToEnterRangeHigh, input)
+	if err := validateLevels(input.IsShort, input.Entries, input.TakeProfits, stopLoss); err != nil {
+		return invalidateWith(err, input)
 	}
-	if !input.IsShort && len(input.Entries) > 0 && len(input.TakeProfits) > 0 && input.TakeProfits[0] <= input.Entries[0] {
-		return invalidateWith(common.ErrFirstTPIsLessThanOrEqualToEnterRangeHigh, input)
+	input.PercentsRelativeTo = strings.ToLower(input.PercentsRelativeTo)
+	if input.PercentsRelativeTo == "" {
+		input.PercentsRelativeTo = common.PERCENTS_RELATIVE_TO_FIRST_CANDLE
 	}
-	if input.IsShort && len(input.Entries) > 0 && len(input.TakeProfits) > 0 && input.TakeProfits[0] >= input.Entries[0] {
-		return invalidateWith(common.ErrFirstTPIsGreaterThanOrEqualToEnterRangeLow, input)
+	if err := validatePercents(input); err != nil {
+		return invalidateWith(err, input)
 	}
 	if input.Exchange == "" {
 		input.Exchange = "binance"
 	}
-	if input.Exchange != "binance" && input.Exchange != "ftx" && input.Exchange != "coinbase" &&
-		input.Exchange != "huobi" && input.Exchange != "kraken" && input.Exchange != "kucoin" &&
-		input.Exchange != "binanceusdmfutures" && input.Exchange != "fake" {
-		return invalidateWith(common.ErrInvalidExchange, input)
+	exchange, ok := getExchange(input)
+	if !ok && input.Exchange != common.FAKE {
+		return invalidateWith(invalidExchangeError(), input)
+	}
+	if _, isOffline := exchange.(*offline.Offline); isOffline && input.DataDir == "" {
+		return invalidateWith(common.ErrDataDirRequired, input)
+	}
+	if !input.DontApplyFees {
+		if input.MakerFeeRatio == nil {
+			input.MakerFeeRatio = common.NewJsonFloat64(common.CapabilitiesOf(exchange).Fees.Maker)
+		}
+		if input.TakerFeeRatio == nil {
+			input.TakerFeeRatio = common.NewJsonFloat64(common.CapabilitiesOf(exchange).Fees.Taker)
+		}
+	}
+	for _, feeRatio := range []*common.JsonFloat64{input.MakerFeeRatio, input.TakerFeeRatio} {
+		if feeRatio != nil && (*feeRatio < 0 || *feeRatio >= 1) {
+			return invalidateWith(common.ErrFeeRatiosMustBeBetweenZeroAndOne, input)
+		}
+	}
+	input.SlippageModel = strings.ToLower(input.SlippageModel)
+	if input.SlippageModel != "" && input.SlippageModel != common.SLIPPAGE_MODEL_FIXED && input.SlippageModel != common.SLIPPAGE_MODEL_VOLUME {
+		return invalidateWith(common.ErrInvalidSlippageModel, input)
+	}
+	if input.SlippageBps < 0 {
+		return invalidateWith(common.ErrSlippageBpsMustNotBeNegative, input)
+	}
+	if input.SlippageModel == common.SLIPPAGE_MODEL_VOLUME && input.Capital <= 0 {
+		return invalidateWith(common.ErrCapitalRequiredForVolumeSlippage, input)
+	}
+	if input.Leverage == 0 {
+		input.Leverage = 1
+	}
+	if input.Leverage < 1 {
+		return invalidateWith(common.ErrLeverageMustBeAtLeastOne, input)
+	}
+	input.MarginMode = strings.ToLower(input.MarginMode)
+	if input.MarginMode == "" {
+		input.MarginMode = common.MARGIN_MODE_ISOLATED
+	}
+	if input.MarginMode != common.MARGIN_MODE_ISOLATED && input.MarginMode != common.MARGIN_MODE_CROSS {
+		return invalidateWith(common.ErrInvalidMarginMode, input)
+	}
+	if input.Leverage > 1 && input.MaintenanceMarginRatio == 0 {
+		input.MaintenanceMarginRatio = 0.004
+	}
+	if input.MaintenanceMarginRatio < 0 || input.MaintenanceMarginRatio*input.Leverage >= 1 {
+		return invalidateWith(common.ErrMaintenanceMarginRatioInvalid, input)
+	}
+	input.IntraCandleResolution = strings.ToLower(input.IntraCandleResolution)
+	if input.IntraCandleResolution == "" {
+		input.IntraCandleResolution = common.INTRA_CANDLE_RESOLUTION_LOW_FIRST
+	}
+	if input.IntraCandleResolution != common.INTRA_CANDLE_RESOLUTION_LOW_FIRST &&
+		input.IntraCandleResolution != common.INTRA_CANDLE_RESOLUTION_PESSIMISTIC &&
+		input.IntraCandleResolution != common.INTRA_CANDLE_RESOLUTION_OPTIMISTIC &&
+		input.IntraCandleResolution != common.INTRA_CANDLE_RESOLUTION_EXACT {
+		return invalidateWith(common.ErrInvalidIntraCandleResolution, input)
+	}
+	if input.EquityCurveSampleSeconds < 0 {
+		return invalidateWith(common.ErrEquityCurveSampleSecondsMustNotBeNegative, input)
+	}
+	if input.EquityCurveSampleSeconds == 0 {
+		input.EquityCurveSampleSeconds = 3600
 	}
 	if input.InitialISO8601 == "" {
 		return invalidateWith(common.ErrInitialISO8601Required, input)
@@ -77,5 +139,125 @@ func validateInput(input common.SignalCheckInput) (common.SignalCheckOutput, err
 	if len(input.TakeProfitRatios) > 0 && sum(input.TakeProfitRatios) != 1.0 {
 		return invalidateWith(common.ErrTakeProfitRatiosMustAddUpToOne, input)
 	}
+	if input.TrailingStopLossDistance < 0 || input.TrailingStopLossRatio < 0 {
+		return invalidateWith(common.ErrTrailingStopLossMustNotBeNegative, input)
+	}
+	if input.TrailingStopLossRatio >= 1 {
+		return invalidateWith(common.ErrTrailingStopLossRatioMustBeLessThanOne, input)
+	}
+	if input.TrailingStopLossDistance > 0 && input.TrailingStopLossRatio > 0 {
+		return invalidateWith(common.ErrOnlyOneTrailingStopLossAllowed, input)
+	}
+	// The movements are copied, so that the caller's input isn't modified, and the legacy flags are cleared once
+	// converted, so that validating the validated input again doesn't convert them twice.
+	input.StopLossMovements = append(append([]common.StopLossMovement{}, input.StopLossMovements...), legacyStopLossMovements(input)...)
+	input.IfTP1StopAtEntry, input.IfTP2StopAtTP1, input.IfTP3StopAtTP2, input.IfTP4StopAtTP3 = false, false, false, false
+	for _, movement := range input.StopLossMovements {
+		if err := validateStopLossMovement(movement, input); err != nil {
+			return invalidateWith(err, input)
+		}
+	}
+	sort.SliceStable(input.StopLossMovements, func(i, j int) bool {
+		return input.StopLossMovements[i].WhenTakeProfit < input.StopLossMovements[j].WhenTakeProfit
+	})
 	return common.SignalCheckOutput{Input: input}, nil
 }
+
+// sortLevels sorts entries and take profits in the order they are reached, i.e. entries from the highest and take
+// profits from the lowest for a LONG, and the opposite for a SHORT.
+func sortLevels(isShort bool, entries, takeProfits []common.JsonFloat64) {
+	if !isShort {
+		sort.Slice(takeProfits, func(i, j int) bool { return takeProfits[i] < takeProfits[j] })
+		sort.Slice(entries, func(i, j int) bool { return entries[i] > entries[j] })
+	} else {
+		sort.Slice(takeProfits, func(i, j int) bool { return takeProfits[i] > takeProfits[j] })
+		sort.Slice(entries, func(i, j int) bool { return entries[i] < entries[j] })
+	}
+}
+
+// validateLevels checks that the sorted entries, take profits and stop loss (-1 or 0 for no stop loss) don't overlap.
+func validateLevels(isShort bool, entries, takeProfits []common.JsonFloat64, stopLoss common.JsonFloat64) error {
+	if !isShort && stopLoss != -1 && len(entries) > 0 && stopLoss >= entries[len(entries)-1] {
+		return common.ErrStopLossIsGreaterThanOrEqualToEnterRangeLow
+	}
+	if isShort && stopLoss != -1 && stopLoss != 0 && len(entries) > 0 && stopLoss <= entries[len(entries)-1] {
+		return common.ErrStopLossIsLessThanOrEqualToEnterRangeHigh
+	}
+	if !isShort && len(entries) > 0 && len(takeProfits) > 0 && takeProfits[0] <= entries[0] {
+		return common.ErrFirstTPIsLessThanOrEqualToEnterRangeHigh
+	}
+	if isShort && len(entries) > 0 && len(takeProfits) > 0 && takeProfits[0] >= entries[0] {
+		return common.ErrFirstTPIsGreaterThanOrEqualToEnterRangeLow
+	}
+	return nil
+}
+
+// validatePercents checks the percents the same way as prices, when they share their reference price. Otherwise, it
+// checks that the take profits and stop loss are on the right side of the entry.
+func validatePercents(input common.SignalCheckInput) error {
+	percents := append(append([]common.JsonFloat64{input.StopLossPercent}, input.EntryPercents...), input.TakeProfitPercents...)
+	for _, percent := range percents {
+		if percent <= -100 {
+			return common.ErrPercentsMustBeGreaterThanMinusOneHundred
+		}
+	}
+	stopLoss := common.JsonFloat64(-1)
+	if input.StopLossPercent != 0 {
+		stopLoss = resolvePercent(1, input.StopLossPercent)
+	}
+	switch input.PercentsRelativeTo {
+	case common.PERCENTS_RELATIVE_TO_FIRST_CANDLE:
+		return validateLevels(input.IsShort, resolvePercents(1, input.EntryPercents), resolvePercents(1, input.TakeProfitPercents), stopLoss)
+	case common.PERCENTS_RELATIVE_TO_ENTRY:
+		for _, percent := range input.TakeProfitPercents {
+			if (!input.IsShort && percent <= 0) || (input.IsShort && percent >= 0) {
+				return common.ErrTakeProfitPercentsMustBeInFavour
+			}
+		}
+		if (!input.IsShort && input.StopLossPercent > 0) || (input.IsShort && input.StopLossPercent < 0) {
+			return common.ErrStopLossPercentMustBeAgainst
+		}
+		return nil
+	}
+	return common.ErrInvalidPercentsRelativeTo
+}
+
+// legacyStopLossMovements converts the IfTPnStopAt* flags into their equivalent stop loss movements. Flags whose
+// take profit doesn't exist on the input are dropped, as they could never trigger.
+func legacyStopLossMovements(input common.SignalCheckInput) []common.StopLossMovement {
+	movements := []common.StopLossMovement{}
+	if input.IfTP1StopAtEntry && input.TakeProfitCount() >= 1 {
+		movements = append(movements, common.StopLossMovement{WhenTakeProfit: 1, MoveTo: common.MOVE_STOP_LOSS_TO_ENTRY})
+	}
+	flags := []bool{input.IfTP2StopAtTP1, input.IfTP3StopAtTP2, input.IfTP4StopAtTP3}
+	for i, flag := range flags {
+		whenTakeProfit := i + 2
+		if flag && input.TakeProfitCount() >= whenTakeProfit {
+			movements = append(movements, common.StopLossMovement{WhenTakeProfit: whenTakeProfit, MoveTo: common.MOVE_STOP_LOSS_TO_TAKE_PROFIT, TakeProfit: whenTakeProfit - 1})
+		}
+	}
+	return movements
+}
+
+func validateStopLossMovement(movement common.StopLossMovement, input common.SignalCheckInput) error {
+	if movement.WhenTakeProfit < 1 {
+		return common.ErrStopLossMovementWhenTakeProfitInvalid
+	}
+	if movement.WhenTakeProfit > input.TakeProfitCount() {
+		return common.ErrStopLossMovementWhenTakeProfitDoesNotExist
+	}
+	switch movement.MoveTo {
+	case common.MOVE_STOP_LOSS_TO_ENTRY, common.MOVE_STOP_LOSS_TO_RATIO:
+	case common.MOVE_STOP_LOSS_TO_TAKE_PROFIT:
+		if movement.TakeProfit < 1 || movement.TakeProfit > input.TakeProfitCount() {
+			return common.ErrStopLossMovementTakeProfitInvalid
+		}
+	case common.MOVE_STOP_LOSS_TO_PRICE:
+		if movement.Price <= 0 {
+			return common.ErrStopLossMovementPriceInvalid
+		}
+	default:
+		return common.ErrStopLossMovementMoveToInvalid
+	}
+	return nil
+}
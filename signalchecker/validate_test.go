@@ -1,6 +1,9 @@
 package signalchecker
 
 import (
+	"encoding/json"
+	"errors"
+	"reflect"
 	"testing"
 
 	"github.com/marianogappa/signal-checker/common"
@@ -127,6 +130,38 @@ func TestValidate(t *testing.T) {
 			},
 			expectedErr: common.ErrInvalidExchange,
 		},
+		{
+			name: "Offline exchange without data dir",
+			input: common.SignalCheckInput{
+				BaseAsset:              "BTC",
+				QuoteAsset:             "USDT",
+				Exchange:               "offline",
+				IsShort:                false,
+				Entries:                []common.JsonFloat64{f(3.0), f(2.0)},
+				StopLoss:               f(1.0),
+				InitialISO8601:         startISO8601,
+				InvalidateISO8601:      "",
+				InvalidateAfterSeconds: 10,
+				TakeProfits:            []common.JsonFloat64{},
+				TakeProfitRatios:       []common.JsonFloat64{},
+			},
+			expectedErr: common.ErrDataDirRequired,
+		},
+		{
+			name: "Negative equity curve sample seconds",
+			input: common.SignalCheckInput{
+				BaseAsset:                "BTC",
+				QuoteAsset:               "USDT",
+				Entries:                  []common.JsonFloat64{f(3.0), f(2.0)},
+				StopLoss:                 f(1.0),
+				InitialISO8601:           startISO8601,
+				InvalidateAfterSeconds:   10,
+				TakeProfits:              []common.JsonFloat64{},
+				TakeProfitRatios:         []common.JsonFloat64{},
+				EquityCurveSampleSeconds: -1,
+			},
+			expectedErr: common.ErrEquityCurveSampleSecondsMustNotBeNegative,
+		},
 		{
 			name: "InitialISO8601 empty",
 			input: common.SignalCheckInput{
@@ -211,11 +246,291 @@ func TestValidate(t *testing.T) {
 			},
 			expectedErr: common.ErrQuoteAssetRequired,
 		},
+		{
+			name: "trailing stop loss must not be negative",
+			input: common.SignalCheckInput{
+				BaseAsset:                "BTC",
+				QuoteAsset:               "USDT",
+				Entries:                  []common.JsonFloat64{f(3.0), f(2.0)},
+				StopLoss:                 f(1.0),
+				TrailingStopLossDistance: f(-0.5),
+				InitialISO8601:           startISO8601,
+			},
+			expectedErr: common.ErrTrailingStopLossMustNotBeNegative,
+		},
+		{
+			name: "trailing stop loss ratio must be less than one",
+			input: common.SignalCheckInput{
+				BaseAsset:             "BTC",
+				QuoteAsset:            "USDT",
+				Entries:               []common.JsonFloat64{f(3.0), f(2.0)},
+				StopLoss:              f(1.0),
+				TrailingStopLossRatio: f(1.0),
+				InitialISO8601:        startISO8601,
+			},
+			expectedErr: common.ErrTrailingStopLossRatioMustBeLessThanOne,
+		},
+		{
+			name: "only one trailing stop loss allowed",
+			input: common.SignalCheckInput{
+				BaseAsset:                "BTC",
+				QuoteAsset:               "USDT",
+				Entries:                  []common.JsonFloat64{f(3.0), f(2.0)},
+				StopLoss:                 f(1.0),
+				TrailingStopLossDistance: f(0.5),
+				TrailingStopLossRatio:    f(0.1),
+				InitialISO8601:           startISO8601,
+			},
+			expectedErr: common.ErrOnlyOneTrailingStopLossAllowed,
+		},
+		{
+			name: "stop loss movement with invalid moveTo",
+			input: common.SignalCheckInput{
+				BaseAsset:         "BTC",
+				QuoteAsset:        "USDT",
+				Entries:           []common.JsonFloat64{f(3.0), f(2.0)},
+				StopLoss:          f(1.0),
+				TakeProfits:       []common.JsonFloat64{5.0, 6.0},
+				StopLossMovements: []common.StopLossMovement{{WhenTakeProfit: 1, MoveTo: "moon"}},
+				InitialISO8601:    startISO8601,
+			},
+			expectedErr: common.ErrStopLossMovementMoveToInvalid,
+		},
+		{
+			name: "stop loss movement without a take profit to trigger it",
+			input: common.SignalCheckInput{
+				BaseAsset:         "BTC",
+				QuoteAsset:        "USDT",
+				Entries:           []common.JsonFloat64{f(3.0), f(2.0)},
+				StopLoss:          f(1.0),
+				TakeProfits:       []common.JsonFloat64{5.0, 6.0},
+				StopLossMovements: []common.StopLossMovement{{WhenTakeProfit: 0, MoveTo: common.MOVE_STOP_LOSS_TO_ENTRY}},
+				InitialISO8601:    startISO8601,
+			},
+			expectedErr: common.ErrStopLossMovementWhenTakeProfitInvalid,
+		},
+		{
+			name: "stop loss movement when a non-existent take profit is reached",
+			input: common.SignalCheckInput{
+				BaseAsset:         "BTC",
+				QuoteAsset:        "USDT",
+				Entries:           []common.JsonFloat64{f(3.0), f(2.0)},
+				StopLoss:          f(1.0),
+				TakeProfits:       []common.JsonFloat64{5.0, 6.0},
+				StopLossMovements: []common.StopLossMovement{{WhenTakeProfit: 3, MoveTo: common.MOVE_STOP_LOSS_TO_ENTRY}},
+				InitialISO8601:    startISO8601,
+			},
+			expectedErr: common.ErrStopLossMovementWhenTakeProfitDoesNotExist,
+		},
+		{
+			name: "stop loss movement to a non-existent take profit",
+			input: common.SignalCheckInput{
+				BaseAsset:         "BTC",
+				QuoteAsset:        "USDT",
+				Entries:           []common.JsonFloat64{f(3.0), f(2.0)},
+				StopLoss:          f(1.0),
+				TakeProfits:       []common.JsonFloat64{5.0, 6.0},
+				StopLossMovements: []common.StopLossMovement{{WhenTakeProfit: 2, MoveTo: common.MOVE_STOP_LOSS_TO_TAKE_PROFIT, TakeProfit: 3}},
+				InitialISO8601:    startISO8601,
+			},
+			expectedErr: common.ErrStopLossMovementTakeProfitInvalid,
+		},
+		{
+			name: "stop loss movement to an invalid price",
+			input: common.SignalCheckInput{
+				BaseAsset:         "BTC",
+				QuoteAsset:        "USDT",
+				Entries:           []common.JsonFloat64{f(3.0), f(2.0)},
+				StopLoss:          f(1.0),
+				TakeProfits:       []common.JsonFloat64{5.0, 6.0},
+				StopLossMovements: []common.StopLossMovement{{WhenTakeProfit: 2, MoveTo: common.MOVE_STOP_LOSS_TO_PRICE}},
+				InitialISO8601:    startISO8601,
+			},
+			expectedErr: common.ErrStopLossMovementPriceInvalid,
+		},
+		{
+			name: "fee ratios must be between zero and one",
+			input: common.SignalCheckInput{
+				BaseAsset:      "BTC",
+				QuoteAsset:     "USDT",
+				Entries:        []common.JsonFloat64{f(3.0), f(2.0)},
+				StopLoss:       f(1.0),
+				TakerFeeRatio:  common.NewJsonFloat64(-0.001),
+				InitialISO8601: startISO8601,
+			},
+			expectedErr: common.ErrFeeRatiosMustBeBetweenZeroAndOne,
+		},
+		{
+			name: "invalid slippage model",
+			input: common.SignalCheckInput{
+				BaseAsset:      "BTC",
+				QuoteAsset:     "USDT",
+				Entries:        []common.JsonFloat64{f(3.0), f(2.0)},
+				StopLoss:       f(1.0),
+				SlippageModel:  "random",
+				InitialISO8601: startISO8601,
+			},
+			expectedErr: common.ErrInvalidSlippageModel,
+		},
+		{
+			name: "slippage bps must not be negative",
+			input: common.SignalCheckInput{
+				BaseAsset:      "BTC",
+				QuoteAsset:     "USDT",
+				Entries:        []common.JsonFloat64{f(3.0), f(2.0)},
+				StopLoss:       f(1.0),
+				SlippageModel:  common.SLIPPAGE_MODEL_FIXED,
+				SlippageBps:    f(-1),
+				InitialISO8601: startISO8601,
+			},
+			expectedErr: common.ErrSlippageBpsMustNotBeNegative,
+		},
+		{
+			name: "volume slippage requires capital",
+			input: common.SignalCheckInput{
+				BaseAsset:      "BTC",
+				QuoteAsset:     "USDT",
+				Entries:        []common.JsonFloat64{f(3.0), f(2.0)},
+				StopLoss:       f(1.0),
+				SlippageModel:  common.SLIPPAGE_MODEL_VOLUME,
+				SlippageBps:    f(10),
+				InitialISO8601: startISO8601,
+			},
+			expectedErr: common.ErrCapitalRequiredForVolumeSlippage,
+		},
+		{
+			name: "leverage below 1",
+			input: common.SignalCheckInput{
+				BaseAsset:      "BTC",
+				QuoteAsset:     "USDT",
+				Entries:        []common.JsonFloat64{f(3.0), f(2.0)},
+				StopLoss:       f(1.0),
+				Leverage:       f(0.5),
+				InitialISO8601: startISO8601,
+			},
+			expectedErr: common.ErrLeverageMustBeAtLeastOne,
+		},
+		{
+			name: "invalid margin mode",
+			input: common.SignalCheckInput{
+				BaseAsset:      "BTC",
+				QuoteAsset:     "USDT",
+				Entries:        []common.JsonFloat64{f(3.0), f(2.0)},
+				StopLoss:       f(1.0),
+				Leverage:       f(10),
+				MarginMode:     "portfolio",
+				InitialISO8601: startISO8601,
+			},
+			expectedErr: common.ErrInvalidMarginMode,
+		},
+		{
+			name: "maintenance margin ratio that liquidates immediately",
+			input: common.SignalCheckInput{
+				BaseAsset:              "BTC",
+				QuoteAsset:             "USDT",
+				Entries:                []common.JsonFloat64{f(3.0), f(2.0)},
+				StopLoss:               f(1.0),
+				Leverage:               f(10),
+				MaintenanceMarginRatio: f(0.1),
+				InitialISO8601:         startISO8601,
+			},
+			expectedErr: common.ErrMaintenanceMarginRatioInvalid,
+		},
+		{
+			name: "invalid intra candle resolution",
+			input: common.SignalCheckInput{
+				BaseAsset:             "BTC",
+				QuoteAsset:            "USDT",
+				Entries:               []common.JsonFloat64{f(3.0), f(2.0)},
+				StopLoss:              f(1.0),
+				IntraCandleResolution: "random",
+				InitialISO8601:        startISO8601,
+			},
+			expectedErr: common.ErrInvalidIntraCandleResolution,
+		},
+		{
+			name: "entries and entry percents both set",
+			input: common.SignalCheckInput{
+				BaseAsset:      "BTC",
+				QuoteAsset:     "USDT",
+				Entries:        []common.JsonFloat64{f(3.0), f(2.0)},
+				EntryPercents:  []common.JsonFloat64{f(0), f(-2)},
+				InitialISO8601: startISO8601,
+			},
+			expectedErr: common.ErrPricesAndPercentsBothSet,
+		},
+		{
+			name: "percents of -100 or less",
+			input: common.SignalCheckInput{
+				BaseAsset:       "BTC",
+				QuoteAsset:      "USDT",
+				StopLossPercent: f(-100),
+				InitialISO8601:  startISO8601,
+			},
+			expectedErr: common.ErrPercentsMustBeGreaterThanMinusOneHundred,
+		},
+		{
+			name: "invalid percents relative to",
+			input: common.SignalCheckInput{
+				BaseAsset:          "BTC",
+				QuoteAsset:         "USDT",
+				TakeProfitPercents: []common.JsonFloat64{f(3)},
+				PercentsRelativeTo: "moon",
+				InitialISO8601:     startISO8601,
+			},
+			expectedErr: common.ErrInvalidPercentsRelativeTo,
+		},
+		{
+			name: "(LONG) stop loss percent is >= entry percents low",
+			input: common.SignalCheckInput{
+				BaseAsset:       "BTC",
+				QuoteAsset:      "USDT",
+				EntryPercents:   []common.JsonFloat64{f(0), f(-2)},
+				StopLossPercent: f(-1),
+				InitialISO8601:  startISO8601,
+			},
+			expectedErr: common.ErrStopLossIsGreaterThanOrEqualToEnterRangeLow,
+		},
+		{
+			name: "(SHORT) TP1 percent is >= entry percents low",
+			input: common.SignalCheckInput{
+				BaseAsset:          "BTC",
+				QuoteAsset:         "USDT",
+				IsShort:            true,
+				EntryPercents:      []common.JsonFloat64{f(0), f(2)},
+				TakeProfitPercents: []common.JsonFloat64{f(1)},
+				InitialISO8601:     startISO8601,
+			},
+			expectedErr: common.ErrFirstTPIsGreaterThanOrEqualToEnterRangeLow,
+		},
+		{
+			name: "(SHORT) take profit percent relative to the entry must be negative",
+			input: common.SignalCheckInput{
+				BaseAsset:          "BTC",
+				QuoteAsset:         "USDT",
+				IsShort:            true,
+				TakeProfitPercents: []common.JsonFloat64{f(-3), f(3)},
+				PercentsRelativeTo: common.PERCENTS_RELATIVE_TO_ENTRY,
+				InitialISO8601:     startISO8601,
+			},
+			expectedErr: common.ErrTakeProfitPercentsMustBeInFavour,
+		},
+		{
+			name: "(LONG) stop loss percent relative to the entry must be negative",
+			input: common.SignalCheckInput{
+				BaseAsset:          "BTC",
+				QuoteAsset:         "USDT",
+				StopLossPercent:    f(5),
+				PercentsRelativeTo: common.PERCENTS_RELATIVE_TO_ENTRY,
+				InitialISO8601:     startISO8601,
+			},
+			expectedErr: common.ErrStopLossPercentMustBeAgainst,
+		},
 	}
 	for _, ts := range tss {
 		t.Run(ts.name, func(t *testing.T) {
 			_, actualErr := validateInput(ts.input)
-			if actualErr != ts.expectedErr {
+			if !errors.Is(actualErr, ts.expectedErr) {
 				t.Errorf("Expected error %v, but got error %v", ts.expectedErr, actualErr)
 				t.FailNow()
 			}
@@ -263,3 +578,103 @@ func TestLowercasesExchangeNames(t *testing.T) {
 		t.Errorf("validation did not lowercase exchange")
 	}
 }
+
+func TestConvertsLegacyStopLossFlagsIntoMovements(t *testing.T) {
+	startISO8601 := common.ISO8601("2021-07-04T14:14:18Z")
+
+	validatedInput, err := validateInput(common.SignalCheckInput{
+		BaseAsset:        "BTC",
+		QuoteAsset:       "USDT",
+		Entries:          []common.JsonFloat64{f(3.0), f(2.0)},
+		StopLoss:         f(1.0),
+		TakeProfits:      []common.JsonFloat64{5.0, 6.0, 7.0},
+		InitialISO8601:   startISO8601,
+		IfTP1StopAtEntry: true,
+		IfTP3StopAtTP2:   true,
+		IfTP4StopAtTP3:   true, // There's no TP4, so it's dropped
+	})
+	if err != nil {
+		t.Fatalf("validation returned error %v", err)
+	}
+	expected := []common.StopLossMovement{
+		{WhenTakeProfit: 1, MoveTo: common.MOVE_STOP_LOSS_TO_ENTRY},
+		{WhenTakeProfit: 3, MoveTo: common.MOVE_STOP_LOSS_TO_TAKE_PROFIT, TakeProfit: 2},
+	}
+	if !reflect.DeepEqual(validatedInput.Input.StopLossMovements, expected) {
+		t.Errorf("expected StopLossMovements = %v but got %v", expected, validatedInput.Input.StopLossMovements)
+	}
+}
+
+func TestDefaultsFeesToTheExchangeFees(t *testing.T) {
+	startISO8601 := common.ISO8601("2021-07-04T14:14:18Z")
+
+	validatedInput, err := validateInput(common.SignalCheckInput{
+		BaseAsset:      "BTC",
+		QuoteAsset:     "USDT",
+		Exchange:       "kraken",
+		Entries:        []common.JsonFloat64{f(3.0), f(2.0)},
+		StopLoss:       f(1.0),
+		TakerFeeRatio:  common.NewJsonFloat64(0.005),
+		InitialISO8601: startISO8601,
+	})
+	if err != nil {
+		t.Fatalf("validation returned error %v", err)
+	}
+	if *validatedInput.Input.MakerFeeRatio != common.DefaultFees[common.KRAKEN].Maker {
+		t.Errorf("expected maker fee ratio to default to Kraken's, but was %v", *validatedInput.Input.MakerFeeRatio)
+	}
+	if *validatedInput.Input.TakerFeeRatio != f(0.005) {
+		t.Errorf("expected taker fee ratio to be overridden, but was %v", *validatedInput.Input.TakerFeeRatio)
+	}
+}
+
+func TestKeepsZeroFees(t *testing.T) {
+	input := common.SignalCheckInput{}
+	if err := json.Unmarshal([]byte(`{"baseAsset":"BTC","quoteAsset":"USDT","exchange":"kraken","initialISO8601":"2021-07-04T14:14:18Z","makerFeeRatio":0}`), &input); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	validatedInput, err := validateInput(input)
+	if err != nil {
+		t.Fatalf("validation returned error %v", err)
+	}
+	if *validatedInput.Input.MakerFeeRatio != 0 {
+		t.Errorf("expected a maker fee ratio of 0 to be kept, but was %v", *validatedInput.Input.MakerFeeRatio)
+	}
+	if *validatedInput.Input.TakerFeeRatio != common.DefaultFees[common.KRAKEN].Taker {
+		t.Errorf("expected taker fee ratio to default to Kraken's, but was %v", *validatedInput.Input.TakerFeeRatio)
+	}
+}
+
+func TestConvertsLegacyStopLossFlagsWithoutModifyingTheInput(t *testing.T) {
+	movements := make([]common.StopLossMovement, 1, 2)
+	movements[0] = common.StopLossMovement{WhenTakeProfit: 2, MoveTo: common.MOVE_STOP_LOSS_TO_ENTRY}
+	input := common.SignalCheckInput{
+		BaseAsset:         "BTC",
+		QuoteAsset:        "USDT",
+		Entries:           []common.JsonFloat64{f(3.0), f(2.0)},
+		StopLoss:          f(1.0),
+		TakeProfits:       []common.JsonFloat64{5.0, 6.0},
+		InitialISO8601:    "2021-07-04T14:14:18Z",
+		StopLossMovements: movements,
+		IfTP1StopAtEntry:  true,
+	}
+	validatedInput, err := validateInput(input)
+	if err != nil {
+		t.Fatalf("validation returned error %v", err)
+	}
+	revalidatedInput, err := validateInput(validatedInput.Input)
+	if err != nil {
+		t.Fatalf("validation returned error %v", err)
+	}
+
+	expected := []common.StopLossMovement{
+		{WhenTakeProfit: 1, MoveTo: common.MOVE_STOP_LOSS_TO_ENTRY},
+		{WhenTakeProfit: 2, MoveTo: common.MOVE_STOP_LOSS_TO_ENTRY},
+	}
+	if !reflect.DeepEqual(revalidatedInput.Input.StopLossMovements, expected) {
+		t.Errorf("expected StopLossMovements = %v after validating twice, but got %v", expected, revalidatedInput.Input.StopLossMovements)
+	}
+	if !reflect.DeepEqual(movements[:2], []common.StopLossMovement{{WhenTakeProfit: 2, MoveTo: common.MOVE_STOP_LOSS_TO_ENTRY}, {}}) {
+		t.Errorf("expected the input's StopLossMovements not to be modified, but they were %v", movements[:2])
+	}
+}
@@ -0,0 +1,204 @@
+package signalchecker
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/marianogappa/signal-checker/common"
+)
+
+// zoomResolutionSeconds is the duration of the coarse candlesticks that checks scan with, i.e. one hour.
+const zoomResolutionSeconds = 3600
+
+// canZoom answers if the check can scan with coarse candlesticks. Returned candlesticks must be the one minute ones,
+// so it can't when the input asks for them.
+func (c SignalChecker) canZoom(capabilities common.ExchangeCapabilities) bool {
+	_, ok := c.exchange.(common.MultiResolutionExchange)
+	return ok && capabilities.SupportsResolution(zoomResolutionSeconds) && !c.input.DontZoom && !c.input.ReturnCandlesticks
+}
+
+// zoomingIterator iterates the exchange's coarse candlesticks, but zooms into the one minute candlesticks within those
+// that canSkip says might trigger something. The checker applies the coarse candlesticks it skips as they are, which
+// only tracks their excursion. Thus, it reaches the same output that it would by iterating one minute candlesticks.
+type zoomingIterator struct {
+	ctx                   context.Context
+	exchange              common.Exchange
+	baseAsset, quoteAsset string
+	initialSeconds        int
+	resolutionSeconds     int
+	canSkip               func(candlestick common.Candlestick, durationSeconds int) bool
+
+	coarse       *common.CandlestickIterator
+	started      bool
+	lookahead    common.Candlestick
+	lookaheadErr error
+
+	// The one minute candlesticks being zoomed into, from the start of the coarse candlestick to its end, or to the
+	// end of the dataset if to is 0. overflow is the candlestick after the end, read before knowing where it was.
+	fine        *common.CandlestickIterator
+	zooming     bool
+	from, to    int
+	overflow    common.Candlestick
+	hasOverflow bool
+}
+
+func newZoomingIterator(ctx context.Context, exchange common.Exchange, input common.SignalCheckInput, resolutionSeconds int, canSkip func(common.Candlestick, int) bool) *zoomingIterator {
+	// N.B. already validated
+	initial, _ := input.InitialISO8601.Time()
+	initialSeconds := int(initial.Unix())
+	coarseInitialISO8601 := common.ISO8601(time.Unix(int64(initialSeconds-initialSeconds%resolutionSeconds), 0).UTC().Format(time.RFC3339))
+	return &zoomingIterator{
+		ctx:               ctx,
+		exchange:          exchange,
+		baseAsset:         input.BaseAsset,
+		quoteAsset:        input.QuoteAsset,
+		initialSeconds:    initialSeconds,
+		resolutionSeconds: resolutionSeconds,
+		canSkip:           canSkip,
+		coarse:            exchange.(common.MultiResolutionExchange).BuildCandlestickIteratorAtResolution(ctx, input.BaseAsset, input.QuoteAsset, coarseInitialISO8601, resolutionSeconds),
+	}
+}
+
+func (it *zoomingIterator) next() (common.Candlestick, error) {
+	for {
+		if it.zooming {
+			c, err := it.nextFine()
+			if err != nil {
+				return c, err
+			}
+			if c.Timestamp < it.from {
+				continue
+			}
+			if it.to != 0 && c.Timestamp >= it.to {
+				it.overflow, it.hasOverflow, it.zooming = c, true, false
+				continue
+			}
+			return c, nil
+		}
+
+		if !it.started {
+			it.started = true
+			it.lookahead, it.lookaheadErr = it.coarse.Next()
+		}
+		c, err := it.lookahead, it.lookaheadErr
+		if err == common.ErrOutOfCandlesticks {
+			// There are no coarse candlesticks at all (e.g. they are not available yet), so use the one minute ones.
+			it.zoom(it.initialSeconds-it.initialSeconds%it.resolutionSeconds, 0)
+			continue
+		}
+		if err != nil {
+			return c, err
+		}
+		it.lookahead, it.lookaheadErr = it.coarse.Next()
+		if it.lookaheadErr == common.ErrOutOfCandlesticks {
+			// The last coarse candlestick may be incomplete, and the dataset finishes on the last one minute candlestick,
+			// so zoom into it and use the one minute candlesticks from then on.
+			it.zoom(c.Timestamp, 0)
+			continue
+		}
+		if it.canSkip(c, it.resolutionSeconds) {
+			return c, nil
+		}
+		it.zoom(c.Timestamp, c.Timestamp+it.resolutionSeconds)
+	}
+}
+
+// zoom starts iterating the one minute candlesticks from from to to (or to the end, if to is 0). If the previous
+// zoom ended at from, its iterator is reused. Otherwise, it's closed, e.g. so that the cache stores what it fetched.
+func (it *zoomingIterator) zoom(from, to int) {
+	if it.fine == nil || it.to == 0 || it.to != from {
+		if it.fine != nil {
+			it.fine.Close()
+		}
+		initialSeconds := from
+		if initialSeconds < it.initialSeconds {
+			initialSeconds = it.initialSeconds
+		}
+		initialISO8601 := common.ISO8601(time.Unix(int64(initialSeconds), 0).UTC().Format(time.RFC3339))
+		it.fine = it.exchange.BuildCandlestickIterator(it.ctx, it.baseAsset, it.quoteAsset, initialISO8601)
+		it.hasOverflow = false
+	}
+	it.from, it.to, it.zooming = from, to, true
+}
+
+// close closes the coarse and one minute iterators.
+func (it *zoomingIterator) close() {
+	it.coarse.Close()
+	if it.fine != nil {
+		it.fine.Close()
+	}
+}
+
+func (it *zoomingIterator) nextFine() (common.Candlestick, error) {
+	if it.hasOverflow {
+		it.hasOverflow = false
+		return it.overflow, nil
+	}
+	return it.fine.Next()
+}
+
+// canSkip answers if applying the candlestick's ticks can't trigger any event, move the stop loss, apply funding rates
+// or increase the drawdown, whatever the one minute candlesticks within it are. If so, applying its ticks only tracks
+// the excursion, which depends on its lowest and highest prices alone.
+func (s *checkSignalState) canSkip(candlestick common.Candlestick, durationSeconds int) bool {
+	var (
+		low, high = candlestick.LowestPrice, candlestick.HighestPrice
+		end       = candlestick.Timestamp + durationSeconds
+		entries   = s.input.Entries
+		tps       = s.input.TakeProfits
+		isShort   = s.input.IsShort
+		h         = s.highestEntry
+	)
+	if s.first || s.isEnded || s.input.ReturnEquityCurve || (s.hasInvalidAt && int64(end) > s.invalidAt.Unix()) {
+		return false
+	}
+	if s.fundingRates != nil && (!s.hasPendingFundingRate || s.pendingFundingRate.Timestamp < end) {
+		return false
+	}
+
+	// These mirror applyTick's conditions for invalidating and entering.
+	if h == 0 && len(entries) == 0 {
+		return false
+	}
+	if s.input.InvalidateIfTPBeforeEntering && h == 0 && len(entries) > 0 && len(tps) > 0 &&
+		((!isShort && high >= tps[0]) || (isShort && low <= tps[0])) {
+		return false
+	}
+	if len(entries) >= h+2 && ((!isShort && high >= entries[h+1] && low < entries[h]) || (isShort && high > entries[h] && low <= entries[h+1])) {
+		return false
+	}
+	if h == 0 {
+		return true
+	}
+
+	// And these, for trailing the stop loss, being liquidated, stopping loss and taking profit.
+	if s.input.TrailingStopLossDistance != 0 || s.input.TrailingStopLossRatio != 0 {
+		return false
+	}
+	if liquidationPrice, ok := s.profitCalculator.LiquidationPrice(); ok &&
+		(s.isLiquidatedAt(low, common.JsonFloat64(liquidationPrice)) || s.isLiquidatedAt(high, common.JsonFloat64(liquidationPrice))) {
+		return false
+	}
+	if (!isShort && low <= s.stopLoss) || (isShort && s.stopLoss != -1 && high >= s.stopLoss) {
+		return false
+	}
+	if s.highestTakeProfit < len(tps) && ((!isShort && high >= tps[s.highestTakeProfit]) || (isShort && low <= tps[s.highestTakeProfit])) {
+		return false
+	}
+	return !s.mightIncreaseDrawdown(low, high)
+}
+
+// mightIncreaseDrawdown answers if prices between low and high could increase the maximum drawdown, in any order. As
+// the profit ratio is monotonic on the price, the worst case is reaching the best equity first, and the worst after.
+func (s *checkSignalState) mightIncreaseDrawdown(low, high common.JsonFloat64) bool {
+	e := s.excursion
+	if !e.entered {
+		return true
+	}
+	lowEquity := 1 + s.profitCalculator.ProfitRatioAt(float64(low))
+	highEquity := 1 + s.profitCalculator.ProfitRatioAt(float64(high))
+	peakEquity := math.Max(e.peakEquity, math.Max(lowEquity, highEquity))
+	worstEquity := math.Min(lowEquity, highEquity)
+	return peakEquity > 0 && (peakEquity-worstEquity)/peakEquity > e.maxDrawdown
+}
@@ -0,0 +1,294 @@
+package signalchecker
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"math/rand"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/marianogappa/signal-checker/cache"
+	"github.com/marianogappa/signal-checker/common"
+	"github.com/marianogappa/signal-checker/fake"
+)
+
+// randomWalk returns count one minute candlesticks from startSeconds, with prices starting at 100.
+func randomWalk(r *rand.Rand, startSeconds, count int) []common.Candlestick {
+	candlesticks := []common.Candlestick{}
+	price := 100.0
+	for i := 0; i < count; i++ {
+		open := price
+		price *= 1 + r.NormFloat64()*0.001
+		candlesticks = append(candlesticks, common.Candlestick{
+			Timestamp:    startSeconds + i*60,
+			OpenPrice:    f(open),
+			ClosePrice:   f(price),
+			LowestPrice:  f(math.Min(open, price) * (1 - r.Float64()*0.001)),
+			HighestPrice: f(math.Max(open, price) * (1 + r.Float64()*0.001)),
+			Volume:       f(1 + r.Float64()),
+		})
+	}
+	return candlesticks
+}
+
+// randomInput returns a signal around the price, which is usually entered, and then reaches some of its levels.
+func randomInput(r *rand.Rand, initialSeconds int, price float64) common.SignalCheckInput {
+	side := 1.0
+	input := common.SignalCheckInput{
+		Exchange:                 "fake",
+		BaseAsset:                "BTC",
+		QuoteAsset:               "USDT",
+		InitialISO8601:           common.ISO8601(time.Unix(int64(initialSeconds), 0).UTC().Format(time.RFC3339)),
+		IsShort:                  r.Intn(2) == 0,
+		DontCalculateMaxEnterUSD: true,
+	}
+	if input.IsShort {
+		side = -1.0
+	}
+	level := func(ratio float64) common.JsonFloat64 { return f(price * (1 + side*ratio)) }
+
+	entry := r.Float64() * 0.02
+	if r.Intn(4) > 0 {
+		input.Entries = []common.JsonFloat64{level(entry), level(-0.01 - r.Float64()*0.02)}
+		if r.Intn(2) == 0 {
+			input.Entries = append(input.Entries, level(-0.04-r.Float64()*0.02))
+			input.EntryRatios = []common.JsonFloat64{0.25, 0.25, 0.5}
+		}
+	}
+	input.StopLoss = level(-0.07 - r.Float64()*0.05)
+	for i := 0; i < 1+r.Intn(3); i++ {
+		input.TakeProfits = append(input.TakeProfits, level(entry+0.01+float64(i)*0.02+r.Float64()*0.02))
+	}
+	if r.Intn(3) == 0 {
+		input.StopLossMovements = []common.StopLossMovement{{WhenTakeProfit: 1, MoveTo: common.MOVE_STOP_LOSS_TO_ENTRY}}
+	}
+	if r.Intn(3) == 0 {
+		input.InvalidateAfterSeconds = 3600 * (1 + r.Intn(48))
+	}
+	if r.Intn(4) == 0 {
+		input.InvalidateIfTPBeforeEntering = true
+	}
+	if r.Intn(4) == 0 {
+		input.Leverage = f(float64(5 + r.Intn(20)))
+	}
+	if r.Intn(5) == 0 {
+		input.TrailingStopLossRatio = f(0.01 + r.Float64()*0.03)
+	}
+	if r.Intn(3) == 0 {
+		input.IntraCandleResolution = common.INTRA_CANDLE_RESOLUTION_OPTIMISTIC
+	}
+	return input
+}
+
+func TestZoomingMatchesOneMinuteCheck(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	startSeconds, _ := common.ISO8601("2021-07-04T00:00:00Z").Seconds()
+	candlesticks := randomWalk(r, startSeconds, 3*24*60)
+
+	fundingRates := []common.FundingRate{}
+	for i := 0; i < 3*3; i++ {
+		fundingRates = append(fundingRates, common.FundingRate{Timestamp: startSeconds + i*8*3600, Rate: f(r.NormFloat64() * 0.0001)})
+	}
+
+	var zoomedCandlesticks, oneMinuteCandlesticks int
+	for i := 0; i < 200; i++ {
+		initial := r.Intn(24 * 60)
+		input := randomInput(r, startSeconds+initial*60+r.Intn(60), float64(candlesticks[initial].OpenPrice))
+		var mockFundingRates []common.FundingRate
+		if r.Intn(3) == 0 {
+			mockFundingRates = fundingRates
+		}
+
+		var progress Progress
+		zoomed := NewSignalChecker(input, WithProgress(func(p Progress) { progress = p }))
+		zoomed.mockCandlesticks = candlesticks[initial:]
+		zoomed.mockFundingRates = mockFundingRates
+		zoomedOutput, zoomedErr := zoomed.Check()
+		zoomedCandlesticks += progress.Candlesticks
+
+		input.DontZoom = true
+		oneMinute := NewSignalChecker(input, WithProgress(func(p Progress) { progress = p }))
+		oneMinute.mockCandlesticks = candlesticks[initial:]
+		oneMinute.mockFundingRates = mockFundingRates
+		expected, expectedErr := oneMinute.Check()
+		oneMinuteCandlesticks += progress.Candlesticks
+
+		// N.B. outputs are compared as strings, as some ratios are NaN when the signal doesn't enter.
+		zoomedOutput.Input.DontZoom = true
+		if zoomedErr != expectedErr || fmt.Sprintf("%+v", zoomedOutput) != fmt.Sprintf("%+v", expected) {
+			t.Fatalf("for input %+v, expected the zoomed check to output\n%+v\nbut it output\n%+v", input, expected, zoomedOutput)
+		}
+	}
+	if zoomedCandlesticks >= oneMinuteCandlesticks*3/4 {
+		t.Fatalf("expected zoomed checks to process fewer candlesticks, but they processed %v vs %v", zoomedCandlesticks, oneMinuteCandlesticks)
+	}
+}
+
+func TestZoomingIterator(t *testing.T) {
+	startSeconds, _ := common.ISO8601("2021-07-04T00:00:00Z").Seconds()
+	candlesticks := randomWalk(rand.New(rand.NewSource(1)), startSeconds, 4*60)
+	input := common.SignalCheckInput{BaseAsset: "BTC", QuoteAsset: "USDT", InitialISO8601: "2021-07-04T00:30:00Z"}
+	exchange := fake.NewFake(candlesticks, nil, nil, nil)
+
+	type test struct {
+		name               string
+		zoomInto           map[int]bool
+		expectedTimestamps []int
+	}
+	minutes := func(from, to int) []int {
+		timestamps := []int{}
+		for i := from; i < to; i++ {
+			timestamps = append(timestamps, startSeconds+i*60)
+		}
+		return timestamps
+	}
+	tss := []test{
+		{
+			name:               "Zooms into the first and last hours only",
+			zoomInto:           map[int]bool{},
+			expectedTimestamps: append(append(minutes(0, 60), startSeconds+3600, startSeconds+7200), minutes(180, 240)...),
+		},
+		{
+			name:               "Zooms into consecutive hours",
+			zoomInto:           map[int]bool{1: true, 2: true},
+			expectedTimestamps: minutes(0, 240),
+		},
+		{
+			name:               "Zooms into non-consecutive hours",
+			zoomInto:           map[int]bool{2: true},
+			expectedTimestamps: append(append(minutes(0, 60), startSeconds+3600), minutes(120, 240)...),
+		},
+	}
+	for _, ts := range tss {
+		t.Run(ts.name, func(t *testing.T) {
+			canSkip := func(c common.Candlestick, durationSeconds int) bool {
+				hour := (c.Timestamp - startSeconds) / 3600
+				return hour > 0 && !ts.zoomInto[hour]
+			}
+			it := newZoomingIterator(context.Background(), exchange, input, 3600, canSkip)
+			timestamps := []int{}
+			for {
+				c, err := it.next()
+				if err == common.ErrOutOfCandlesticks {
+					break
+				}
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				timestamps = append(timestamps, c.Timestamp)
+			}
+			if !reflect.DeepEqual(timestamps, ts.expectedTimestamps) {
+				t.Fatalf("expected timestamps %v but got %v", ts.expectedTimestamps, timestamps)
+			}
+		})
+	}
+}
+
+// seekingExchange is a fake exchange whose candlesticks start at the requested initial time, like the real ones.
+type seekingExchange struct {
+	*fake.Fake
+}
+
+func (e seekingExchange) BuildCandlestickIterator(ctx context.Context, baseAsset, quoteAsset string, initialISO8601 common.ISO8601) *common.CandlestickIterator {
+	return seek(e.Fake.BuildCandlestickIterator(ctx, baseAsset, quoteAsset, initialISO8601), initialISO8601)
+}
+
+func (e seekingExchange) BuildCandlestickIteratorAtResolution(ctx context.Context, baseAsset, quoteAsset string, initialISO8601 common.ISO8601, resolutionSeconds int) *common.CandlestickIterator {
+	return seek(e.Fake.BuildCandlestickIteratorAtResolution(ctx, baseAsset, quoteAsset, initialISO8601, resolutionSeconds), initialISO8601)
+}
+
+func seek(it *common.CandlestickIterator, initialISO8601 common.ISO8601) *common.CandlestickIterator {
+	initialSeconds, _ := initialISO8601.Seconds()
+	return common.NewCandlestickIterator(func() (common.Candlestick, error) {
+		for {
+			c, err := it.Next()
+			if err != nil || c.Timestamp >= initialSeconds {
+				return c, err
+			}
+		}
+	})
+}
+
+func TestZoomingStoresCandlesticksOnTheCache(t *testing.T) {
+	dir := t.TempDir()
+	startSeconds, _ := common.ISO8601("2021-07-04T00:00:00Z").Seconds()
+	candlesticks := randomWalk(rand.New(rand.NewSource(1)), startSeconds, 24*60)
+	// Prices are rounded, so that they survive being stored as JSON.
+	round := func(price common.JsonFloat64) common.JsonFloat64 {
+		return common.JsonFloat64(math.Round(float64(price)*100) / 100)
+	}
+	for i, c := range candlesticks {
+		candlesticks[i].OpenPrice, candlesticks[i].ClosePrice = round(c.OpenPrice), round(c.ClosePrice)
+		candlesticks[i].LowestPrice, candlesticks[i].HighestPrice = round(c.LowestPrice), round(c.HighestPrice)
+		candlesticks[i].Volume = round(c.Volume)
+	}
+	input, _ := validateInput(common.SignalCheckInput{
+		Exchange:                 "fake",
+		BaseAsset:                "BTC",
+		QuoteAsset:               "USDT",
+		InitialISO8601:           "2021-07-04T00:30:00Z",
+		TakeProfits:              []common.JsonFloat64{f(200)},
+		StopLoss:                 f(50),
+		DontCalculateMaxEnterUSD: true,
+	})
+	check := func(exchange common.Exchange) common.SignalCheckOutput {
+		c := NewSignalChecker(input.Input)
+		c.exchange = cache.NewCache(exchange, "fake", dir)
+		output, err := c.doCheck(context.Background())
+		if err != nil && err != common.ErrOutOfCandlesticks {
+			t.Fatalf("check should have succeeded, but failed with %v", err)
+		}
+		return output
+	}
+	expected := check(seekingExchange{fake.NewFake(candlesticks, nil, nil, nil)})
+
+	for _, pairDir := range []string{"BTC-USDT", "BTC-USDT@3600s"} {
+		files, _ := ioutil.ReadDir(filepath.Join(dir, "fake", pairDir))
+		if len(files) == 0 {
+			t.Fatalf("expected chunks on %v, but there were none", pairDir)
+		}
+	}
+	// N.B. outputs are compared as strings, as some ratios are NaN.
+	if actual := check(seekingExchange{fake.NewFake(nil, nil, nil, nil)}); fmt.Sprintf("%+v", actual) != fmt.Sprintf("%+v", expected) {
+		t.Fatalf("expected the check served from the cache to output\n%+v\nbut it output\n%+v", expected, actual)
+	}
+}
+
+// closeCountingExchange counts the one minute candlestick iterators that are built and closed.
+type closeCountingExchange struct {
+	seekingExchange
+	built, closed *int
+}
+
+func (e closeCountingExchange) BuildCandlestickIterator(ctx context.Context, baseAsset, quoteAsset string, initialISO8601 common.ISO8601) *common.CandlestickIterator {
+	*e.built++
+	it := e.seekingExchange.BuildCandlestickIterator(ctx, baseAsset, quoteAsset, initialISO8601)
+	return common.NewClosableCandlestickIterator(it.Next, func() { *e.closed++ })
+}
+
+func TestZoomingClosesEveryOneMinuteIterator(t *testing.T) {
+	startSeconds, _ := common.ISO8601("2021-07-04T00:00:00Z").Seconds()
+	candlesticks := randomWalk(rand.New(rand.NewSource(1)), startSeconds, 24*60)
+	input, _ := validateInput(common.SignalCheckInput{
+		Exchange:                 "fake",
+		BaseAsset:                "BTC",
+		QuoteAsset:               "USDT",
+		InitialISO8601:           "2021-07-04T00:30:00Z",
+		TakeProfits:              []common.JsonFloat64{f(200)},
+		StopLoss:                 f(50),
+		DontCalculateMaxEnterUSD: true,
+	})
+	var built, closed int
+	c := NewSignalChecker(input.Input)
+	c.exchange = closeCountingExchange{seekingExchange{fake.NewFake(candlesticks, nil, nil, nil)}, &built, &closed}
+	if _, err := c.doCheck(context.Background()); err != nil && err != common.ErrOutOfCandlesticks {
+		t.Fatalf("check should have succeeded, but failed with %v", err)
+	}
+	if built == 0 || built != closed {
+		t.Fatalf("expected every one minute iterator to be closed, but %v were built and %v closed", built, closed)
+	}
+}
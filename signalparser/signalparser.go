@@ -0,0 +1,436 @@
+// The signalparser package converts free-text signal messages (e.g. as posted on Telegram channels) into
+// common.SignalCheckInputs.
+//
+// It understands single-line messages like "BTC/USDT LONG Entry: 30000-29500 Targets: 31000, 32000 SL: 28500", Cornix
+// style blocks with numbered targets under "Entry Targets:", "Take-Profit Targets:" and "Stop Targets:" headers, and
+// emoji-heavy variants of both.
+//
+// Use it like this: output := signalparser.Parse(message)
+//
+// Note that messages don't say when they were posted, so the output's InitialISO8601 must be set before checking it.
+package signalparser
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/marianogappa/signal-checker/common"
+)
+
+// Output is the result of parsing a signal message.
+type Output struct {
+	// Input is the signal check input, with the fields that could be understood from the message.
+	Input common.SignalCheckInput `json:"input"`
+
+	// Unparsed are the lines (or parts of lines) of the message that couldn't be understood.
+	Unparsed []string `json:"unparsed"`
+
+	// Missing are the input fields that couldn't be found on the message, e.g. stopLoss.
+	Missing []string `json:"missing"`
+
+	// Warnings are issues with the understood fields, which may need to be fixed before checking the signal.
+	Warnings []string `json:"warnings"`
+}
+
+const (
+	fieldPair        = "pair"
+	fieldDirection   = "direction"
+	fieldEntries     = "entries"
+	fieldTakeProfits = "takeProfits"
+	fieldStopLoss    = "stopLoss"
+	fieldLeverage    = "leverage"
+	fieldExchange    = "exchange"
+)
+
+// labels maps the labels found on messages to the fields they precede. Longer labels go first, so that they win
+// over their prefixes (e.g. "stop targets" over "targets"). Numbered labels like "Target 1:" need a separator after the
+// number, so that "Targets 10 12" isn't read as target 10.
+var labels = []struct {
+	pattern string
+	field   string
+}{
+	{`take[\s-]*profit\s+targets?`, fieldTakeProfits},
+	{`take[\s-]*profits?`, fieldTakeProfits},
+	{`profit\s+targets?`, fieldTakeProfits},
+	{`stop\s+targets?`, fieldStopLoss},
+	{`stop[\s-]*loss`, fieldStopLoss},
+	{`entry\s+targets?`, fieldEntries},
+	{`entry\s+zone`, fieldEntries},
+	{`entry\s+price`, fieldEntries},
+	{`entries`, fieldEntries},
+	{`entry`, fieldEntries},
+	{`buy\s+zone`, fieldEntries},
+	{`sell\s+zone`, fieldEntries},
+	{`buy`, fieldEntries},
+	{`sell`, fieldEntries},
+	{`targets?(?:\s*\d{1,2}\s*[:)=]|\d*)`, fieldTakeProfits},
+	{`tps?(?:\s*\d{1,2}\s*[:)=]|\d*)`, fieldTakeProfits},
+	{`stop`, fieldStopLoss},
+	{`sl`, fieldStopLoss},
+	{`signal\s+type`, fieldDirection},
+	{`direction`, fieldDirection},
+	{`position`, fieldDirection},
+	{`side`, fieldDirection},
+	{`leverage`, fieldLeverage},
+	{`lev`, fieldLeverage},
+	{`exchanges?`, fieldExchange},
+	{`pair`, fieldPair},
+	{`coin`, fieldPair},
+	{`symbol`, fieldPair},
+}
+
+var (
+	labelRegexp       = buildLabelRegexp()
+	labelFieldRegexps = buildLabelFieldRegexps()
+
+	// Cornix-style messages end with a trailing configuration, whose targets aren't the signal's.
+	trailingConfigurationRegexp = regexp.MustCompile(`(?i)trailing\s+configuration`)
+
+	// Pairs like BTC/USDT, btc-usdt or #BTCUSDT. Without a separator, only uppercase pairs are accepted, so that
+	// regular words aren't mistaken for pairs.
+	quotes                 = `USDT|BUSD|USDC|TUSD|USD|BTC|ETH|BNB|EUR`
+	pairWithSeparatorRegex = regexp.MustCompile(`(?i)(?:^|[^a-z0-9])[#$]?([a-z][a-z0-9]{0,9})\s*[/\-_]\s*(` + quotes + `)(?:[^a-z0-9]|$)`)
+	pairRegexp             = regexp.MustCompile(`(?:^|[^A-Za-z0-9])[#$]?([A-Z][A-Z0-9]{0,9}?)(` + quotes + `)(?:[^A-Za-z0-9]|$)`)
+
+	numberRegexp           = regexp.MustCompile(`\d+(?:\.\d+)?`)
+	thousandsRegexp        = regexp.MustCompile(`(\d),(\d{3})\b`)
+	enumerationRegexp      = regexp.MustCompile(`^\s*(?:\(?\d+\)|\d+[.:]\s|\d+\s+-\s)\s*`)
+	percentageRegexp       = regexp.MustCompile(`\(?[+-]?\d+(?:\.\d+)?\s*%\)?`)
+	leverageRegexp         = regexp.MustCompile(`(?i)(\d+(?:\.\d+)?)\s*x|x\s*(\d+(?:\.\d+)?)`)
+	directionRegexp        = regexp.MustCompile(`(?i)\b(long|short|buy|sell)\b`)
+	emojiReplacer          = strings.NewReplacer("🎯", " target: ", "🛑", " stop: ", "⛔", " stop: ", "–", "-", "—", "-", "：", ":")
+	meaninglessWordsRegexp = regexp.MustCompile(`(?i)\b(signal|new|call|vip|free|futures|spot|regular|now|at|and|zone|market|cmp|price|usdt|from|to|only|min|max)\b`)
+)
+
+func buildLabelRegexp() *regexp.Regexp {
+	patterns := []string{}
+	for _, label := range labels {
+		patterns = append(patterns, label.pattern)
+	}
+	return regexp.MustCompile(`(?i)(?:^|[^a-z0-9])(` + strings.Join(patterns, "|") + `)(?:\s*[:=\-]|\s+|$)`)
+}
+
+func buildLabelFieldRegexps() []*regexp.Regexp {
+	regexps := []*regexp.Regexp{}
+	for _, label := range labels {
+		regexps = append(regexps, regexp.MustCompile(`(?i)^(?:`+label.pattern+`)$`))
+	}
+	return regexps
+}
+
+func fieldOf(label string) string {
+	for i, r := range labelFieldRegexps {
+		if r.MatchString(label) {
+			return labels[i].field
+		}
+	}
+	return ""
+}
+
+// parser holds the state while parsing a message.
+type parser struct {
+	output      Output
+	found       map[string]bool
+	section     string
+	ignoreRest  bool
+	isShort     bool
+	entries     []float64
+	takeProfits []float64
+	stopLoss    float64
+}
+
+// Parse converts a signal message into a signal check input, reporting what it couldn't understand.
+func Parse(message string) Output {
+	p := &parser{
+		output: Output{Unparsed: []string{}, Missing: []string{}, Warnings: []string{}},
+		found:  map[string]bool{},
+	}
+	for _, line := range strings.Split(normalize(message), "\n") {
+		p.parseLine(line)
+	}
+	p.build()
+	return p.output
+}
+
+// normalize replaces meaningful emojis with their labels, removes the other ones, and removes thousands separators.
+func normalize(message string) string {
+	message = emojiReplacer.Replace(message)
+	message = strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '\n' || strings.ContainsRune(".,:;/()-+%#$=_!?'&", r) {
+			return r
+		}
+		if unicode.IsSpace(r) {
+			return ' '
+		}
+		return -1
+	}, message)
+	for {
+		replaced := thousandsRegexp.ReplaceAllString(message, "$1$2")
+		if replaced == message {
+			return message
+		}
+		message = replaced
+	}
+}
+
+func (p *parser) parseLine(line string) {
+	line = strings.TrimSpace(line)
+	if line == "" || !strings.ContainsAny(line, "0123456789") && !containsLetter(line) {
+		return
+	}
+	if p.ignoreRest || trailingConfigurationRegexp.MatchString(line) {
+		p.ignoreRest = true
+		p.output.Unparsed = append(p.output.Unparsed, line)
+		return
+	}
+
+	matches := labelRegexp.FindAllStringSubmatchIndex(line, -1)
+	if len(matches) == 0 {
+		// Numbered lines under a header, e.g. "1) 30000" under "Entry Targets:".
+		if p.section != "" && (enumerationRegexp.MatchString(line) || isOnlyNumbers(line)) {
+			if p.parseField(p.section, enumerationRegexp.ReplaceAllString(line, "")) {
+				return
+			}
+		}
+		p.section = ""
+		if !p.parseFreeText(line) {
+			p.output.Unparsed = append(p.output.Unparsed, line)
+		}
+		return
+	}
+
+	// Text before the first label, e.g. "BTC/USDT LONG" in "BTC/USDT LONG Entry: 30000-29500".
+	if before := strings.TrimSpace(line[:matches[0][2]]); before != "" && !p.parseFreeText(before) {
+		p.output.Unparsed = append(p.output.Unparsed, before)
+	}
+	for i, match := range matches {
+		label := line[match[2]:match[3]]
+		end := len(line)
+		if i+1 < len(matches) {
+			end = matches[i+1][2]
+		}
+		rest := strings.TrimSpace(line[match[1]:end])
+		field := fieldOf(label)
+		p.section = field
+		// e.g. "Buy: 30000" is also a direction.
+		if direction := directionRegexp.FindString(label); direction != "" {
+			p.setDirection(direction)
+		}
+		if rest == "" {
+			// A header, whose values are on the following lines.
+			continue
+		}
+		if !p.parseField(field, rest) {
+			p.output.Unparsed = append(p.output.Unparsed, strings.TrimSpace(line[match[2]:end]))
+		}
+	}
+}
+
+// parseFreeText finds the pair and the direction in text without labels.
+func (p *parser) parseFreeText(text string) bool {
+	parsed := p.parsePair(text)
+	if direction := directionRegexp.FindString(text); direction != "" {
+		p.setDirection(direction)
+		parsed = true
+	}
+	return parsed
+}
+
+// parseField parses the text after a label, and answers if it could be understood.
+func (p *parser) parseField(field, text string) bool {
+	switch field {
+	case fieldEntries, fieldTakeProfits, fieldStopLoss:
+		numbers := parseNumbers(text)
+		if len(numbers) == 0 {
+			// e.g. "Entry: market" or "Buy: now".
+			return strings.Trim(meaninglessWordsRegexp.ReplaceAllString(text, ""), " ,;:-/()") == "" || p.parseFreeText(text)
+		}
+		switch field {
+		case fieldEntries:
+			p.entries = append(p.entries, numbers...)
+		case fieldTakeProfits:
+			p.takeProfits = append(p.takeProfits, numbers...)
+		case fieldStopLoss:
+			if !p.found[fieldStopLoss] {
+				p.stopLoss = numbers[0]
+			}
+		}
+		p.found[field] = true
+		return true
+	case fieldDirection:
+		direction := directionRegexp.FindString(text)
+		if direction == "" {
+			return false
+		}
+		p.setDirection(direction)
+		return true
+	case fieldLeverage:
+		return p.parseLeverage(text)
+	case fieldExchange:
+		return p.parseExchange(text)
+	case fieldPair:
+		return p.parsePair(text)
+	}
+	return false
+}
+
+func (p *parser) parsePair(text string) bool {
+	if p.found[fieldPair] {
+		return false
+	}
+	match := pairWithSeparatorRegex.FindStringSubmatch(text)
+	if match == nil {
+		match = pairRegexp.FindStringSubmatch(text)
+	}
+	if match == nil {
+		return false
+	}
+	p.output.Input.BaseAsset = strings.ToUpper(match[1])
+	p.output.Input.QuoteAsset = strings.ToUpper(match[2])
+	p.found[fieldPair] = true
+	return true
+}
+
+func (p *parser) setDirection(direction string) {
+	if p.found[fieldDirection] {
+		return
+	}
+	direction = strings.ToLower(direction)
+	p.isShort = direction == "short" || direction == "sell"
+	p.found[fieldDirection] = true
+}
+
+func (p *parser) parseLeverage(text string) bool {
+	parsed := false
+	lower := strings.ToLower(text)
+	if strings.Contains(lower, "cross") {
+		p.output.Input.MarginMode = common.MARGIN_MODE_CROSS
+		parsed = true
+	}
+	if strings.Contains(lower, "isolated") {
+		p.output.Input.MarginMode = common.MARGIN_MODE_ISOLATED
+		parsed = true
+	}
+	leverage := 0.0
+	if match := leverageRegexp.FindStringSubmatch(text); match != nil {
+		leverage, _ = strconv.ParseFloat(match[1]+match[2], 64)
+	} else if numbers := parseNumbers(text); len(numbers) > 0 {
+		leverage = numbers[0]
+	}
+	if leverage > 0 {
+		p.output.Input.Leverage = common.JsonFloat64(leverage)
+		p.found[fieldLeverage] = true
+		parsed = true
+	}
+	return parsed
+}
+
+// parseExchange sets the first supported exchange mentioned on the text.
+func (p *parser) parseExchange(text string) bool {
+	lower := strings.ToLower(text)
+	for _, name := range strings.FieldsFunc(lower, func(r rune) bool { return r == ',' || r == '/' || r == '|' }) {
+		name = strings.TrimSpace(name)
+		switch {
+		case strings.Contains(name, "binance") && strings.Contains(name, "futures"):
+			p.output.Input.Exchange = common.BINANCE_USDM_FUTURES
+		case strings.Contains(name, "binance"):
+			p.output.Input.Exchange = common.BINANCE
+		case strings.Contains(name, "kucoin"):
+			p.output.Input.Exchange = common.KUCOIN
+		case strings.Contains(name, "ftx"):
+			p.output.Input.Exchange = common.FTX
+		case strings.Contains(name, "kraken"):
+			p.output.Input.Exchange = common.KRAKEN
+		case strings.Contains(name, "coinbase"):
+			p.output.Input.Exchange = common.COINBASE
+		default:
+			continue
+		}
+		p.found[fieldExchange] = true
+		return true
+	}
+	return false
+}
+
+// build sets the understood prices on the input, ordering them according to the direction, and reports what's missing.
+func (p *parser) build() {
+	input := &p.output.Input
+
+	// If the direction isn't explicit, it can be inferred from the prices.
+	if !p.found[fieldDirection] && len(p.takeProfits) > 0 {
+		reference := p.stopLoss
+		if len(p.entries) > 0 {
+			reference = p.entries[0]
+		}
+		if reference != 0 && p.takeProfits[0] < reference {
+			p.isShort = true
+			p.found[fieldDirection] = true
+		}
+		if reference != 0 && p.takeProfits[0] > reference {
+			p.found[fieldDirection] = true
+		}
+	}
+	input.IsShort = p.isShort
+
+	sort.Float64s(p.entries)
+	sort.Float64s(p.takeProfits)
+	if !p.isShort {
+		reverse(p.entries)
+	} else {
+		reverse(p.takeProfits)
+	}
+	input.Entries = toJsonFloat64s(p.entries)
+	input.TakeProfits = toJsonFloat64s(p.takeProfits)
+	input.StopLoss = common.JsonFloat64(p.stopLoss)
+
+	for _, field := range []string{fieldPair, fieldDirection, fieldEntries, fieldTakeProfits, fieldStopLoss} {
+		if !p.found[field] {
+			p.output.Missing = append(p.output.Missing, field)
+		}
+	}
+	if len(p.entries) == 1 {
+		p.output.Warnings = append(p.output.Warnings, "only one entry price was found, but entries must be a range of at least two prices (e.g. [30000, 29500])")
+	}
+}
+
+func parseNumbers(text string) []float64 {
+	text = percentageRegexp.ReplaceAllString(text, "")
+	numbers := []float64{}
+	for _, s := range numberRegexp.FindAllString(text, -1) {
+		number, err := strconv.ParseFloat(s, 64)
+		if err == nil && number > 0 {
+			numbers = append(numbers, number)
+		}
+	}
+	return numbers
+}
+
+func isOnlyNumbers(text string) bool {
+	return strings.Trim(numberRegexp.ReplaceAllString(percentageRegexp.ReplaceAllString(text, ""), ""), " ,;-/") == ""
+}
+
+func containsLetter(text string) bool {
+	for _, r := range text {
+		if unicode.IsLetter(r) {
+			return true
+		}
+	}
+	return false
+}
+
+func reverse(fs []float64) {
+	for i, j := 0, len(fs)-1; i < j; i, j = i+1, j-1 {
+		fs[i], fs[j] = fs[j], fs[i]
+	}
+}
+
+func toJsonFloat64s(fs []float64) []common.JsonFloat64 {
+	jfs := []common.JsonFloat64{}
+	for _, f := range fs {
+		jfs = append(jfs, common.JsonFloat64(f))
+	}
+	return jfs
+}
@@ -0,0 +1,144 @@
+package signalparser
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/marianogappa/signal-checker/common"
+)
+
+func f(fl float64) common.JsonFloat64 {
+	return common.JsonFloat64(fl)
+}
+
+func TestParse(t *testing.T) {
+	type test struct {
+		name     string
+		message  string
+		expected Output
+	}
+	tss := []test{
+		{
+			name:    "Single line",
+			message: "BTC/USDT LONG Entry: 30000-29500 Targets: 31000, 32000 SL: 28500",
+			expected: Output{
+				Input: common.SignalCheckInput{
+					BaseAsset:   "BTC",
+					QuoteAsset:  "USDT",
+					Entries:     []common.JsonFloat64{f(30000), f(29500)},
+					TakeProfits: []common.JsonFloat64{f(31000), f(32000)},
+					StopLoss:    f(28500),
+				},
+				Unparsed: []string{},
+				Missing:  []string{},
+				Warnings: []string{},
+			},
+		},
+		{
+			name: "Cornix",
+			message: `⚡️⚡️ #ETH/USDT ⚡️⚡️
+Exchanges: Binance Futures
+Signal Type: Regular (Short)
+Leverage: Cross (20.0X)
+
+Entry Targets:
+1) 2,000
+2) 2,050
+
+Take-Profit Targets:
+1) 1,950
+2) 1,900
+3) 1,800
+
+Stop Targets:
+1) 2,100
+
+Trailing Configuration:
+Stop: Breakeven -
+ Trigger: Target (1)`,
+			expected: Output{
+				Input: common.SignalCheckInput{
+					Exchange:    "binanceusdmfutures",
+					BaseAsset:   "ETH",
+					QuoteAsset:  "USDT",
+					IsShort:     true,
+					Leverage:    f(20),
+					MarginMode:  "cross",
+					Entries:     []common.JsonFloat64{f(2000), f(2050)},
+					TakeProfits: []common.JsonFloat64{f(1950), f(1900), f(1800)},
+					StopLoss:    f(2100),
+				},
+				Unparsed: []string{"Trailing Configuration:", "Stop: Breakeven -", "Trigger: Target (1)"},
+				Missing:  []string{},
+				Warnings: []string{},
+			},
+		},
+		{
+			name: "Emoji-heavy",
+			message: `🚀🚀 #SOLUSDT 🚀🚀
+📈 BUY ZONE: 20.5 - 21.2
+🎯 22.0
+🎯 23.5 (+10%)
+🎯 25
+🛑 19.8
+Lev 10x 🔥`,
+			expected: Output{
+				Input: common.SignalCheckInput{
+					BaseAsset:   "SOL",
+					QuoteAsset:  "USDT",
+					Leverage:    f(10),
+					Entries:     []common.JsonFloat64{f(21.2), f(20.5)},
+					TakeProfits: []common.JsonFloat64{f(22), f(23.5), f(25)},
+					StopLoss:    f(19.8),
+				},
+				Unparsed: []string{},
+				Missing:  []string{},
+				Warnings: []string{},
+			},
+		},
+		{
+			name: "Numbered take profits and an inferred short",
+			message: `ADA-USDT
+Entry: 0.50
+TP1: 0.48
+TP2: 0.45
+Stop loss: 0.53
+Good luck everyone!`,
+			expected: Output{
+				Input: common.SignalCheckInput{
+					BaseAsset:   "ADA",
+					QuoteAsset:  "USDT",
+					IsShort:     true,
+					Entries:     []common.JsonFloat64{f(0.5)},
+					TakeProfits: []common.JsonFloat64{f(0.48), f(0.45)},
+					StopLoss:    f(0.53),
+				},
+				Unparsed: []string{"Good luck everyone!"},
+				Missing:  []string{},
+				Warnings: []string{"only one entry price was found, but entries must be a range of at least two prices (e.g. [30000, 29500])"},
+			},
+		},
+		{
+			name:    "Missing fields",
+			message: "Buy LINK now at market, targets 10 12",
+			expected: Output{
+				Input: common.SignalCheckInput{
+					Entries:     []common.JsonFloat64{},
+					TakeProfits: []common.JsonFloat64{f(10), f(12)},
+				},
+				// LINK has no quote asset, so it's not understood as a pair.
+				Unparsed: []string{"Buy LINK now at market,"},
+				Missing:  []string{"pair", "entries", "stopLoss"},
+				Warnings: []string{},
+			},
+		},
+	}
+	for _, ts := range tss {
+		t.Run(ts.name, func(t *testing.T) {
+			actual := Parse(ts.message)
+			if !reflect.DeepEqual(ts.expected, actual) {
+				t.Fatalf("expected %+v but got %+v", ts.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/marianogappa/signal-checker/common"
+	"github.com/marianogappa/signal-checker/signalchecker"
+)
+
+// streamHeartbeatInterval is how often a check's stream sends its progress.
+const streamHeartbeatInterval = time.Second
+
+// streamMessage is a server-sent event, named "event", "progress" or "output".
+type streamMessage struct {
+	name string
+	data interface{}
+}
+
+// serveCheckStream returns the handler of streamed checks. It takes the input either as the request's body, or on the
+// "input" query parameter (as browsers' EventSource can only send GET requests), and streams the check as server-sent
+// events: an "event" message with each of the check's events as soon as they happen, a "progress" message every
+// heartbeat, and a final "output" message with the check's output.
+func serveCheckStream(opts []signalchecker.Option, heartbeat time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var input common.SignalCheckInput
+		var err error
+		if query := r.URL.Query().Get("input"); query != "" {
+			err = json.Unmarshal([]byte(query), &input)
+		} else {
+			err = json.NewDecoder(r.Body).Decode(&input)
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+			return
+		}
+
+		var (
+			ctx          = r.Context()
+			messages     = make(chan streamMessage, 100)
+			progressLock sync.Mutex
+			progress     signalchecker.Progress
+		)
+		onEvent := func(event common.SignalCheckOutputEvent) {
+			select {
+			case messages <- streamMessage{name: "event", data: event}:
+			case <-ctx.Done():
+			}
+		}
+		onProgress := func(p signalchecker.Progress) {
+			progressLock.Lock()
+			defer progressLock.Unlock()
+			progress = p
+		}
+		go func() {
+			defer close(messages)
+			checkOpts := append(append([]signalchecker.Option{}, opts...), signalchecker.WithEvents(onEvent), signalchecker.WithProgress(onProgress))
+			output, _ := signalchecker.NewSignalChecker(input, checkOpts...).CheckContext(ctx)
+			select {
+			case messages <- streamMessage{name: "output", data: output}:
+			case <-ctx.Done():
+			}
+		}()
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.WriteHeader(http.StatusOK)
+		flusher.Flush()
+
+		ticker := time.NewTicker(heartbeat)
+		defer ticker.Stop()
+		for {
+			var message streamMessage
+			select {
+			case m, ok := <-messages:
+				if !ok {
+					return
+				}
+				message = m
+			case <-ticker.C:
+				progressLock.Lock()
+				message = streamMessage{name: "progress", data: progress}
+				progressLock.Unlock()
+			case <-ctx.Done():
+				return
+			}
+			if err := writeStreamMessage(w, message); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+func writeStreamMessage(w http.ResponseWriter, message streamMessage) error {
+	byts, err := json.Marshal(message.data)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "event: %v\ndata: %s\n\n", message.name, byts)
+	return err
+}
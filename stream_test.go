@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/marianogappa/signal-checker/common"
+	"github.com/marianogappa/signal-checker/fake"
+	"github.com/marianogappa/signal-checker/signalchecker"
+)
+
+// slowExchange takes 20ms to serve each candlestick.
+type slowExchange struct {
+	*fake.Fake
+}
+
+func (e slowExchange) BuildCandlestickIterator(ctx context.Context, baseAsset, quoteAsset string, initialISO8601 common.ISO8601) *common.CandlestickIterator {
+	it := e.Fake.BuildCandlestickIterator(ctx, baseAsset, quoteAsset, initialISO8601)
+	return common.NewCandlestickIterator(func() (common.Candlestick, error) {
+		time.Sleep(20 * time.Millisecond)
+		return it.Next()
+	})
+}
+
+type receivedMessage struct {
+	name string
+	data string
+}
+
+func TestCheckStream(t *testing.T) {
+	ts := common.ISO8601("2021-07-04T14:14:00Z")
+	tsSec, _ := ts.Seconds()
+	signalchecker.RegisterExchange("stream_test", slowExchange{fake.NewFake([]common.Candlestick{
+		{Timestamp: tsSec, LowestPrice: 1.5, HighestPrice: 1.5},
+		{Timestamp: tsSec + 60, LowestPrice: 2, HighestPrice: 2},
+		{Timestamp: tsSec + 120, LowestPrice: 3, HighestPrice: 3},
+	}, nil, nil, nil)})
+	server := httptest.NewServer(serveCheckStream(nil, 5*time.Millisecond))
+	defer server.Close()
+
+	input := `{"exchange":"stream_test","baseAsset":"BTC","quoteAsset":"USDT","entries":[2,1],"stopLoss":0.5,"takeProfits":[3],"initialISO8601":"2021-07-04T14:14:00Z","dontCalculateMaxEnterUSD":true}`
+	resp, err := http.Post(server.URL, "application/json", strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("expected an event stream, but the content type was %v", resp.Header.Get("Content-Type"))
+	}
+
+	messages := []receivedMessage{}
+	scanner := bufio.NewScanner(resp.Body)
+	message := receivedMessage{}
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "event: "):
+			message.name = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			message.data = strings.TrimPrefix(line, "data: ")
+		case line == "":
+			messages = append(messages, message)
+			message = receivedMessage{}
+		}
+	}
+
+	events := []common.SignalCheckOutputEvent{}
+	progressMessages := 0
+	for _, message := range messages[:len(messages)-1] {
+		switch message.name {
+		case "event":
+			event := common.SignalCheckOutputEvent{}
+			if err := json.Unmarshal([]byte(message.data), &event); err != nil {
+				t.Fatal(err)
+			}
+			events = append(events, event)
+		case "progress":
+			progressMessages++
+		default:
+			t.Fatalf("unexpected message before the output: %+v", message)
+		}
+	}
+	if progressMessages == 0 {
+		t.Fatal("expected progress heartbeats while checking")
+	}
+	last := messages[len(messages)-1]
+	if last.name != "output" {
+		t.Fatalf("expected the last message to be the output, but it was %+v", last)
+	}
+	output := common.SignalCheckOutput{}
+	if err := json.Unmarshal([]byte(last.data), &output); err != nil {
+		t.Fatal(err)
+	}
+	if len(output.Events) != 2 || output.HighestTakeProfit != 1 {
+		t.Fatalf("expected to enter and take profit, but the output was %+v", output)
+	}
+	if !reflect.DeepEqual(events, output.Events) {
+		t.Fatalf("expected the streamed events %+v to equal the output's %+v", events, output.Events)
+	}
+}
+
+func TestCheckStreamInvalidInput(t *testing.T) {
+	server := httptest.NewServer(serveCheckStream(nil, time.Second))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "?input=%7B")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected status code 400 but got %v", resp.StatusCode)
+	}
+}